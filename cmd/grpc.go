@@ -3,13 +3,19 @@ package cmd
 import (
 	"net"
 
+	"github.com/hilmiikhsan/library-book-service/external/proto/book"
 	"github.com/hilmiikhsan/library-book-service/helpers"
 	"google.golang.org/grpc"
 )
 
 func ServeGRPC() {
+	dependency := dependencyInject()
+
 	server := grpc.NewServer()
 
+	// register grpc services
+	book.RegisterBookServiceServer(server, dependency.BookGRPCAPI)
+
 	lis, err := net.Listen("tcp", ":"+helpers.GetEnv("GRPC_PORT", "6003"))
 	if err != nil {
 		helpers.Logger.Fatal("failed to listen grpc port: ", err)
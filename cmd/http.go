@@ -1,24 +1,38 @@
 package cmd
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
 	"github.com/hilmiikhsan/library-book-service/external"
 	"github.com/hilmiikhsan/library-book-service/helpers"
 	bookAPI "github.com/hilmiikhsan/library-book-service/internal/api/book"
 	bookBorrowedAPI "github.com/hilmiikhsan/library-book-service/internal/api/book_borrowed"
+	bookCopyAPI "github.com/hilmiikhsan/library-book-service/internal/api/book_copy"
 	bookStockAPI "github.com/hilmiikhsan/library-book-service/internal/api/book_stock"
+	bookTransferAPI "github.com/hilmiikhsan/library-book-service/internal/api/book_transfer"
 	bookUserPreferencesAPI "github.com/hilmiikhsan/library-book-service/internal/api/book_user_preferences"
+	branchAPI "github.com/hilmiikhsan/library-book-service/internal/api/branch"
 	healthCheckAPI "github.com/hilmiikhsan/library-book-service/internal/api/health_check"
 	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
 	bookRepository "github.com/hilmiikhsan/library-book-service/internal/repository/book"
 	bookBorrowedRepository "github.com/hilmiikhsan/library-book-service/internal/repository/book_borrowed"
+	bookCopyRepository "github.com/hilmiikhsan/library-book-service/internal/repository/book_copy"
 	bookStockRepository "github.com/hilmiikhsan/library-book-service/internal/repository/book_stock"
+	bookTransferRepository "github.com/hilmiikhsan/library-book-service/internal/repository/book_transfer"
 	bookUserPreferencesRepository "github.com/hilmiikhsan/library-book-service/internal/repository/book_user_preferences"
+	branchRepository "github.com/hilmiikhsan/library-book-service/internal/repository/branch"
 	bookServices "github.com/hilmiikhsan/library-book-service/internal/services/book"
 	bookBorrowedServices "github.com/hilmiikhsan/library-book-service/internal/services/book_borrowed"
+	bookCopyServices "github.com/hilmiikhsan/library-book-service/internal/services/book_copy"
 	bookStockServices "github.com/hilmiikhsan/library-book-service/internal/services/book_stock"
+	bookTransferServices "github.com/hilmiikhsan/library-book-service/internal/services/book_transfer"
 	bookUserPreferencesServices "github.com/hilmiikhsan/library-book-service/internal/services/book_user_preferences"
+	branchServices "github.com/hilmiikhsan/library-book-service/internal/services/branch"
 	healthCheckServices "github.com/hilmiikhsan/library-book-service/internal/services/health_check"
+	"github.com/hilmiikhsan/library-book-service/internal/storage"
 	"github.com/hilmiikhsan/library-book-service/internal/validator"
 	"github.com/sirupsen/logrus"
 )
@@ -35,16 +49,50 @@ func ServeHTTP() {
 	bookV1.GET("/:id", dependency.MiddlewareValidateToken, dependency.BookAPI.GetDetailBook)
 	bookV1.GET("/", dependency.MiddlewareValidateToken, dependency.BookAPI.GetListBook)
 	bookV1.PUT("/update", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.UpdateBook)
+	bookV1.PATCH("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.PatchBook)
 	bookV1.DELETE("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.DeleteBook)
 	bookV1.GET("/search", dependency.MiddlewareValidateUserToken, dependency.BookAPI.SearchBooks)
 	bookV1.GET("/recommendations", dependency.MiddlewareValidateUserToken, dependency.BookAPI.GetRecommendations)
+	bookV1.GET("/suggest", dependency.MiddlewareValidateToken, dependency.BookAPI.SuggestBooks)
+	bookV1.POST("/import", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.ImportBooks)
+	bookV1.GET("/export", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.ExportBooks)
+	bookV1.GET("/isbn/:isbn", dependency.MiddlewareValidateToken, dependency.BookAPI.GetDetailBookByIsbn)
+	bookV1.GET("/deleted", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.GetListDeletedBook)
+	bookV1.POST("/:id/restore", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.RestoreBook)
+	bookV1.POST("/works", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.CreateWork)
+	bookV1.GET("/works/:id", dependency.MiddlewareValidateToken, dependency.BookAPI.GetDetailWork)
+	bookV1.POST("/:id/cover", dependency.MiddlewareValidateAdminToken, dependency.BookAPI.UploadCover)
+	bookV1.GET("/:id/cover/:size", dependency.BookAPI.GetCover)
 
 	bookStockV1 := router.Group("/book-stock/v1")
 	bookStockV1.POST("/create", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.CreateBookStock)
 	bookStockV1.GET("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.GetDetailBookStock)
 	bookStockV1.GET("/", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.GetListBookStock)
 	bookStockV1.PUT("/update", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.UpdateBookStock)
+	bookStockV1.PATCH("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.PatchBookStock)
 	bookStockV1.DELETE("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.DeleteBookStock)
+	bookStockV1.POST("/:id/adjust", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.AdjustBookStock)
+	bookStockV1.GET("/:id/movements", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.GetListStockMovement)
+	bookStockV1.POST("/reconcile", dependency.MiddlewareValidateAdminToken, dependency.BookStockAPI.ReconcileBookStock)
+
+	bookCopyV1 := router.Group("/book-copy/v1")
+	bookCopyV1.POST("/create", dependency.MiddlewareValidateAdminToken, dependency.BookCopyAPI.CreateBookCopy)
+	bookCopyV1.GET("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookCopyAPI.GetDetailBookCopy)
+	bookCopyV1.GET("/", dependency.MiddlewareValidateAdminToken, dependency.BookCopyAPI.GetListBookCopy)
+	bookCopyV1.PATCH("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookCopyAPI.PatchBookCopy)
+	bookCopyV1.POST("/:id/withdraw", dependency.MiddlewareValidateAdminToken, dependency.BookCopyAPI.WithdrawBookCopy)
+
+	branchV1 := router.Group("/branch/v1")
+	branchV1.POST("/create", dependency.MiddlewareValidateAdminToken, dependency.BranchAPI.CreateBranch)
+	branchV1.GET("/:id", dependency.MiddlewareValidateToken, dependency.BranchAPI.GetDetailBranch)
+	branchV1.GET("/", dependency.MiddlewareValidateToken, dependency.BranchAPI.GetListBranch)
+
+	bookTransferV1 := router.Group("/book-transfer/v1")
+	bookTransferV1.POST("/create", dependency.MiddlewareValidateAdminToken, dependency.BookTransferAPI.CreateBookTransfer)
+	bookTransferV1.GET("/:id", dependency.MiddlewareValidateAdminToken, dependency.BookTransferAPI.GetDetailBookTransfer)
+	bookTransferV1.GET("/", dependency.MiddlewareValidateAdminToken, dependency.BookTransferAPI.GetListBookTransfer)
+	bookTransferV1.POST("/:id/ship", dependency.MiddlewareValidateAdminToken, dependency.BookTransferAPI.ShipBookTransfer)
+	bookTransferV1.POST("/:id/receive", dependency.MiddlewareValidateAdminToken, dependency.BookTransferAPI.ReceiveBookTransfer)
 
 	bookBorrowedV1 := router.Group("/book-borrowed/v1")
 	bookBorrowedV1.POST("/borrow", dependency.MiddlewareValidateUserToken, dependency.BookBorrowedAPI.BookBorrowed)
@@ -64,12 +112,19 @@ type Dependency struct {
 	BookRepository                interfaces.IBookRepository
 	BookStockRepository           interfaces.IBookStockRepository
 	BookBorrowedRepository        interfaces.IBookBorrowedRepository
+	BookCopyRepository            interfaces.IBookCopyRepository
+	BranchRepository              interfaces.IBranchRepository
+	BookTransferRepository        interfaces.IBookTransferRepository
 	BookUserPreferencesRepository interfaces.IBookUserPreferencesRepository
 
 	HealthcheckAPI         interfaces.IHealthcheckHandler
 	BookAPI                interfaces.IBookHandler
+	BookGRPCAPI            interfaces.IBookGRPCHandler
 	BookStockAPI           interfaces.IBookStockHandler
 	BookBorrowedAPI        interfaces.IBookBorrowedHandler
+	BookCopyAPI            interfaces.IBookCopyHandler
+	BranchAPI              interfaces.IBranchHandler
+	BookTransferAPI        interfaces.IBookTransferHandler
 	BookUserPreferencesAPI interfaces.IBookUserPreferencesHandler
 	External               interfaces.IExternal
 }
@@ -99,6 +154,21 @@ func dependencyInject() Dependency {
 		Logger: helpers.Logger,
 	}
 
+	bookCopyRepo := &bookCopyRepository.BookCopyRepository{
+		DB:     helpers.DB,
+		Logger: helpers.Logger,
+	}
+
+	branchRepo := &branchRepository.BranchRepository{
+		DB:     helpers.DB,
+		Logger: helpers.Logger,
+	}
+
+	bookTransferRepo := &bookTransferRepository.BookTransferRepository{
+		DB:     helpers.DB,
+		Logger: helpers.Logger,
+	}
+
 	bookUserPreferencesRepo := &bookUserPreferencesRepository.BookUserPreferencesRepository{
 		DB:     helpers.DB,
 		Logger: helpers.Logger,
@@ -111,9 +181,15 @@ func dependencyInject() Dependency {
 	}
 
 	bookSvc := &bookServices.BookService{
-		BookRepo: bookRepo,
-		External: external,
-		Logger:   helpers.Logger,
+		BookRepo:  bookRepo,
+		External:  external,
+		Storage:   newStorage(),
+		NameCache: helpers.NewTTLCache[string](constants.ExpandCacheTTL * time.Second),
+		Logger:    helpers.Logger,
+	}
+	bookGRPCAPI := &bookAPI.BookGRPCHandler{
+		BookService: bookSvc,
+		Validator:   validator,
 	}
 	bookAPI := &bookAPI.BookHandler{
 		BookService: bookSvc,
@@ -122,8 +198,11 @@ func dependencyInject() Dependency {
 
 	bookStockSvc := &bookStockServices.BookStockService{
 		BookStockRepo: bookStockRepo,
+		BookCopyRepo:  bookCopyRepo,
 		BookRepo:      bookRepo,
+		BranchRepo:    branchRepo,
 		Logger:        helpers.Logger,
+		DB:            helpers.DB,
 	}
 	bookStockAPI := &bookStockAPI.BookStockHandler{
 		BookStockService: bookStockSvc,
@@ -133,6 +212,9 @@ func dependencyInject() Dependency {
 	bookBorrowedSvc := &bookBorrowedServices.BookBorrowedService{
 		BookBorrowedRepo: bookBorrowedRepo,
 		BookStockRepo:    bookStockRepo,
+		BookCopyRepo:     bookCopyRepo,
+		BookRepo:         bookRepo,
+		BranchRepo:       branchRepo,
 		Logger:           helpers.Logger,
 		DB:               helpers.DB,
 	}
@@ -141,6 +223,43 @@ func dependencyInject() Dependency {
 		Validator:           validator,
 	}
 
+	bookCopySvc := &bookCopyServices.BookCopyService{
+		BookCopyRepo:     bookCopyRepo,
+		BookStockRepo:    bookStockRepo,
+		BookBorrowedRepo: bookBorrowedRepo,
+		BookRepo:         bookRepo,
+		BranchRepo:       branchRepo,
+		Logger:           helpers.Logger,
+		DB:               helpers.DB,
+	}
+	bookCopyAPI := &bookCopyAPI.BookCopyHandler{
+		BookCopyService: bookCopySvc,
+		Validator:       validator,
+	}
+
+	branchSvc := &branchServices.BranchService{
+		BranchRepo: branchRepo,
+		Logger:     helpers.Logger,
+	}
+	branchAPI := &branchAPI.BranchHandler{
+		BranchService: branchSvc,
+		Validator:     validator,
+	}
+
+	bookTransferSvc := &bookTransferServices.BookTransferService{
+		BookTransferRepo: bookTransferRepo,
+		BookStockRepo:    bookStockRepo,
+		BookCopyRepo:     bookCopyRepo,
+		BookRepo:         bookRepo,
+		BranchRepo:       branchRepo,
+		Logger:           helpers.Logger,
+		DB:               helpers.DB,
+	}
+	bookTransferAPI := &bookTransferAPI.BookTransferHandler{
+		BookTransferService: bookTransferSvc,
+		Validator:           validator,
+	}
+
 	bookUserPreferencesSvc := &bookUserPreferencesServices.BookUserPreferencesService{
 		BookUserPreferencesRepo: bookUserPreferencesRepo,
 		External:                external,
@@ -156,12 +275,39 @@ func dependencyInject() Dependency {
 		BookRepository:                bookRepo,
 		BookStockRepository:           bookStockRepo,
 		BookBorrowedRepository:        bookBorrowedRepo,
+		BookCopyRepository:            bookCopyRepo,
+		BranchRepository:              branchRepo,
+		BookTransferRepository:        bookTransferRepo,
 		BookUserPreferencesRepository: bookUserPreferencesRepo,
 		HealthcheckAPI:                healthcheckAPI,
 		BookAPI:                       bookAPI,
+		BookGRPCAPI:                   bookGRPCAPI,
 		BookStockAPI:                  bookStockAPI,
 		BookBorrowedAPI:               bookBorrowedAPI,
+		BookCopyAPI:                   bookCopyAPI,
+		BranchAPI:                     branchAPI,
+		BookTransferAPI:               bookTransferAPI,
 		BookUserPreferencesAPI:        bookUserPreferencesAPI,
 		External:                      external,
 	}
 }
+
+// newStorage picks the object storage for book covers from STORAGE_DRIVER.
+func newStorage() interfaces.IStorage {
+	if helpers.GetEnv("STORAGE_DRIVER", constants.StorageDriverLocal) == constants.StorageDriverS3 {
+		return &storage.S3Storage{
+			Endpoint:  helpers.GetEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:    helpers.GetEnv("S3_REGION", "us-east-1"),
+			Bucket:    helpers.GetEnv("S3_BUCKET", ""),
+			AccessKey: helpers.GetEnv("S3_ACCESS_KEY", ""),
+			SecretKey: helpers.GetEnv("S3_SECRET_KEY", ""),
+			Client:    &http.Client{Timeout: 30 * time.Second},
+			Logger:    helpers.Logger,
+		}
+	}
+
+	return &storage.LocalStorage{
+		BasePath: helpers.GetEnv("STORAGE_LOCAL_PATH", "./storage"),
+		Logger:   helpers.Logger,
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	bookStockServices "github.com/hilmiikhsan/library-book-service/internal/services/book_stock"
+)
+
+// RunReconcileStock prints the stock rows that drifted from their loans and
+// copies as JSON, with -fix they are corrected as well.
+func RunReconcileStock(args []string) {
+	flags := flag.NewFlagSet("reconcile-stock", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "correct the drifted stock rows")
+	flags.Parse(args)
+
+	dependency := dependencyInject()
+
+	bookStockSvc := &bookStockServices.BookStockService{
+		BookStockRepo: dependency.BookStockRepository,
+		BookCopyRepo:  dependency.BookCopyRepository,
+		BookRepo:      dependency.BookRepository,
+		BranchRepo:    dependency.BranchRepository,
+		Logger:        dependency.Logger,
+	}
+
+	res, err := bookStockSvc.ReconcileBookStock(context.Background(), *fix, "")
+	if err != nil {
+		helpers.Logger.Fatal("failed to reconcile book stock: ", err)
+	}
+
+	fmt.Println(string(helpers.MarshalJSON(res)))
+	helpers.Logger.Infof("reconcile-stock: %d drifted stock rows, fixed: %t", res.Drifted, res.Fixed)
+}
@@ -19,12 +19,188 @@ const (
 	ErrBookAlreadyBorrowed        = "book already borrowed"
 	ErrInsufficientStock          = "insufficient stock"
 	ErrBookAlreadyReturned        = "book already returned"
+	ErrImportFileIsRequired       = "import file is required"
+	ErrInvalidImportFormat        = "invalid import format, must be csv or jsonl"
+	ErrImportFileTooLarge         = "import file is too large"
+	ErrImportTooManyRows          = "import file has too many rows"
+	ErrInvalidImportHeader        = "invalid import header"
+	ErrInvalidIsbn                = "invalid isbn"
+	ErrBookHasActiveLoans         = "book has active loans"
+	ErrDeletedBookNotFound        = "deleted book not found"
+	ErrVersionIsRequired          = "version is required, send If-Match header or version field"
+	ErrInvalidIfMatch             = "invalid If-Match header"
+	ErrVersionConflict            = "resource has been modified by another request"
+	ErrInvalidMergePatch          = "invalid merge patch document"
+	ErrUnsupportedMediaType       = "unsupported media type, use application/merge-patch+json"
+	ErrWorkNotFound               = "work not found"
+	ErrCoverFileIsRequired        = "cover file is required"
+	ErrCoverFileTooLarge          = "cover file is too large"
+	ErrInvalidCoverType           = "invalid cover type, must be jpeg or png"
+	ErrInvalidCoverImage          = "invalid cover image"
+	ErrInvalidCoverSize           = "invalid cover size, must be thumbnail or large"
+	ErrCoverNotFound              = "cover not found"
+	ErrFileNotFound               = "file not found"
+	ErrInvalidPageCount           = "invalid page count"
+	ErrInvalidCursor              = "invalid cursor"
+	ErrInvalidSearchQuery         = "invalid search query"
+	ErrInvalidPublishedRange      = "invalid published date range"
+	ErrBookCopyNotFound           = "book copy not found"
+	ErrBookCopyAlreadyExist       = "book copy barcode or accession number already exist"
+	ErrBookCopyNotAvailable       = "book copy is not available"
+	ErrBookCopyOnLoan             = "book copy is on loan"
+	ErrBookCopyWithdrawn          = "book copy has been withdrawn"
+	ErrBookStockManagedByCopies   = "book stock is derived from its copies"
+	ErrBookHasLoansWithoutCopy    = "book has active loans without a copy"
+	ErrInvalidStockAdjustment     = "invalid stock adjustment"
+	ErrInvalidStockCounts         = "invalid stock counts"
+	ErrBookStockBookChanged       = "book stock cannot be moved to another book"
+	ErrStockExceedsTotal          = "available stock would exceed total stock"
+	ErrHeldStockMismatch          = "branch stock does not hold the quantity being moved"
+	ErrBranchStockModeMismatch    = "branches track the stock of the book differently"
+	ErrBranchNotFound             = "branch not found"
+	ErrBranchAlreadyExist         = "branch code already exist"
+	ErrBookCopyInTransit          = "book copy is in transit"
+	ErrBookTransferNotFound       = "book transfer not found"
+	ErrBookTransferStatus         = "book transfer is not in a status that allows this"
+	ErrInvalidBookTransfer        = "invalid book transfer"
 )
 
 const (
 	HeaderAuthorization = "Authorization"
+	HeaderETag          = "ETag"
+	HeaderIfMatch       = "If-Match"
+	MimeMergePatchJSON  = "application/merge-patch+json"
+	MimeJSON            = "application/json"
+	MimeJPEG            = "image/jpeg"
+	MimePNG             = "image/png"
 	TokenTypeAccess     = "token"
 	DateTimeFormat      = "2006-01-02"
+	SearchModeFuzzy     = "fuzzy"
 	AuthRoleUser        = "User"
 	AuthRoleAdmin       = "Admin"
 )
+
+const (
+	ImportFormatCSV       = "csv"
+	ImportFormatJSONL     = "jsonl"
+	ImportMaxFileSize     = 10 << 20
+	ImportMaxRows         = 10000
+	ImportBatchSize       = 500
+	ImportStatusCreated   = "created"
+	ImportStatusValid     = "valid"
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusRejected  = "rejected"
+)
+
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatJSONL   = "jsonl"
+	ExportFormatMARCXML = "marcxml"
+	ExportFetchSize     = 500
+)
+
+const (
+	ContributorRoleAuthor      = "author"
+	ContributorRoleCoAuthor    = "co-author"
+	ContributorRoleEditor      = "editor"
+	ContributorRoleTranslator  = "translator"
+	ContributorRoleIllustrator = "illustrator"
+)
+
+const (
+	MatchAny = "any"
+	MatchAll = "all"
+)
+
+const (
+	BookFormatHardcover = "hardcover"
+	BookFormatPaperback = "paperback"
+	BookFormatEbook     = "ebook"
+	BookFormatAudio     = "audio"
+	GroupByWork         = "work"
+)
+
+const (
+	BookSortTitle         = "title"
+	BookSortPublishedDate = "published_date"
+	BookSortCreatedAt     = "created_at"
+	BookSortPopularity    = "popularity"
+	SortDirectionAsc      = "asc"
+	SortDirectionDesc     = "desc"
+)
+
+const (
+	ExpandAuthor         = "author"
+	ExpandCategory       = "category"
+	ExpandStock          = "stock"
+	ExpandMaxConcurrency = 8
+	ExpandCacheTTL       = 60
+)
+
+const (
+	SearchQueryAnd        = "and"
+	SearchQueryOr         = "or"
+	SearchQueryNot        = "not"
+	SearchQueryTerm       = "term"
+	SearchQueryMaxTerms   = 20
+	SearchQueryMaxDepth   = 5
+	SearchFieldTitle      = "title"
+	SearchFieldAuthor     = "author"
+	SearchFieldCategory   = "category"
+	SearchFieldIsbn       = "isbn"
+	SearchFieldYear       = "year"
+	SearchFieldLanguage   = "language"
+	SearchFieldFormat     = "format"
+	SearchFieldPublisher  = "publisher"
+	SearchFieldTag        = "tag"
+	SearchQueryErrorField = "query"
+)
+
+const (
+	FacetCategory           = "category"
+	FacetAuthor             = "author"
+	FacetPublishedYear      = "published_year"
+	FacetLanguage           = "language"
+	FacetAvailability       = "availability"
+	FacetMaxValues          = 20
+	FacetYearBucketSize     = 10
+	AvailabilityAvailable   = "available"
+	AvailabilityUnavailable = "unavailable"
+)
+
+const (
+	CoverMaxFileSize   = 5 << 20
+	CoverMaxDimension  = 4000
+	CoverJPEGQuality   = 85
+	CoverCacheMaxAge   = 86400
+	CoverSizeThumbnail = "thumbnail"
+	CoverSizeLarge     = "large"
+	StorageDriverLocal = "local"
+	StorageDriverS3    = "s3"
+)
+
+const (
+	CopyStatusAvailable = "available"
+	CopyStatusOnLoan    = "on_loan"
+	CopyStatusInRepair  = "in_repair"
+	CopyStatusInTransit = "in_transit"
+	CopyStatusLost      = "lost"
+	CopyStatusWithdrawn = "withdrawn"
+)
+
+const (
+	StockMovementAcquisition = "acquisition"
+	StockMovementLoan        = "loan"
+	StockMovementReturn      = "return"
+	StockMovementTransfer    = "transfer"
+	StockMovementWriteOff    = "write_off"
+	StockMovementCorrection  = "correction"
+
+	StockMovementReasonReconcile = "stock reconciliation"
+)
+
+const (
+	TransferStatusRequested = "requested"
+	TransferStatusInTransit = "in_transit"
+	TransferStatusReceived  = "received"
+)
\ No newline at end of file
@@ -33,3 +33,12 @@ func GetEnvInt(key string, defaultValue int) int {
 	}
 	return value
 }
+
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	valueStr := GetEnv(key, strconv.FormatFloat(defaultValue, 'f', -1, 64))
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -171,6 +171,12 @@ func errorValidationHandler[T any](err error, payload *T) (int, map[string][]str
 			oneOfValues[len(oneOfValues)-1] = "atau " + oneOfValues[len(oneOfValues)-1]
 			oneOfValuesStr := strings.Join(oneOfValues, ", ")
 			message = fmt.Sprintf("%s harus salah satu dari %s.", fieldInMsg, oneOfValuesStr)
+		case "valid_isbn":
+			// message = fmt.Sprintf("%s is not a valid ISBN-10 or ISBN-13.", fieldInMsg)
+			message = fmt.Sprintf("%s bukan ISBN-10 atau ISBN-13 yang valid.", fieldInMsg)
+		case "valid_language":
+			// message = fmt.Sprintf("%s is not a valid ISO 639-1 language code.", fieldInMsg)
+			message = fmt.Sprintf("%s bukan kode bahasa ISO 639-1 yang valid.", fieldInMsg)
 		case "unique_in_slice":
 			// message = fmt.Sprintf("%s elements must be unique.", fieldInMsg)
 			message = fmt.Sprintf("elemen %s harus unik.", fieldInMsg)
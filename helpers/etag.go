@@ -0,0 +1,32 @@
+package helpers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+)
+
+// FormatETag renders a row version as a strong ETag, so it can be sent back in
+// If-Match, which only matches with the strong comparison.
+func FormatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ParseETag reads the version back from an If-Match value, accepting strong
+// and bare forms. A weak ETag never matches If-Match, so it is rejected.
+func ParseETag(etag string) (int, error) {
+	etag = strings.TrimSpace(etag)
+	if strings.HasPrefix(etag, "W/") {
+		return 0, errors.New(constants.ErrInvalidIfMatch)
+	}
+	etag = strings.Trim(etag, `"`)
+
+	version, err := strconv.Atoi(etag)
+	if err != nil || version <= 0 {
+		return 0, errors.New(constants.ErrInvalidIfMatch)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import "testing"
+
+func TestFormatETag(t *testing.T) {
+	if got := FormatETag(3); got != `"3"` {
+		t.Errorf(`FormatETag(3) = %s, want "3"`, got)
+	}
+}
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		name    string
+		etag    string
+		want    int
+		wantErr bool
+	}{
+		{name: "strong", etag: `"7"`, want: 7},
+		{name: "bare", etag: "7", want: 7},
+		{name: "surrounding spaces", etag: ` "7" `, want: 7},
+		{name: "round trip", etag: FormatETag(42), want: 42},
+		{name: "weak", etag: `W/"7"`, wantErr: true},
+		{name: "zero", etag: `"0"`, wantErr: true},
+		{name: "negative", etag: `"-1"`, wantErr: true},
+		{name: "not a number", etag: `"abc"`, wantErr: true},
+		{name: "wildcard", etag: "*", wantErr: true},
+		{name: "list", etag: `"1", "2"`, wantErr: true},
+		{name: "empty", etag: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseETag(tt.etag)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseETag(%q) = %d, want error", tt.etag, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseETag(%q) error = %v", tt.etag, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseETag(%q) = %d, want %d", tt.etag, got, tt.want)
+			}
+		})
+	}
+}
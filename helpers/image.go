@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"math"
+	"net/http"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+)
+
+// DecodeImage sniffs data as JPEG or PNG and checks its dimensions before
+// decoding, so oversized images are rejected without allocating their pixels.
+func DecodeImage(data []byte, maxDimension int) (image.Image, error) {
+	contentType := http.DetectContentType(data)
+	if contentType != constants.MimeJPEG && contentType != constants.MimePNG {
+		return nil, errors.New(constants.ErrInvalidCoverType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New(constants.ErrInvalidCoverImage)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxDimension || config.Height > maxDimension {
+		return nil, errors.New(constants.ErrInvalidCoverImage)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New(constants.ErrInvalidCoverImage)
+	}
+
+	return img, nil
+}
+
+// ResizeImage scales src down to fit within maxWidth x maxHeight keeping its
+// aspect ratio, averaging the source pixels under each target pixel. Images
+// are never scaled up and transparency is flattened onto white.
+func ResizeImage(src image.Image, maxWidth, maxHeight int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	flat := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+
+	scale := math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	if scale >= 1 {
+		return flat
+	}
+
+	dstWidth := max(1, int(math.Round(float64(width)*scale)))
+	dstHeight := max(1, int(math.Round(float64(height)*scale)))
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, max((y+1)*height/dstHeight, y*height/dstHeight+1)
+
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, max((x+1)*width/dstWidth, x*width/dstWidth+1)
+
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := flat.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += uint64(flat.Pix[offset])
+					sum[1] += uint64(flat.Pix[offset+1])
+					sum[2] += uint64(flat.Pix[offset+2])
+					sum[3] += uint64(flat.Pix[offset+3])
+					offset += 4
+				}
+			}
+
+			count := uint64((y1 - y0) * (x1 - x0))
+			offset := dst.PixOffset(x, y)
+			for i := range sum {
+				dst.Pix[offset+i] = uint8(sum[i] / count)
+			}
+		}
+	}
+
+	return dst
+}
+
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
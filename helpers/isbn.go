@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+)
+
+// NormalizeIsbn validates an ISBN-10 or ISBN-13 checksum, ignoring hyphens
+// and spaces, and returns the canonical ISBN-13 form.
+func NormalizeIsbn(isbn string) (string, error) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+
+	switch len(digits) {
+	case 10:
+		if !isValidIsbn10(digits) {
+			return "", errors.New(constants.ErrInvalidIsbn)
+		}
+
+		base := "978" + digits[:9]
+		return base + string(isbn13CheckDigit(base)), nil
+	case 13:
+		if !isDigits(digits) || (!strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979")) {
+			return "", errors.New(constants.ErrInvalidIsbn)
+		}
+
+		if isbn13CheckDigit(digits[:12]) != digits[12] {
+			return "", errors.New(constants.ErrInvalidIsbn)
+		}
+
+		return digits, nil
+	}
+
+	return "", errors.New(constants.ErrInvalidIsbn)
+}
+
+func IsValidIsbn(isbn string) bool {
+	_, err := NormalizeIsbn(isbn)
+	return err == nil
+}
+
+func isValidIsbn10(digits string) bool {
+	if !isDigits(digits[:9]) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(digits[i]-'0')
+	}
+
+	switch check := digits[9]; {
+	case check == 'X':
+		sum += 10
+	case check >= '0' && check <= '9':
+		sum += int(check - '0')
+	default:
+		return false
+	}
+
+	return sum%11 == 0
+}
+
+func isbn13CheckDigit(base string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(base[i]-'0')
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
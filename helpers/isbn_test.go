@@ -0,0 +1,54 @@
+package helpers
+
+import "testing"
+
+func TestNormalizeIsbn(t *testing.T) {
+	tests := []struct {
+		name    string
+		isbn    string
+		want    string
+		wantErr bool
+	}{
+		{name: "isbn-13", isbn: "9780306406157", want: "9780306406157"},
+		{name: "isbn-13 with hyphens", isbn: "978-0-306-40615-7", want: "9780306406157"},
+		{name: "isbn-13 with spaces", isbn: " 978 0 306 40615 7 ", want: "9780306406157"},
+		{name: "isbn-13 979 prefix", isbn: "9791090636071", want: "9791090636071"},
+		{name: "isbn-10 converted", isbn: "0-306-40615-2", want: "9780306406157"},
+		{name: "isbn-10 with x check digit", isbn: "080442957X", want: "9780804429573"},
+		{name: "isbn-10 with lower x check digit", isbn: "080442957x", want: "9780804429573"},
+		{name: "isbn-13 bad checksum", isbn: "9780306406158", wantErr: true},
+		{name: "isbn-13 bad prefix", isbn: "9770306406157", wantErr: true},
+		{name: "isbn-13 with letters", isbn: "978030640615A", wantErr: true},
+		{name: "isbn-10 bad checksum", isbn: "0306406153", wantErr: true},
+		{name: "isbn-10 x not last", isbn: "03064X6152", wantErr: true},
+		{name: "wrong length", isbn: "97803064061", wantErr: true},
+		{name: "empty", isbn: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeIsbn(tt.isbn)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NormalizeIsbn(%q) = %q, want error", tt.isbn, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeIsbn(%q) error = %v", tt.isbn, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeIsbn(%q) = %q, want %q", tt.isbn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidIsbn(t *testing.T) {
+	if !IsValidIsbn("978-0-306-40615-7") {
+		t.Error("IsValidIsbn rejected a valid isbn")
+	}
+	if IsValidIsbn("978-0-306-40615-8") {
+		t.Error("IsValidIsbn accepted an isbn with a bad checksum")
+	}
+}
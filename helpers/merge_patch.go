@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+)
+
+// DecodeMergePatch decodes a JSON Merge Patch (RFC 7386) document into dest,
+// which should use pointer fields so absent members stay nil. It returns the
+// members explicitly set to null, since those decode to nil as well.
+func DecodeMergePatch(body []byte, dest any) ([]string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, errors.New(constants.ErrInvalidMergePatch)
+	}
+
+	if err := json.Unmarshal(body, dest); err != nil {
+		return nil, errors.New(constants.ErrInvalidMergePatch)
+	}
+
+	nulls := make([]string, 0)
+	for name, value := range members {
+		if string(value) == "null" {
+			nulls = append(nulls, name)
+		}
+	}
+
+	return nulls, nil
+}
+
+// NullFieldErrors reports members that a merge patch tried to remove but that
+// cannot be empty, in the same shape as validation errors.
+func NullFieldErrors(fields []string) map[string][]string {
+	errorMessages := make(map[string][]string, len(fields))
+	for _, field := range fields {
+		// errorMessages[field] = []string{fmt.Sprintf("%s cannot be null.", strings.ReplaceAll(field, "_", " "))}
+		errorMessages[field] = []string{fmt.Sprintf("%s tidak boleh null.", strings.ReplaceAll(field, "_", " "))}
+	}
+
+	return errorMessages
+}
@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type mergePatchTarget struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	PageCount   *int    `json:"page_count"`
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantTitle *string
+		wantPages *int
+		wantNulls []string
+		wantErr   bool
+	}{
+		{name: "set member", body: `{"title":"Dune"}`, wantTitle: strPtr("Dune"), wantNulls: []string{}},
+		{name: "absent members stay nil", body: `{}`, wantNulls: []string{}},
+		{name: "null member", body: `{"description":null}`, wantNulls: []string{"description"}},
+		{name: "set and null members", body: `{"page_count":412,"title":null,"description":null}`, wantPages: intPtr(412), wantNulls: []string{"description", "title"}},
+		{name: "unknown member set to null", body: `{"isbn":null}`, wantNulls: []string{"isbn"}},
+		{name: "array", body: `[{"title":"Dune"}]`, wantErr: true},
+		{name: "null document", body: `null`, wantErr: true},
+		{name: "malformed", body: `{"title":`, wantErr: true},
+		{name: "wrong type", body: `{"page_count":"many"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := new(mergePatchTarget)
+			nulls, err := DecodeMergePatch([]byte(tt.body), dest)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("DecodeMergePatch(%s) want error", tt.body)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeMergePatch(%s) error = %v", tt.body, err)
+			}
+
+			sort.Strings(nulls)
+			if !reflect.DeepEqual(nulls, tt.wantNulls) {
+				t.Errorf("nulls = %v, want %v", nulls, tt.wantNulls)
+			}
+			if !reflect.DeepEqual(dest.Title, tt.wantTitle) {
+				t.Errorf("title = %v, want %v", dest.Title, tt.wantTitle)
+			}
+			if !reflect.DeepEqual(dest.PageCount, tt.wantPages) {
+				t.Errorf("page_count = %v, want %v", dest.PageCount, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestNullFieldErrors(t *testing.T) {
+	got := NullFieldErrors([]string{"published_date"})
+	want := map[string][]string{"published_date": {"published date tidak boleh null."}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NullFieldErrors = %v, want %v", got, want)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
@@ -20,9 +20,60 @@ func FormatNullableDate(t sql.NullTime, format string) string {
 	return ""
 }
 
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func SafeString(s *string) string {
 	if s == nil {
 		return ""
 	}
 	return *s
 }
+
+// NullableString maps an empty string to nil so it is stored as NULL.
+func NullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// NullableInt maps zero to nil so it is stored as NULL.
+func NullableInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+// SplitValues flattens repeated and comma separated query values, dropping blanks.
+func SplitValues(values []string) []string {
+	res := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				res = append(res, part)
+			}
+		}
+	}
+	return res
+}
+
+// NormalizeTags lowercases tags, collapses inner whitespace and removes duplicates.
+func NormalizeTags(tags []string) []string {
+	var (
+		res  = make([]string, 0, len(tags))
+		seen = make(map[string]bool, len(tags))
+	)
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	return res
+}
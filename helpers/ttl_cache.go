@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLCache is a small in-process cache whose entries expire after a fixed
+// time to live. Expired entries are dropped when read, and swept once the
+// cache grows past ttlCacheSweepSize.
+type TTLCache[V any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]ttlCacheItem[V]
+}
+
+const ttlCacheSweepSize = 1024
+
+type ttlCacheItem[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func NewTTLCache[V any](ttl time.Duration) *TTLCache[V] {
+	return &TTLCache[V]{
+		ttl:   ttl,
+		items: make(map[string]ttlCacheItem[V]),
+	}
+}
+
+func (c *TTLCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	if time.Now().After(item.expiresAt) {
+		delete(c.items, key)
+		var zero V
+		return zero, false
+	}
+
+	return item.value, true
+}
+
+func (c *TTLCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.items) >= ttlCacheSweepSize {
+		for k, item := range c.items {
+			if now.After(item.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+	}
+
+	c.items[key] = ttlCacheItem[V]{
+		value:     value,
+		expiresAt: now.Add(c.ttl),
+	}
+}
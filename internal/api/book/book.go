@@ -1,6 +1,7 @@
 package book
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -51,6 +52,18 @@ func (api *BookHandler) CreateBook(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrWorkNotFound) {
+			helpers.Logger.Error("handler::CreateBook - work not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrWorkNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidIsbn) {
+			helpers.Logger.Error("handler::CreateBook - invalid isbn")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIsbn))
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrIsbnAlreadyExist) {
 			helpers.Logger.Error("handler::CreateBook - isbn already exist")
 			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrIsbnAlreadyExist))
@@ -95,26 +108,81 @@ func (api *BookHandler) GetDetailBook(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BookHandler) GetDetailBookByIsbn(ctx *gin.Context) {
+	var (
+		isbn = ctx.Param("isbn")
+	)
+
+	if !helpers.IsValidIsbn(isbn) {
+		helpers.Logger.Error("handler::GetDetailBookByIsbn - Invalid ISBN format for parameter: isbn")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIsbn))
+		return
+	}
+
+	res, err := api.BookService.GetDetailBookByIsbn(ctx.Request.Context(), isbn)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			helpers.Logger.Error("handler::GetDetailBookByIsbn - Book not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetDetailBookByIsbn - Failed to get Book detail : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
 	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
 }
 
 func (api *BookHandler) GetListBook(ctx *gin.Context) {
-	pageIndexStr := ctx.Query("page")
-	pageSizeStr := ctx.Query("limit")
+	var (
+		req = new(dto.GetListBookRequest)
+	)
 
-	pageIndex, _ := strconv.Atoi(pageIndexStr)
-	pageSize, _ := strconv.Atoi(pageSizeStr)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		helpers.Logger.Error("handler::GetListBook - Failed to bind query : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
 
-	if pageIndex <= 0 {
-		pageIndex = 1
+	req.Expand = helpers.SplitValues(req.Expand)
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::GetListBook - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
 	}
 
-	if pageSize <= 0 {
-		pageSize = 10
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
 	}
 
-	res, err := api.BookService.GetListBook(ctx.Request.Context(), pageSize, pageIndex)
+	res, err := api.BookService.GetListBook(ctx.Request.Context(), req)
 	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("handler::GetListBook - Invalid cursor")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCursor))
+			return
+		}
+
+		var rangeErr *helpers.CustomError
+		if errors.As(err, &rangeErr) {
+			helpers.Logger.Error("handler::GetListBook - Invalid published date range")
+			ctx.JSON(rangeErr.Code, helpers.Error(rangeErr))
+			return
+		}
+
 		helpers.Logger.Error("handler::GetListBook - Failed to get list Book : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -147,8 +215,30 @@ func (api *BookHandler) UpdateBook(ctx *gin.Context) {
 		return
 	}
 
+	if ifMatch := ctx.GetHeader(constants.HeaderIfMatch); ifMatch != "" {
+		version, err := helpers.ParseETag(ifMatch)
+		if err != nil {
+			helpers.Logger.Error("handler::UpdateBook - Invalid If-Match header")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIfMatch))
+			return
+		}
+		req.Version = version
+	}
+
+	if req.Version == 0 {
+		helpers.Logger.Error("handler::UpdateBook - Missing version")
+		ctx.JSON(http.StatusPreconditionRequired, helpers.Error(constants.ErrVersionIsRequired))
+		return
+	}
+
 	err := api.BookService.UpdateBook(ctx.Request.Context(), req)
 	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrVersionConflict) {
+			helpers.Logger.Error("handler::UpdateBook - version conflict")
+			api.conflictBook(ctx, req.ID)
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrInvalidFormatDate) {
 			helpers.Logger.Error("handler::UpdateBook - Invalid format date")
 			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidFormatDate))
@@ -173,6 +263,18 @@ func (api *BookHandler) UpdateBook(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrWorkNotFound) {
+			helpers.Logger.Error("handler::UpdateBook - work not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrWorkNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidIsbn) {
+			helpers.Logger.Error("handler::UpdateBook - invalid isbn")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIsbn))
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrIsbnAlreadyExist) {
 			helpers.Logger.Error("handler::UpdateBook - isbn already exist")
 			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrIsbnAlreadyExist))
@@ -184,9 +286,186 @@ func (api *BookHandler) UpdateBook(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(req.Version+1))
 	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
 }
 
+// PatchBook applies a JSON Merge Patch. Only description, contributors, category_ids
+// and tags may be removed with null, the lists reset to the primary author or category.
+func (api *BookHandler) PatchBook(ctx *gin.Context) {
+	var (
+		id  = ctx.Param("id")
+		req = new(dto.PatchBookRequest)
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::PatchBook - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	if contentType := ctx.ContentType(); contentType != constants.MimeMergePatchJSON && contentType != constants.MimeJSON {
+		helpers.Logger.Error("handler::PatchBook - Unsupported content type : ", contentType)
+		ctx.JSON(http.StatusUnsupportedMediaType, helpers.Error(constants.ErrUnsupportedMediaType))
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		helpers.Logger.Error("handler::PatchBook - Failed to read request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	nulls, err := helpers.DecodeMergePatch(body, req)
+	if err != nil {
+		helpers.Logger.Error("handler::PatchBook - Failed to decode merge patch : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidMergePatch))
+		return
+	}
+
+	nonNullable := make([]string, 0)
+	for _, name := range nulls {
+		switch name {
+		case "description":
+			req.Description = new(string)
+		case "contributors":
+			req.Contributors = &[]dto.ContributorRequest{}
+		case "category_ids":
+			req.CategoryIDs = &[]string{}
+		case "tags":
+			req.Tags = &[]string{}
+		case "title", "author_id", "category_id", "isbn", "published_date":
+			nonNullable = append(nonNullable, name)
+		}
+	}
+
+	if len(nonNullable) > 0 {
+		helpers.Logger.Error("handler::PatchBook - Non nullable fields set to null")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(helpers.NullFieldErrors(nonNullable)))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::PatchBook - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	// an empty value clears a nullable column, it would not pass validation above
+	for _, name := range nulls {
+		switch name {
+		case "subtitle":
+			req.Subtitle = new(string)
+		case "original_title":
+			req.OriginalTitle = new(string)
+		case "language":
+			req.Language = new(string)
+		case "page_count":
+			req.PageCount = new(int)
+		case "work_id":
+			req.WorkID = new(string)
+		case "edition":
+			req.Edition = new(string)
+		case "format":
+			req.Format = new(string)
+		case "publisher":
+			req.Publisher = new(string)
+		}
+	}
+
+	if ifMatch := ctx.GetHeader(constants.HeaderIfMatch); ifMatch != "" {
+		version, err := helpers.ParseETag(ifMatch)
+		if err != nil {
+			helpers.Logger.Error("handler::PatchBook - Invalid If-Match header")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIfMatch))
+			return
+		}
+		req.Version = version
+	}
+
+	if req.Version == 0 {
+		helpers.Logger.Error("handler::PatchBook - Missing version")
+		ctx.JSON(http.StatusPreconditionRequired, helpers.Error(constants.ErrVersionIsRequired))
+		return
+	}
+
+	err = api.BookService.PatchBook(ctx.Request.Context(), id, req)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrVersionConflict) {
+			helpers.Logger.Error("handler::PatchBook - version conflict")
+			api.conflictBook(ctx, id)
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidFormatDate) {
+			helpers.Logger.Error("handler::PatchBook - Invalid format date")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidFormatDate))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			helpers.Logger.Error("handler::PatchBook - book not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrAuthorNotFound) {
+			helpers.Logger.Error("handler::PatchBook - author not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrAuthorNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrCategoryNotFound) {
+			helpers.Logger.Error("handler::PatchBook - category not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrCategoryNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrWorkNotFound) {
+			helpers.Logger.Error("handler::PatchBook - work not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrWorkNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidIsbn) {
+			helpers.Logger.Error("handler::PatchBook - invalid isbn")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIsbn))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrIsbnAlreadyExist) {
+			helpers.Logger.Error("handler::PatchBook - isbn already exist")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrIsbnAlreadyExist))
+			return
+		}
+
+		helpers.Logger.Error("handler::PatchBook - Failed to patch Book : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(req.Version+1))
+	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
+}
+
+// conflictBook answers a stale update with the current representation and its ETag.
+func (api *BookHandler) conflictBook(ctx *gin.Context, id string) {
+	res, err := api.BookService.GetDetailBook(ctx.Request.Context(), id)
+	if err != nil {
+		helpers.Logger.Error("handler::UpdateBook - Failed to get current Book : ", err)
+		ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrVersionConflict))
+		return
+	}
+
+	response := helpers.Error(constants.ErrVersionConflict)
+	response["data"] = res
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
+	ctx.JSON(http.StatusConflict, response)
+}
+
 func (api *BookHandler) DeleteBook(ctx *gin.Context) {
 	var (
 		id = ctx.Param("id")
@@ -204,7 +483,21 @@ func (api *BookHandler) DeleteBook(ctx *gin.Context) {
 		return
 	}
 
-	err := api.BookService.DeleteBook(ctx.Request.Context(), id)
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::DeleteBook - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::DeleteBook - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	err := api.BookService.DeleteBook(ctx.Request.Context(), id, tokenData.UserID)
 	if err != nil {
 		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
 			helpers.Logger.Error("handler::DeleteBook - book not found")
@@ -212,6 +505,12 @@ func (api *BookHandler) DeleteBook(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBookHasActiveLoans) {
+			helpers.Logger.Error("handler::DeleteBook - book has active loans")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookHasActiveLoans))
+			return
+		}
+
 		helpers.Logger.Error("handler::DeleteBook - Failed to delete book : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -220,17 +519,87 @@ func (api *BookHandler) DeleteBook(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
 }
 
+func (api *BookHandler) RestoreBook(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if id == "" {
+		helpers.Logger.Error("handler::RestoreBook - Missing required parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error("missing required parameter: id"))
+		return
+	}
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::RestoreBook - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	err := api.BookService.RestoreBook(ctx.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrDeletedBookNotFound) {
+			helpers.Logger.Error("handler::RestoreBook - deleted book not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrDeletedBookNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::RestoreBook - Failed to restore book : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
+}
+
+func (api *BookHandler) GetListDeletedBook(ctx *gin.Context) {
+	pageIndexStr := ctx.Query("page")
+	pageSizeStr := ctx.Query("limit")
+
+	pageIndex, _ := strconv.Atoi(pageIndexStr)
+	pageSize, _ := strconv.Atoi(pageSizeStr)
+
+	if pageIndex <= 0 {
+		pageIndex = 1
+	}
+
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	res, err := api.BookService.GetListDeletedBook(ctx.Request.Context(), pageSize, pageIndex)
+	if err != nil {
+		helpers.Logger.Error("handler::GetListDeletedBook - Failed to get list deleted Book : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
 func (api *BookHandler) SearchBooks(ctx *gin.Context) {
 	var (
 		req = new(dto.SearchBookRequest)
 	)
 
-	if err := ctx.ShouldBindJSON(req); err != nil {
-		helpers.Logger.Error("handler::SearchBooks - Failed to bind request : ", err)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		helpers.Logger.Error("handler::SearchBooks - Failed to bind query : ", err)
 		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
 		return
 	}
 
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			helpers.Logger.Error("handler::SearchBooks - Failed to bind request : ", err)
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+			return
+		}
+	}
+
+	req.CategoryIDs = helpers.SplitValues(req.CategoryIDs)
+	req.Tags = helpers.SplitValues(req.Tags)
+	req.Expand = helpers.SplitValues(req.Expand)
+
 	if err := api.Validator.Validate(req); err != nil {
 		helpers.Logger.Error("handler::SearchBooks - Failed to validate request : ", err)
 		code, errs := helpers.Errors(err, req)
@@ -247,6 +616,20 @@ func (api *BookHandler) SearchBooks(ctx *gin.Context) {
 
 	res, err := api.BookService.SearchBooks(ctx.Request.Context(), req)
 	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("handler::SearchBooks - Invalid cursor")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCursor))
+			return
+		}
+
+		// query errors carry the offending field and position of the query
+		var queryErr *helpers.CustomError
+		if errors.As(err, &queryErr) {
+			helpers.Logger.Error("handler::SearchBooks - Invalid search query")
+			ctx.JSON(queryErr.Code, helpers.Error(queryErr))
+			return
+		}
+
 		helpers.Logger.Error("handler::SearchBooks - Failed to search books : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -284,8 +667,18 @@ func (api *BookHandler) GetRecommendations(ctx *gin.Context) {
 		return
 	}
 
-	res, err := api.BookService.GetRecommendations(ctx.Request.Context(), tokenData.UserID, pageSize, pageIndex)
+	res, err := api.BookService.GetRecommendations(ctx.Request.Context(), tokenData.UserID, &dto.PaginationRequest{
+		Page:   pageIndex,
+		Limit:  pageSize,
+		Cursor: ctx.Query("cursor"),
+	})
 	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("handler::GetRecommendations - Invalid cursor")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCursor))
+			return
+		}
+
 		helpers.Logger.Error("handler::GetRecommendations - Failed to get recommendations : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -293,3 +686,37 @@ func (api *BookHandler) GetRecommendations(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
 }
+
+func (api *BookHandler) SuggestBooks(ctx *gin.Context) {
+	var (
+		req = new(dto.SuggestBookRequest)
+	)
+
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		helpers.Logger.Error("handler::SuggestBooks - Failed to bind request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	req.Prefix = strings.TrimSpace(req.Prefix)
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::SuggestBooks - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	res, err := api.BookService.SuggestBooks(ctx.Request.Context(), req)
+	if err != nil {
+		helpers.Logger.Error("handler::SuggestBooks - Failed to suggest books : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
@@ -0,0 +1,317 @@
+package book
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/external/proto/book"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/validator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type BookGRPCHandler struct {
+	book.UnimplementedBookServiceServer
+	BookService interfaces.IBookService
+	Validator   *validator.Validator
+}
+
+func (api *BookGRPCHandler) GetDetailBook(ctx context.Context, req *book.BookRequest) (*book.BookResponse, error) {
+	if !helpers.IsValidUUID(req.Id) {
+		helpers.Logger.Error("grpc::GetDetailBook - Invalid UUID format for parameter: id")
+		return nil, status.Error(codes.InvalidArgument, constants.ErrIdIsNotValidUUID)
+	}
+
+	res, err := api.BookService.GetDetailBook(ctx, req.Id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			helpers.Logger.Error("grpc::GetDetailBook - Book not found")
+			return nil, status.Error(codes.NotFound, constants.ErrBookNotFound)
+		}
+
+		helpers.Logger.Error("grpc::GetDetailBook - Failed to get Book detail : ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &book.BookResponse{
+		Message: constants.SuccessMessage,
+		Data: &book.BookDetailData{
+			Id:    res.ID,
+			Title: res.Title,
+			Author: &book.AuthorData{
+				Id:   res.Author.ID,
+				Name: res.Author.Name,
+			},
+			Category: &book.CategoryData{
+				Id:   res.Category.ID,
+				Name: res.Category.Name,
+			},
+			Description:   res.Description,
+			Isbn:          res.Isbn,
+			Stock:         int32(res.Stock),
+			PublishedDate: res.PublishedDate,
+			CreatedAt:     res.CreatedAt,
+			UpdatedAt:     res.UpdatedAt,
+			Contributors:  mapContributorData(res.Contributors),
+			Categories:    mapCategoryData(res.Categories),
+			Tags:          res.Tags,
+			WorkId:        res.WorkID,
+			Edition:       res.Edition,
+			Format:        res.Format,
+			Publisher:     res.Publisher,
+			Cover:         mapCoverData(res.Cover),
+			Subtitle:      res.Subtitle,
+			OriginalTitle: res.OriginalTitle,
+			Language:      res.Language,
+			PageCount:     int32(res.PageCount),
+			StockInfo: &book.StockInfoData{
+				TotalStock:           int32(res.StockInfo.TotalStock),
+				AvailableStock:       int32(res.StockInfo.AvailableStock),
+				ActiveLoans:          int32(res.StockInfo.ActiveLoans),
+				ExpectedAvailability: res.StockInfo.ExpectedAvailability,
+				Branches:             mapBranchStockData(res.StockInfo.Branches),
+			},
+		},
+	}, nil
+}
+
+func (api *BookGRPCHandler) GetListBook(ctx context.Context, req *book.ListBookRequest) (*book.ListBookResponse, error) {
+	listReq := &dto.GetListBookRequest{
+		Page:   int(req.Page),
+		Limit:  int(req.Limit),
+		Cursor: req.Cursor,
+	}
+
+	if err := api.Validator.Validate(listReq); err != nil {
+		helpers.Logger.Error("grpc::GetListBook - Failed to validate request : ", err)
+		return nil, invalidArgument(err, listReq)
+	}
+
+	if listReq.Page <= 0 {
+		listReq.Page = 1
+	}
+
+	if listReq.Limit <= 0 {
+		listReq.Limit = 10
+	}
+
+	res, err := api.BookService.GetListBook(ctx, listReq)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("grpc::GetListBook - Invalid cursor")
+			return nil, status.Error(codes.InvalidArgument, constants.ErrInvalidCursor)
+		}
+
+		helpers.Logger.Error("grpc::GetListBook - Failed to get list Book : ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &book.ListBookResponse{
+		Message: constants.SuccessMessage,
+		Data:    mapListBookData(res),
+	}, nil
+}
+
+func (api *BookGRPCHandler) SearchBooks(ctx context.Context, req *book.SearchBooksRequest) (*book.ListBookResponse, error) {
+	searchReq := &dto.SearchBookRequest{
+		Q:             req.Q,
+		Query:         req.Query,
+		Title:         req.Title,
+		AuthorID:      req.AuthorId,
+		CategoryID:    req.CategoryId,
+		CategoryIDs:   req.CategoryIds,
+		CategoryMatch: req.CategoryMatch,
+		Tags:          req.Tags,
+		TagMatch:      req.TagMatch,
+		GroupBy:       req.GroupBy,
+		Language:      req.Language,
+		Format:        req.Format,
+		Publisher:     req.Publisher,
+		Page:          int(req.Page),
+		Limit:         int(req.Limit),
+		Cursor:        req.Cursor,
+	}
+
+	if err := api.Validator.Validate(searchReq); err != nil {
+		helpers.Logger.Error("grpc::SearchBooks - Failed to validate request : ", err)
+		return nil, invalidArgument(err, searchReq)
+	}
+
+	if searchReq.Page <= 0 {
+		searchReq.Page = 1
+	}
+	if searchReq.Limit <= 0 {
+		searchReq.Limit = 10
+	}
+
+	res, err := api.BookService.SearchBooks(ctx, searchReq)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("grpc::SearchBooks - Invalid cursor")
+			return nil, status.Error(codes.InvalidArgument, constants.ErrInvalidCursor)
+		}
+
+		var queryErr *helpers.CustomError
+		if errors.As(err, &queryErr) {
+			helpers.Logger.Error("grpc::SearchBooks - Invalid search query")
+			return nil, status.Error(codes.InvalidArgument, formatErrors(queryErr.Msg, queryErr.Errors))
+		}
+
+		helpers.Logger.Error("grpc::SearchBooks - Failed to search books : ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &book.ListBookResponse{
+		Message: constants.SuccessMessage,
+		Data:    mapListBookData(res),
+	}, nil
+}
+
+func (api *BookGRPCHandler) GetBooksByIDs(ctx context.Context, req *book.BooksByIDsRequest) (*book.BooksByIDsResponse, error) {
+	idsReq := &dto.GetBooksByIDsRequest{
+		IDs: req.Ids,
+	}
+
+	if err := api.Validator.Validate(idsReq); err != nil {
+		helpers.Logger.Error("grpc::GetBooksByIDs - Failed to validate request : ", err)
+		return nil, invalidArgument(err, idsReq)
+	}
+
+	if len(idsReq.IDs) == 0 {
+		return &book.BooksByIDsResponse{
+			Message: constants.SuccessMessage,
+		}, nil
+	}
+
+	res, err := api.BookService.GetBooksByIDs(ctx, idsReq.IDs)
+	if err != nil {
+		helpers.Logger.Error("grpc::GetBooksByIDs - Failed to get books by ids : ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &book.BooksByIDsResponse{
+		Message: constants.SuccessMessage,
+		Data:    mapBookData(res),
+	}, nil
+}
+
+// invalidArgument answers a request that failed validation with the same
+// field messages the HTTP handlers return, flattened into the status message.
+func invalidArgument[T any](err error, req *T) error {
+	_, errs := helpers.Errors(err, req)
+
+	fields, ok := errs.(map[string][]string)
+	if !ok {
+		return status.Error(codes.InvalidArgument, constants.ErrFailedBadRequest)
+	}
+
+	return status.Error(codes.InvalidArgument, formatErrors(constants.ErrFailedBadRequest, fields))
+}
+
+func formatErrors(msg string, fields map[string][]string) string {
+	if len(fields) == 0 {
+		return msg
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %s", name, strings.Join(fields[name], ", ")))
+	}
+
+	return msg + ": " + strings.Join(parts, "; ")
+}
+
+func mapListBookData(res *dto.GetListBookResponse) *book.ListBookData {
+	return &book.ListBookData{
+		BookList: mapBookData(res.BookList),
+		Pagination: &book.Pagination{
+			Page:       int32(res.Pagination.Page),
+			Limit:      int32(res.Pagination.Limit),
+			TotalItems: int32(res.Pagination.TotalItems),
+			TotalPages: int32(res.Pagination.TotalPages),
+			NextCursor: res.Pagination.NextCursor,
+			PrevCursor: res.Pagination.PrevCursor,
+		},
+	}
+}
+
+func mapBookData(books []dto.Book) []*book.BookData {
+	data := make([]*book.BookData, 0, len(books))
+	for _, b := range books {
+		data = append(data, &book.BookData{
+			Id:            b.ID,
+			Title:         b.Title,
+			Description:   b.Description,
+			Isbn:          b.Isbn,
+			PublishedDate: b.PublishedDate,
+			WorkId:        b.WorkID,
+			EditionCount:  int32(b.EditionCount),
+			Cover:         mapCoverData(b.Cover),
+		})
+	}
+
+	return data
+}
+
+func mapContributorData(contributors []dto.Contributor) []*book.ContributorData {
+	data := make([]*book.ContributorData, 0, len(contributors))
+	for _, c := range contributors {
+		data = append(data, &book.ContributorData{
+			Id:   c.ID,
+			Name: c.Name,
+			Role: c.Role,
+		})
+	}
+
+	return data
+}
+
+func mapCategoryData(categories []dto.Category) []*book.CategoryData {
+	data := make([]*book.CategoryData, 0, len(categories))
+	for _, c := range categories {
+		data = append(data, &book.CategoryData{
+			Id:   c.ID,
+			Name: c.Name,
+		})
+	}
+
+	return data
+}
+
+func mapBranchStockData(branches []dto.BranchStockInfo) []*book.BranchStockData {
+	data := make([]*book.BranchStockData, 0, len(branches))
+	for _, b := range branches {
+		data = append(data, &book.BranchStockData{
+			BranchId:       b.Branch.ID,
+			BranchName:     b.Branch.Name,
+			TotalStock:     int32(b.TotalStock),
+			AvailableStock: int32(b.AvailableStock),
+		})
+	}
+
+	return data
+}
+
+func mapCoverData(cover *dto.Cover) *book.CoverData {
+	if cover == nil {
+		return nil
+	}
+
+	return &book.CoverData{
+		Thumbnail: cover.Thumbnail,
+		Large:     cover.Large,
+	}
+}
@@ -0,0 +1,124 @@
+package book
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+)
+
+func (api *BookHandler) UploadCover(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::UploadCover - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		helpers.Logger.Error("handler::UploadCover - Failed to get cover file : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrCoverFileIsRequired))
+		return
+	}
+
+	if fileHeader.Size > constants.CoverMaxFileSize {
+		helpers.Logger.Error("handler::UploadCover - Cover file is too large")
+		ctx.JSON(http.StatusRequestEntityTooLarge, helpers.Error(constants.ErrCoverFileTooLarge))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		helpers.Logger.Error("handler::UploadCover - Failed to open cover file : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrCoverFileIsRequired))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, constants.CoverMaxFileSize))
+	if err != nil {
+		helpers.Logger.Error("handler::UploadCover - Failed to read cover file : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrCoverFileIsRequired))
+		return
+	}
+
+	res, err := api.BookService.UploadCover(ctx.Request.Context(), id, data)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			helpers.Logger.Error("handler::UploadCover - Book not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidCoverType) {
+			helpers.Logger.Error("handler::UploadCover - Invalid cover type")
+			ctx.JSON(http.StatusUnsupportedMediaType, helpers.Error(constants.ErrInvalidCoverType))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidCoverImage) {
+			helpers.Logger.Error("handler::UploadCover - Invalid cover image")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCoverImage))
+			return
+		}
+
+		helpers.Logger.Error("handler::UploadCover - Failed to upload cover : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BookHandler) GetCover(ctx *gin.Context) {
+	var (
+		id   = ctx.Param("id")
+		size = ctx.Param("size")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::GetCover - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	res, err := api.BookService.GetCover(ctx.Request.Context(), id, size)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCoverSize) {
+			helpers.Logger.Error("handler::GetCover - Invalid cover size")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCoverSize))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			helpers.Logger.Error("handler::GetCover - Book not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrCoverNotFound) {
+			helpers.Logger.Error("handler::GetCover - Cover not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrCoverNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetCover - Failed to get cover : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	// ServeContent answers If-None-Match and If-Modified-Since with 304
+	ctx.Header("Content-Type", res.ContentType)
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", constants.CoverCacheMaxAge))
+	ctx.Header(constants.HeaderETag, fmt.Sprintf(`"%d-%s"`, res.UpdatedAt.Unix(), size))
+	http.ServeContent(ctx.Writer, ctx.Request, "", res.UpdatedAt, bytes.NewReader(res.Data))
+}
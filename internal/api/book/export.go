@@ -0,0 +1,63 @@
+package book
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+)
+
+var exportContentTypes = map[string]string{
+	constants.ExportFormatCSV:     "text/csv; charset=utf-8",
+	constants.ExportFormatJSONL:   "application/x-ndjson",
+	constants.ExportFormatMARCXML: "application/marcxml+xml",
+}
+
+var exportExtensions = map[string]string{
+	constants.ExportFormatCSV:     "csv",
+	constants.ExportFormatJSONL:   "jsonl",
+	constants.ExportFormatMARCXML: "xml",
+}
+
+func (api *BookHandler) ExportBooks(ctx *gin.Context) {
+	var (
+		req = new(dto.ExportBookRequest)
+	)
+
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		helpers.Logger.Error("handler::ExportBooks - Failed to bind request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::ExportBooks - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	filename := fmt.Sprintf("books-%s.%s", time.Now().Format("20060102150405"), exportExtensions[req.Format])
+
+	ctx.Header("Content-Type", exportContentTypes[req.Format])
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Status(http.StatusOK)
+
+	err := api.BookService.ExportBooks(ctx.Request.Context(), req, ctx.Writer)
+	if err != nil {
+		helpers.Logger.Error("handler::ExportBooks - Failed to export books : ", err)
+
+		// once streaming has started the status line is already sent, so the error can only be logged,
+		// before that the export headers are dropped so the error goes out as JSON
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+			ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		}
+		return
+	}
+}
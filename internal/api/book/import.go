@@ -0,0 +1,201 @@
+package book
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+)
+
+func (api *BookHandler) ImportBooks(ctx *gin.Context) {
+	dryRun, _ := strconv.ParseBool(ctx.Query("dry_run"))
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		helpers.Logger.Error("handler::ImportBooks - Failed to get import file : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrImportFileIsRequired))
+		return
+	}
+
+	if fileHeader.Size > constants.ImportMaxFileSize {
+		helpers.Logger.Error("handler::ImportBooks - Import file is too large")
+		ctx.JSON(http.StatusRequestEntityTooLarge, helpers.Error(constants.ErrImportFileTooLarge))
+		return
+	}
+
+	format := strings.ToLower(ctx.Query("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	if format != constants.ImportFormatCSV && format != constants.ImportFormatJSONL {
+		helpers.Logger.Error("handler::ImportBooks - Invalid import format : ", format)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidImportFormat))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		helpers.Logger.Error("handler::ImportBooks - Failed to open import file : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrImportFileIsRequired))
+		return
+	}
+	defer file.Close()
+
+	var rows []dto.ImportBookRow
+	if format == constants.ImportFormatCSV {
+		rows, err = parseImportCSV(file)
+	} else {
+		rows, err = parseImportJSONL(file)
+	}
+	if err != nil {
+		helpers.Logger.Error("handler::ImportBooks - Failed to parse import file : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(err.Error()))
+		return
+	}
+
+	for i := range rows {
+		if len(rows[i].Errors) > 0 {
+			continue
+		}
+
+		if err := api.Validator.Validate(&rows[i].Request); err != nil {
+			_, errs := helpers.Errors(err, &rows[i].Request)
+			if fieldErrors, ok := errs.(map[string][]string); ok {
+				rows[i].Errors = fieldErrors
+			}
+		}
+	}
+
+	res, err := api.BookService.ImportBooks(ctx.Request.Context(), rows, dryRun)
+	if err != nil {
+		helpers.Logger.Error("handler::ImportBooks - Failed to import books : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+// parseImportCSV reads a header row naming the CreateBookRequest json fields
+// followed by one book per row. Rows are numbered from 1, excluding the header.
+func parseImportCSV(r io.Reader) ([]dto.ImportBookRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New(constants.ErrInvalidImportHeader)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	for _, name := range []string{"title", "author_id", "category_id", "isbn", "published_date"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New(constants.ErrInvalidImportHeader)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]dto.ImportBookRow, 0)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if len(rows) >= constants.ImportMaxRows {
+			return nil, errors.New(constants.ErrImportTooManyRows)
+		}
+
+		if err != nil {
+			rows = append(rows, dto.ImportBookRow{
+				Row:    line,
+				Errors: map[string][]string{"row": {err.Error()}},
+			})
+			continue
+		}
+
+		row := dto.ImportBookRow{
+			Row: line,
+			Request: dto.CreateBookRequest{
+				Title:         field(record, "title"),
+				AuthorID:      field(record, "author_id"),
+				CategoryID:    field(record, "category_id"),
+				Isbn:          field(record, "isbn"),
+				Description:   field(record, "description"),
+				PublishedDate: field(record, "published_date"),
+				WorkID:        field(record, "work_id"),
+				Edition:       field(record, "edition"),
+				Format:        field(record, "format"),
+				Publisher:     field(record, "publisher"),
+				Subtitle:      field(record, "subtitle"),
+				OriginalTitle: field(record, "original_title"),
+				Language:      field(record, "language"),
+			},
+		}
+
+		if pageCount := field(record, "page_count"); pageCount != "" {
+			row.Request.PageCount, err = strconv.Atoi(pageCount)
+			if err != nil {
+				row.Errors = map[string][]string{"page_count": {constants.ErrInvalidPageCount}}
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseImportJSONL reads one CreateBookRequest object per line, skipping blank lines.
+func parseImportJSONL(r io.Reader) ([]dto.ImportBookRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	rows := make([]dto.ImportBookRow, 0)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if len(rows) >= constants.ImportMaxRows {
+			return nil, errors.New(constants.ErrImportTooManyRows)
+		}
+
+		row := dto.ImportBookRow{Row: line}
+		if err := json.Unmarshal([]byte(text), &row.Request); err != nil {
+			row.Errors = map[string][]string{"row": {"invalid json: " + err.Error()}}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
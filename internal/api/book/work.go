@@ -0,0 +1,66 @@
+package book
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+)
+
+func (api *BookHandler) CreateWork(ctx *gin.Context) {
+	var (
+		req = new(dto.CreateWorkRequest)
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.Logger.Error("handler::CreateWork - Failed to bind request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::CreateWork - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	res, err := api.BookService.CreateWork(ctx.Request.Context(), req)
+	if err != nil {
+		helpers.Logger.Error("handler::CreateWork - Failed to create Work : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, helpers.Success(res, ""))
+}
+
+func (api *BookHandler) GetDetailWork(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::GetDetailWork - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	res, err := api.BookService.GetDetailWork(ctx.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrWorkNotFound) {
+			helpers.Logger.Error("handler::GetDetailWork - Work not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrWorkNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetDetailWork - Failed to get Work detail : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
@@ -58,6 +58,12 @@ func (api *BookBorrowedHandler) BookBorrowed(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBranchNotFound) {
+			helpers.Logger.Error("handler::BookBorrowed - Branch not found : ", err)
+			ctx.JSON(http.StatusNotFound, helpers.Error(err.Error()))
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
 			helpers.Logger.Error("handler::BookBorrowed - Book stock not found : ", err)
 			ctx.JSON(http.StatusNotFound, helpers.Error(err.Error()))
@@ -76,6 +82,18 @@ func (api *BookBorrowedHandler) BookBorrowed(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBookCopyNotFound) {
+			helpers.Logger.Error("handler::BookBorrowed - Book copy not found : ", err)
+			ctx.JSON(http.StatusNotFound, helpers.Error(err.Error()))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyNotAvailable) {
+			helpers.Logger.Error("handler::BookBorrowed - Book copy not available : ", err)
+			ctx.JSON(http.StatusConflict, helpers.Error(err.Error()))
+			return
+		}
+
 		helpers.Logger.Error("handler::BookBorrowed - Failed to borrow book : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -124,6 +142,12 @@ func (api *BookBorrowedHandler) BookReturned(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBranchNotFound) {
+			helpers.Logger.Error("handler::BookReturned - Branch not found : ", err)
+			ctx.JSON(http.StatusNotFound, helpers.Error(err.Error()))
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
 			helpers.Logger.Error("handler::BookReturned - Book borrowed not found : ", err)
 			ctx.JSON(http.StatusNotFound, helpers.Error(err.Error()))
@@ -136,6 +160,24 @@ func (api *BookBorrowedHandler) BookReturned(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrHeldStockMismatch) {
+			helpers.Logger.Error("handler::BookReturned - Held stock mismatch : ", err)
+			ctx.JSON(http.StatusConflict, helpers.Error(err.Error()))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrStockExceedsTotal) {
+			helpers.Logger.Error("handler::BookReturned - Stock exceeds total : ", err)
+			ctx.JSON(http.StatusConflict, helpers.Error(err.Error()))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBranchStockModeMismatch) {
+			helpers.Logger.Error("handler::BookReturned - Return branch tracks stock differently : ", err)
+			ctx.JSON(http.StatusConflict, helpers.Error(err.Error()))
+			return
+		}
+
 		helpers.Logger.Error("handler::BookReturned - Failed to return book : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -0,0 +1,383 @@
+package book_copy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/validator"
+)
+
+type BookCopyHandler struct {
+	BookCopyService interfaces.IBookCopyService
+	Validator       *validator.Validator
+}
+
+func (api *BookCopyHandler) CreateBookCopy(ctx *gin.Context) {
+	var (
+		req = new(dto.CreateBookCopyRequest)
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.Logger.Error("handler::CreateBookCopy - Failed to bind request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::CreateBookCopy - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::CreateBookCopy - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::CreateBookCopy - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	res, err := api.BookCopyService.CreateBookCopy(ctx.Request.Context(), req, tokenData.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			helpers.Logger.Error("handler::CreateBookCopy - book not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBranchNotFound) {
+			helpers.Logger.Error("handler::CreateBookCopy - branch not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBranchNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidFormatDate) {
+			helpers.Logger.Error("handler::CreateBookCopy - Invalid format date")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidFormatDate))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyAlreadyExist) {
+			helpers.Logger.Error("handler::CreateBookCopy - BookCopy already exist")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyAlreadyExist))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookHasLoansWithoutCopy) {
+			helpers.Logger.Error("handler::CreateBookCopy - book has loans without copy")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookHasLoansWithoutCopy))
+			return
+		}
+
+		helpers.Logger.Error("handler::CreateBookCopy - Failed to create BookCopy : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
+	ctx.JSON(http.StatusCreated, helpers.Success(res, ""))
+}
+
+func (api *BookCopyHandler) GetDetailBookCopy(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::GetDetailBookCopy - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	res, err := api.BookCopyService.GetDetailBookCopy(ctx.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookCopyNotFound) {
+			helpers.Logger.Error("handler::GetDetailBookCopy - BookCopy not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookCopyNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetDetailBookCopy - Failed to get BookCopy detail : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BookCopyHandler) GetListBookCopy(ctx *gin.Context) {
+	var (
+		req = new(dto.GetListBookCopyRequest)
+	)
+
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		helpers.Logger.Error("handler::GetListBookCopy - Failed to bind query : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::GetListBookCopy - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	res, err := api.BookCopyService.GetListBookCopy(ctx.Request.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("handler::GetListBookCopy - Invalid cursor")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCursor))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetListBookCopy - Failed to get list BookCopy : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+// PatchBookCopy applies a JSON Merge Patch, null clears acquisition_date and shelf_location.
+func (api *BookCopyHandler) PatchBookCopy(ctx *gin.Context) {
+	var (
+		id  = ctx.Param("id")
+		req = new(dto.PatchBookCopyRequest)
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::PatchBookCopy - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	if contentType := ctx.ContentType(); contentType != constants.MimeMergePatchJSON && contentType != constants.MimeJSON {
+		helpers.Logger.Error("handler::PatchBookCopy - Unsupported content type : ", contentType)
+		ctx.JSON(http.StatusUnsupportedMediaType, helpers.Error(constants.ErrUnsupportedMediaType))
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		helpers.Logger.Error("handler::PatchBookCopy - Failed to read request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	nulls, err := helpers.DecodeMergePatch(body, req)
+	if err != nil {
+		helpers.Logger.Error("handler::PatchBookCopy - Failed to decode merge patch : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidMergePatch))
+		return
+	}
+
+	nonNullable := make([]string, 0)
+	for _, name := range nulls {
+		switch name {
+		case "barcode", "accession_number", "status":
+			nonNullable = append(nonNullable, name)
+		}
+	}
+
+	if len(nonNullable) > 0 {
+		helpers.Logger.Error("handler::PatchBookCopy - Non nullable fields set to null")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(helpers.NullFieldErrors(nonNullable)))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::PatchBookCopy - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	// an empty value clears a nullable column, it would not pass validation above
+	for _, name := range nulls {
+		switch name {
+		case "acquisition_date":
+			req.AcquisitionDate = new(string)
+		case "shelf_location":
+			req.ShelfLocation = new(string)
+		}
+	}
+
+	if ifMatch := ctx.GetHeader(constants.HeaderIfMatch); ifMatch != "" {
+		version, err := helpers.ParseETag(ifMatch)
+		if err != nil {
+			helpers.Logger.Error("handler::PatchBookCopy - Invalid If-Match header")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIfMatch))
+			return
+		}
+		req.Version = version
+	}
+
+	if req.Version == 0 {
+		helpers.Logger.Error("handler::PatchBookCopy - Missing version")
+		ctx.JSON(http.StatusPreconditionRequired, helpers.Error(constants.ErrVersionIsRequired))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::PatchBookCopy - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::PatchBookCopy - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	err = api.BookCopyService.PatchBookCopy(ctx.Request.Context(), id, req, tokenData.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrVersionConflict) {
+			helpers.Logger.Error("handler::PatchBookCopy - version conflict")
+			api.conflictBookCopy(ctx, id)
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyNotFound) {
+			helpers.Logger.Error("handler::PatchBookCopy - BookCopy not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookCopyNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInvalidFormatDate) {
+			helpers.Logger.Error("handler::PatchBookCopy - Invalid format date")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidFormatDate))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyAlreadyExist) {
+			helpers.Logger.Error("handler::PatchBookCopy - BookCopy already exist")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyAlreadyExist))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyWithdrawn) {
+			helpers.Logger.Error("handler::PatchBookCopy - BookCopy withdrawn")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyWithdrawn))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyOnLoan) {
+			helpers.Logger.Error("handler::PatchBookCopy - BookCopy on loan")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyOnLoan))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyInTransit) {
+			helpers.Logger.Error("handler::PatchBookCopy - BookCopy in transit")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyInTransit))
+			return
+		}
+
+		helpers.Logger.Error("handler::PatchBookCopy - Failed to patch BookCopy : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(req.Version+1))
+	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
+}
+
+// conflictBookCopy answers a stale update with the current representation and its ETag.
+func (api *BookCopyHandler) conflictBookCopy(ctx *gin.Context, id string) {
+	res, err := api.BookCopyService.GetDetailBookCopy(ctx.Request.Context(), id)
+	if err != nil {
+		helpers.Logger.Error("handler::PatchBookCopy - Failed to get current BookCopy : ", err)
+		ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrVersionConflict))
+		return
+	}
+
+	response := helpers.Error(constants.ErrVersionConflict)
+	response["data"] = res
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
+	ctx.JSON(http.StatusConflict, response)
+}
+
+func (api *BookCopyHandler) WithdrawBookCopy(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::WithdrawBookCopy - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::WithdrawBookCopy - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::WithdrawBookCopy - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	err := api.BookCopyService.WithdrawBookCopy(ctx.Request.Context(), id, tokenData.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookCopyNotFound) {
+			helpers.Logger.Error("handler::WithdrawBookCopy - BookCopy not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookCopyNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyWithdrawn) {
+			helpers.Logger.Error("handler::WithdrawBookCopy - BookCopy already withdrawn")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyWithdrawn))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyOnLoan) {
+			helpers.Logger.Error("handler::WithdrawBookCopy - BookCopy on loan")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyOnLoan))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookCopyInTransit) {
+			helpers.Logger.Error("handler::WithdrawBookCopy - BookCopy in transit")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookCopyInTransit))
+			return
+		}
+
+		helpers.Logger.Error("handler::WithdrawBookCopy - Failed to withdraw BookCopy : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
+}
@@ -1,6 +1,7 @@
 package book_stock
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/hilmiikhsan/library-book-service/helpers"
 	"github.com/hilmiikhsan/library-book-service/internal/dto"
 	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
 	"github.com/hilmiikhsan/library-book-service/internal/validator"
 )
 
@@ -36,20 +38,53 @@ func (api *BookStockHandler) CreateBookStock(ctx *gin.Context) {
 		return
 	}
 
-	err := api.BookStockService.CreateBookStock(ctx.Request.Context(), req)
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::CreateBookStock - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::CreateBookStock - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	err := api.BookStockService.CreateBookStock(ctx.Request.Context(), req, tokenData.UserID)
 	if err != nil {
+		var countsErr *helpers.CustomError
+		if errors.As(err, &countsErr) {
+			helpers.Logger.Error("handler::CreateBookStock - Invalid stock counts")
+			ctx.JSON(countsErr.Code, helpers.Error(countsErr))
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
 			helpers.Logger.Error("handler::CreateBookStock - book not found")
 			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookNotFound))
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBranchNotFound) {
+			helpers.Logger.Error("handler::CreateBookStock - branch not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBranchNotFound))
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrBookStockAlreadyExist) {
 			helpers.Logger.Error("handler::CreateBookStock - BookStock already exist")
 			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookStockAlreadyExist))
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBookStockManagedByCopies) {
+			helpers.Logger.Error("handler::CreateBookStock - BookStock managed by copies")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookStockManagedByCopies))
+			return
+		}
+
 		helpers.Logger.Error("handler::CreateBookStock - Failed to create BookStock : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -88,6 +123,7 @@ func (api *BookStockHandler) GetDetailBookStock(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
 	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
 }
 
@@ -106,8 +142,18 @@ func (api *BookStockHandler) GetListBookStock(ctx *gin.Context) {
 		pageSize = 10
 	}
 
-	res, err := api.BookStockService.GetListBookStock(ctx.Request.Context(), pageSize, pageIndex)
+	res, err := api.BookStockService.GetListBookStock(ctx.Request.Context(), &dto.PaginationRequest{
+		Page:   pageIndex,
+		Limit:  pageSize,
+		Cursor: ctx.Query("cursor"),
+	})
 	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("handler::GetListBookStock - Invalid cursor")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCursor))
+			return
+		}
+
 		helpers.Logger.Error("handler::GetListBookStock - Failed to get list BookStock : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -140,22 +186,209 @@ func (api *BookStockHandler) UpdateBookStock(ctx *gin.Context) {
 		return
 	}
 
-	err := api.BookStockService.UpdateBookStock(ctx.Request.Context(), req)
+	if ifMatch := ctx.GetHeader(constants.HeaderIfMatch); ifMatch != "" {
+		version, err := helpers.ParseETag(ifMatch)
+		if err != nil {
+			helpers.Logger.Error("handler::UpdateBookStock - Invalid If-Match header")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIfMatch))
+			return
+		}
+		req.Version = version
+	}
+
+	if req.Version == 0 {
+		helpers.Logger.Error("handler::UpdateBookStock - Missing version")
+		ctx.JSON(http.StatusPreconditionRequired, helpers.Error(constants.ErrVersionIsRequired))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::UpdateBookStock - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::UpdateBookStock - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	err := api.BookStockService.UpdateBookStock(ctx.Request.Context(), req, tokenData.UserID)
 	if err != nil {
+		// the counts or a changed book_id
+		var updateErr *helpers.CustomError
+		if errors.As(err, &updateErr) {
+			helpers.Logger.Error("handler::UpdateBookStock - Invalid stock update")
+			ctx.JSON(updateErr.Code, helpers.Error(updateErr))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrVersionConflict) {
+			helpers.Logger.Error("handler::UpdateBookStock - version conflict")
+			api.conflictBookStock(ctx, req.ID)
+			return
+		}
+
 		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
 			helpers.Logger.Error("handler::UpdateBookStock - BookStock not found")
 			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookStockNotFound))
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBookStockManagedByCopies) {
+			helpers.Logger.Error("handler::UpdateBookStock - BookStock managed by copies")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookStockManagedByCopies))
+			return
+		}
+
 		helpers.Logger.Error("handler::UpdateBookStock - Failed to update BookStock : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
 	}
 
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(req.Version+1))
+	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
+}
+
+// PatchBookStock applies a JSON Merge Patch, stock counts cannot be removed with null.
+func (api *BookStockHandler) PatchBookStock(ctx *gin.Context) {
+	var (
+		id  = ctx.Param("id")
+		req = new(dto.PatchBookStockRequest)
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::PatchBookStock - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	if contentType := ctx.ContentType(); contentType != constants.MimeMergePatchJSON && contentType != constants.MimeJSON {
+		helpers.Logger.Error("handler::PatchBookStock - Unsupported content type : ", contentType)
+		ctx.JSON(http.StatusUnsupportedMediaType, helpers.Error(constants.ErrUnsupportedMediaType))
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		helpers.Logger.Error("handler::PatchBookStock - Failed to read request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	nulls, err := helpers.DecodeMergePatch(body, req)
+	if err != nil {
+		helpers.Logger.Error("handler::PatchBookStock - Failed to decode merge patch : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidMergePatch))
+		return
+	}
+
+	nonNullable := make([]string, 0)
+	for _, name := range nulls {
+		switch name {
+		case "total_stock", "available_stock":
+			nonNullable = append(nonNullable, name)
+		}
+	}
+
+	if len(nonNullable) > 0 {
+		helpers.Logger.Error("handler::PatchBookStock - Non nullable fields set to null")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(helpers.NullFieldErrors(nonNullable)))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::PatchBookStock - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	if ifMatch := ctx.GetHeader(constants.HeaderIfMatch); ifMatch != "" {
+		version, err := helpers.ParseETag(ifMatch)
+		if err != nil {
+			helpers.Logger.Error("handler::PatchBookStock - Invalid If-Match header")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidIfMatch))
+			return
+		}
+		req.Version = version
+	}
+
+	if req.Version == 0 {
+		helpers.Logger.Error("handler::PatchBookStock - Missing version")
+		ctx.JSON(http.StatusPreconditionRequired, helpers.Error(constants.ErrVersionIsRequired))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::PatchBookStock - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::PatchBookStock - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	err = api.BookStockService.PatchBookStock(ctx.Request.Context(), id, req, tokenData.UserID)
+	if err != nil {
+		var countsErr *helpers.CustomError
+		if errors.As(err, &countsErr) {
+			helpers.Logger.Error("handler::PatchBookStock - Invalid stock counts")
+			ctx.JSON(countsErr.Code, helpers.Error(countsErr))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrVersionConflict) {
+			helpers.Logger.Error("handler::PatchBookStock - version conflict")
+			api.conflictBookStock(ctx, id)
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
+			helpers.Logger.Error("handler::PatchBookStock - BookStock not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookStockNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockManagedByCopies) {
+			helpers.Logger.Error("handler::PatchBookStock - BookStock managed by copies")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookStockManagedByCopies))
+			return
+		}
+
+		helpers.Logger.Error("handler::PatchBookStock - Failed to patch BookStock : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(req.Version+1))
 	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
 }
 
+// conflictBookStock answers a stale update with the current representation and its ETag.
+func (api *BookStockHandler) conflictBookStock(ctx *gin.Context, id string) {
+	res, err := api.BookStockService.GetDetailBookStock(ctx.Request.Context(), id)
+	if err != nil {
+		helpers.Logger.Error("handler::UpdateBookStock - Failed to get current BookStock : ", err)
+		ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrVersionConflict))
+		return
+	}
+
+	response := helpers.Error(constants.ErrVersionConflict)
+	response["data"] = res
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
+	ctx.JSON(http.StatusConflict, response)
+}
+
 func (api *BookStockHandler) DeleteBookStock(ctx *gin.Context) {
 	var (
 		id = ctx.Param("id")
@@ -173,7 +406,21 @@ func (api *BookStockHandler) DeleteBookStock(ctx *gin.Context) {
 		return
 	}
 
-	err := api.BookStockService.DeleteBookStock(ctx.Request.Context(), id)
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::DeleteBookStock - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::DeleteBookStock - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	err := api.BookStockService.DeleteBookStock(ctx.Request.Context(), id, tokenData.UserID)
 	if err != nil {
 		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
 			helpers.Logger.Error("handler::DeleteBookStock - BookStock not found")
@@ -181,6 +428,12 @@ func (api *BookStockHandler) DeleteBookStock(ctx *gin.Context) {
 			return
 		}
 
+		if strings.Contains(err.Error(), constants.ErrBookStockManagedByCopies) {
+			helpers.Logger.Error("handler::DeleteBookStock - BookStock managed by copies")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookStockManagedByCopies))
+			return
+		}
+
 		helpers.Logger.Error("handler::DeleteBookStock - Failed to delete BookStock : ", err)
 		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
 		return
@@ -188,3 +441,155 @@ func (api *BookStockHandler) DeleteBookStock(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, helpers.Success(nil, ""))
 }
+
+// AdjustBookStock applies a signed delta to both counts and records it as a stock movement.
+func (api *BookStockHandler) AdjustBookStock(ctx *gin.Context) {
+	var (
+		id  = ctx.Param("id")
+		req = new(dto.AdjustBookStockRequest)
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::AdjustBookStock - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.Logger.Error("handler::AdjustBookStock - Failed to bind request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::AdjustBookStock - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::AdjustBookStock - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::AdjustBookStock - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	res, err := api.BookStockService.AdjustBookStock(ctx.Request.Context(), id, req, tokenData.UserID)
+	if err != nil {
+		var adjustErr *helpers.CustomError
+		if errors.As(err, &adjustErr) {
+			helpers.Logger.Error("handler::AdjustBookStock - Invalid stock adjustment")
+			ctx.JSON(adjustErr.Code, helpers.Error(adjustErr))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
+			helpers.Logger.Error("handler::AdjustBookStock - BookStock not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookStockNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockManagedByCopies) {
+			helpers.Logger.Error("handler::AdjustBookStock - BookStock managed by copies")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookStockManagedByCopies))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInsufficientStock) {
+			helpers.Logger.Error("handler::AdjustBookStock - Insufficient stock")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrInsufficientStock))
+			return
+		}
+
+		helpers.Logger.Error("handler::AdjustBookStock - Failed to adjust BookStock : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.Header(constants.HeaderETag, helpers.FormatETag(res.Version))
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BookStockHandler) GetListStockMovement(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::GetListStockMovement - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	pageIndex, _ := strconv.Atoi(ctx.Query("page"))
+	pageSize, _ := strconv.Atoi(ctx.Query("limit"))
+
+	if pageIndex <= 0 {
+		pageIndex = 1
+	}
+
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	res, err := api.BookStockService.GetListStockMovement(ctx.Request.Context(), id, &dto.PaginationRequest{
+		Page:   pageIndex,
+		Limit:  pageSize,
+		Cursor: ctx.Query("cursor"),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("handler::GetListStockMovement - Invalid cursor")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCursor))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
+			helpers.Logger.Error("handler::GetListStockMovement - BookStock not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookStockNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetListStockMovement - Failed to get list stock movement : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+// ReconcileBookStock reports stock drift, fix=true also corrects it.
+func (api *BookStockHandler) ReconcileBookStock(ctx *gin.Context) {
+	fix, _ := strconv.ParseBool(ctx.Query("fix"))
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::ReconcileBookStock - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::ReconcileBookStock - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	res, err := api.BookStockService.ReconcileBookStock(ctx.Request.Context(), fix, tokenData.UserID)
+	if err != nil {
+		helpers.Logger.Error("handler::ReconcileBookStock - Failed to reconcile BookStock : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
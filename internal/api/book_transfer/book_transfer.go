@@ -0,0 +1,297 @@
+package book_transfer
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/validator"
+)
+
+type BookTransferHandler struct {
+	BookTransferService interfaces.IBookTransferService
+	Validator           *validator.Validator
+}
+
+func (api *BookTransferHandler) CreateBookTransfer(ctx *gin.Context) {
+	var (
+		req = new(dto.CreateBookTransferRequest)
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.Logger.Error("handler::CreateBookTransfer - Failed to bind request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::CreateBookTransfer - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::CreateBookTransfer - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::CreateBookTransfer - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	res, err := api.BookTransferService.CreateBookTransfer(ctx.Request.Context(), req, tokenData.UserID)
+	if err != nil {
+		var transferErr *helpers.CustomError
+		if errors.As(err, &transferErr) {
+			helpers.Logger.Error("handler::CreateBookTransfer - Invalid book transfer")
+			ctx.JSON(transferErr.Code, helpers.Error(transferErr))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			helpers.Logger.Error("handler::CreateBookTransfer - book not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBranchNotFound) {
+			helpers.Logger.Error("handler::CreateBookTransfer - branch not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBranchNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
+			helpers.Logger.Error("handler::CreateBookTransfer - BookStock not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookStockNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBranchStockModeMismatch) {
+			helpers.Logger.Error("handler::CreateBookTransfer - destination branch tracks stock differently")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBranchStockModeMismatch))
+			return
+		}
+
+		helpers.Logger.Error("handler::CreateBookTransfer - Failed to create BookTransfer : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, helpers.Success(res, ""))
+}
+
+func (api *BookTransferHandler) GetDetailBookTransfer(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::GetDetailBookTransfer - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	res, err := api.BookTransferService.GetDetailBookTransfer(ctx.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookTransferNotFound) {
+			helpers.Logger.Error("handler::GetDetailBookTransfer - BookTransfer not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookTransferNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetDetailBookTransfer - Failed to get BookTransfer detail : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BookTransferHandler) GetListBookTransfer(ctx *gin.Context) {
+	var (
+		req = new(dto.GetListBookTransferRequest)
+	)
+
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		helpers.Logger.Error("handler::GetListBookTransfer - Failed to bind query : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::GetListBookTransfer - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	res, err := api.BookTransferService.GetListBookTransfer(ctx.Request.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrInvalidCursor) {
+			helpers.Logger.Error("handler::GetListBookTransfer - Invalid cursor")
+			ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrInvalidCursor))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetListBookTransfer - Failed to get list BookTransfer : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BookTransferHandler) ShipBookTransfer(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::ShipBookTransfer - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::ShipBookTransfer - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::ShipBookTransfer - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	res, err := api.BookTransferService.ShipBookTransfer(ctx.Request.Context(), id, tokenData.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookTransferNotFound) {
+			helpers.Logger.Error("handler::ShipBookTransfer - BookTransfer not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookTransferNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookTransferStatus) {
+			helpers.Logger.Error("handler::ShipBookTransfer - BookTransfer status conflict")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookTransferStatus))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
+			helpers.Logger.Error("handler::ShipBookTransfer - BookStock not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookStockNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInsufficientStock) {
+			helpers.Logger.Error("handler::ShipBookTransfer - Insufficient stock")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrInsufficientStock))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBranchStockModeMismatch) {
+			helpers.Logger.Error("handler::ShipBookTransfer - destination branch tracks stock differently")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBranchStockModeMismatch))
+			return
+		}
+
+		helpers.Logger.Error("handler::ShipBookTransfer - Failed to ship BookTransfer : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BookTransferHandler) ReceiveBookTransfer(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::ReceiveBookTransfer - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	token, ok := ctx.Get(constants.TokenTypeAccess)
+	if !ok {
+		helpers.Logger.Error("handler::ReceiveBookTransfer - Failed to get token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to get token"))
+		return
+	}
+
+	tokenData, ok := token.(models.TokenData)
+	if !ok {
+		helpers.Logger.Error("handler::ReceiveBookTransfer - Failed to parse token")
+		ctx.JSON(http.StatusUnauthorized, helpers.Error("Failed to parse token"))
+		return
+	}
+
+	res, err := api.BookTransferService.ReceiveBookTransfer(ctx.Request.Context(), id, tokenData.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookTransferNotFound) {
+			helpers.Logger.Error("handler::ReceiveBookTransfer - BookTransfer not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookTransferNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookTransferStatus) {
+			helpers.Logger.Error("handler::ReceiveBookTransfer - BookTransfer status conflict")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBookTransferStatus))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBookStockNotFound) {
+			helpers.Logger.Error("handler::ReceiveBookTransfer - BookStock not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBookStockNotFound))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrHeldStockMismatch) {
+			helpers.Logger.Error("handler::ReceiveBookTransfer - held stock mismatch")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrHeldStockMismatch))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrInsufficientStock) {
+			helpers.Logger.Error("handler::ReceiveBookTransfer - Insufficient stock")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrInsufficientStock))
+			return
+		}
+
+		if strings.Contains(err.Error(), constants.ErrBranchStockModeMismatch) {
+			helpers.Logger.Error("handler::ReceiveBookTransfer - destination branch tracks stock differently")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBranchStockModeMismatch))
+			return
+		}
+
+		helpers.Logger.Error("handler::ReceiveBookTransfer - Failed to receive BookTransfer : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
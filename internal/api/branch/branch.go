@@ -0,0 +1,90 @@
+package branch
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/validator"
+)
+
+type BranchHandler struct {
+	BranchService interfaces.IBranchService
+	Validator     *validator.Validator
+}
+
+func (api *BranchHandler) CreateBranch(ctx *gin.Context) {
+	var (
+		req = new(dto.CreateBranchRequest)
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.Logger.Error("handler::CreateBranch - Failed to bind request : ", err)
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrFailedBadRequest))
+		return
+	}
+
+	if err := api.Validator.Validate(req); err != nil {
+		helpers.Logger.Error("handler::CreateBranch - Failed to validate request : ", err)
+		code, errs := helpers.Errors(err, req)
+		ctx.JSON(code, helpers.Error(errs))
+		return
+	}
+
+	res, err := api.BranchService.CreateBranch(ctx.Request.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBranchAlreadyExist) {
+			helpers.Logger.Error("handler::CreateBranch - Branch already exist")
+			ctx.JSON(http.StatusConflict, helpers.Error(constants.ErrBranchAlreadyExist))
+			return
+		}
+
+		helpers.Logger.Error("handler::CreateBranch - Failed to create Branch : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, helpers.Success(res, ""))
+}
+
+func (api *BranchHandler) GetDetailBranch(ctx *gin.Context) {
+	var (
+		id = ctx.Param("id")
+	)
+
+	if !helpers.IsValidUUID(id) {
+		helpers.Logger.Error("handler::GetDetailBranch - Invalid UUID format for parameter: id")
+		ctx.JSON(http.StatusBadRequest, helpers.Error(constants.ErrIdIsNotValidUUID))
+		return
+	}
+
+	res, err := api.BranchService.GetDetailBranch(ctx.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBranchNotFound) {
+			helpers.Logger.Error("handler::GetDetailBranch - Branch not found")
+			ctx.JSON(http.StatusNotFound, helpers.Error(constants.ErrBranchNotFound))
+			return
+		}
+
+		helpers.Logger.Error("handler::GetDetailBranch - Failed to get Branch detail : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
+
+func (api *BranchHandler) GetListBranch(ctx *gin.Context) {
+	res, err := api.BranchService.GetListBranch(ctx.Request.Context())
+	if err != nil {
+		helpers.Logger.Error("handler::GetListBranch - Failed to get list Branch : ", err)
+		ctx.JSON(http.StatusInternalServerError, helpers.Error(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, helpers.Success(res, ""))
+}
@@ -4,9 +4,29 @@ type CreateBookRequest struct {
 	Title         string `json:"title" validate:"required,min=2,max=255"`
 	AuthorID      string `json:"author_id" validate:"required"`
 	CategoryID    string `json:"category_id" validate:"required"`
-	Isbn          string `json:"isbn" validate:"required"`
+	Isbn          string `json:"isbn" validate:"required,valid_isbn"`
 	Description   string `json:"description" validate:"required"`
 	PublishedDate string `json:"published_date" validate:"required"`
+
+	Subtitle      string `json:"subtitle,omitempty" validate:"omitempty,max=255"`
+	OriginalTitle string `json:"original_title,omitempty" validate:"omitempty,max=255"`
+	Language      string `json:"language,omitempty" validate:"omitempty,valid_language"`
+	PageCount     int    `json:"page_count,omitempty" validate:"omitempty,min=1,max=100000"`
+	WorkID        string `json:"work_id,omitempty" validate:"omitempty,uuid"`
+	Edition       string `json:"edition,omitempty" validate:"omitempty,max=100"`
+	Format        string `json:"format,omitempty" validate:"omitempty,oneof=hardcover paperback ebook audio"`
+	Publisher     string `json:"publisher,omitempty" validate:"omitempty,max=255"`
+
+	Contributors []ContributorRequest `json:"contributors,omitempty" validate:"omitempty,max=20,dive"`
+	CategoryIDs  []string             `json:"category_ids,omitempty" validate:"omitempty,max=10,dive,uuid"`
+	Tags         []string             `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+// ContributorRequest lists a contributor in display order. The book's author_id
+// is always kept as the first contributor with the author role.
+type ContributorRequest struct {
+	AuthorID string `json:"author_id" validate:"required,uuid"`
+	Role     string `json:"role" validate:"required,oneof=author co-author editor translator illustrator"`
 }
 
 type UpdateBookRequest struct {
@@ -14,35 +34,179 @@ type UpdateBookRequest struct {
 	Title         string `json:"title" validate:"required,min=2,max=255"`
 	AuthorID      string `json:"author_id" validate:"required"`
 	CategoryID    string `json:"category_id" validate:"required"`
-	Isbn          string `json:"isbn"`
+	Isbn          string `json:"isbn" validate:"omitempty,valid_isbn"`
 	Description   string `json:"description"`
 	PublishedDate string `json:"published_date" validate:"required"`
+	Version       int    `json:"version" validate:"omitempty,min=1"`
+
+	// the optional bibliographic fields are kept when empty, clear them with a merge patch
+	Subtitle      string `json:"subtitle,omitempty" validate:"omitempty,max=255"`
+	OriginalTitle string `json:"original_title,omitempty" validate:"omitempty,max=255"`
+	Language      string `json:"language,omitempty" validate:"omitempty,valid_language"`
+	PageCount     int    `json:"page_count,omitempty" validate:"omitempty,min=1,max=100000"`
+	WorkID        string `json:"work_id,omitempty" validate:"omitempty,uuid"`
+	Edition       string `json:"edition,omitempty" validate:"omitempty,max=100"`
+	Format        string `json:"format,omitempty" validate:"omitempty,oneof=hardcover paperback ebook audio"`
+	Publisher     string `json:"publisher,omitempty" validate:"omitempty,max=255"`
+
+	// Contributors, CategoryIDs and Tags replace the list when present, omitted keeps the current one
+	Contributors []ContributorRequest `json:"contributors,omitempty" validate:"omitempty,max=20,dive"`
+	CategoryIDs  []string             `json:"category_ids,omitempty" validate:"omitempty,max=10,dive,uuid"`
+	Tags         []string             `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+// PatchBookRequest is a JSON Merge Patch document, absent members stay nil.
+type PatchBookRequest struct {
+	Title         *string `json:"title" validate:"omitnil,min=2,max=255"`
+	AuthorID      *string `json:"author_id" validate:"omitnil,uuid"`
+	CategoryID    *string `json:"category_id" validate:"omitnil,uuid"`
+	Isbn          *string `json:"isbn" validate:"omitnil,valid_isbn"`
+	Description   *string `json:"description"`
+	PublishedDate *string `json:"published_date" validate:"omitnil,datetime=2006-01-02"`
+	Version       int     `json:"version" validate:"omitempty,min=1"`
+
+	// null clears these columns, the handler maps it to an empty value after validation
+	Subtitle      *string `json:"subtitle" validate:"omitnil,min=1,max=255"`
+	OriginalTitle *string `json:"original_title" validate:"omitnil,min=1,max=255"`
+	Language      *string `json:"language" validate:"omitnil,valid_language"`
+	PageCount     *int    `json:"page_count" validate:"omitnil,min=1,max=100000"`
+	WorkID        *string `json:"work_id" validate:"omitnil,uuid"`
+	Edition       *string `json:"edition" validate:"omitnil,min=1,max=100"`
+	Format        *string `json:"format" validate:"omitnil,oneof=hardcover paperback ebook audio"`
+	Publisher     *string `json:"publisher" validate:"omitnil,min=1,max=255"`
+
+	Contributors *[]ContributorRequest `json:"contributors" validate:"omitnil,max=20,dive"`
+	CategoryIDs  *[]string             `json:"category_ids" validate:"omitnil,max=10,dive,uuid"`
+	Tags         *[]string             `json:"tags" validate:"omitnil,max=20,dive,min=1,max=50"`
 }
 
 type GetDetailBookResponse struct {
-	ID            string   `json:"id"`
-	Title         string   `json:"title"`
-	Author        Author   `json:"author"`
-	Category      Category `json:"category"`
-	Description   string   `json:"description"`
-	Isbn          string   `json:"isbn"`
-	Stock         int      `json:"stock"`
-	PublishedDate string   `json:"published_date"`
-	CreatedAt     string   `json:"created_at"`
-	UpdatedAt     string   `json:"updated_at"`
+	ID            string        `json:"id"`
+	Title         string        `json:"title"`
+	Subtitle      string        `json:"subtitle"`
+	OriginalTitle string        `json:"original_title"`
+	Author        Author        `json:"author"`
+	Contributors  []Contributor `json:"contributors"`
+	Category      Category      `json:"category"`
+	Categories    []Category    `json:"categories"`
+	Tags          []string      `json:"tags"`
+	Description   string        `json:"description"`
+	Isbn          string        `json:"isbn"`
+	WorkID        string        `json:"work_id"`
+	Edition       string        `json:"edition"`
+	Format        string        `json:"format"`
+	Publisher     string        `json:"publisher"`
+	Language      string        `json:"language"`
+	PageCount     int           `json:"page_count"`
+	Cover         *Cover        `json:"cover"`
+	Stock         int           `json:"stock"`
+	StockInfo     BookStockInfo `json:"stock_info"`
+	PublishedDate string        `json:"published_date"`
+	CreatedAt     string        `json:"created_at"`
+	UpdatedAt     string        `json:"updated_at"`
+	Version       int           `json:"version"`
+}
+
+type BookStockInfo struct {
+	TotalStock     int `json:"total_stock"`
+	AvailableStock int `json:"available_stock"`
+	ActiveLoans    int `json:"active_loans"`
+	// ExpectedAvailability is the earliest due date among active loans, empty without loans
+	ExpectedAvailability string            `json:"expected_availability,omitempty"`
+	Branches             []BranchStockInfo `json:"branches"`
+}
+
+type BranchStockInfo struct {
+	Branch         DetailBranch `json:"branch"`
+	TotalStock     int          `json:"total_stock"`
+	AvailableStock int          `json:"available_stock"`
+}
+
+type Contributor struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type GetListBookRequest struct {
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit" validate:"omitempty,max=100"`
+	Cursor string `form:"cursor"`
+
+	// Sort is a field followed by _asc or _desc, e.g. published_date_desc
+	Sort          string `form:"sort" validate:"omitempty,oneof=title_asc title_desc published_date_asc published_date_desc created_at_asc created_at_desc popularity_asc popularity_desc"`
+	PublishedFrom string `form:"published_from" validate:"omitempty,datetime=2006-01-02"`
+	PublishedTo   string `form:"published_to" validate:"omitempty,datetime=2006-01-02"`
+	CategoryID    string `form:"category_id" validate:"omitempty,uuid"`
+	AuthorID      string `form:"author_id" validate:"omitempty,uuid"`
+	Language      string `form:"language" validate:"omitempty,valid_language"`
+	AvailableOnly bool   `form:"available_only"`
+
+	// Expand accepts repeated or comma separated values
+	Expand []string `form:"expand" validate:"omitempty,max=3,dive,oneof=author category stock"`
+}
+
+type GetBooksByIDsRequest struct {
+	IDs []string `json:"ids" validate:"omitempty,max=100,dive,uuid"`
 }
 
 type GetListBookResponse struct {
-	BookList   []Book     `json:"book_list"`
-	Pagination Pagination `json:"pagination"`
+	BookList   []Book        `json:"book_list"`
+	Pagination Pagination    `json:"pagination"`
+	Facets     *SearchFacets `json:"facets,omitempty"`
 }
 
 type Book struct {
+	ID            string         `json:"id"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	Isbn          string         `json:"isbn"`
+	PublishedDate string         `json:"published_date"`
+	WorkID        string         `json:"work_id,omitempty"`
+	EditionCount  int            `json:"edition_count,omitempty"`
+	Cover         *Cover         `json:"cover,omitempty"`
+	Rank          float64        `json:"rank,omitempty"`
+	Highlight     *BookHighlight `json:"highlight,omitempty"`
+
+	// Author, Category and Stock are only set when asked for with expand
+	Author   *BookAuthor       `json:"author,omitempty"`
+	Category *BookCategory     `json:"category,omitempty"`
+	Stock    *BookAvailability `json:"stock,omitempty"`
+}
+
+type BookAuthor struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type BookCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type BookAvailability struct {
+	TotalStock     int `json:"total_stock"`
+	AvailableStock int `json:"available_stock"`
+}
+
+type GetListDeletedBookResponse struct {
+	BookList   []DeletedBook `json:"book_list"`
+	Pagination Pagination    `json:"pagination"`
+}
+
+type DeletedBook struct {
 	ID            string `json:"id"`
 	Title         string `json:"title"`
 	Description   string `json:"description"`
 	Isbn          string `json:"isbn"`
 	PublishedDate string `json:"published_date"`
+	DeletedAt     string `json:"deleted_at"`
+	DeletedBy     string `json:"deleted_by"`
+}
+
+type BookHighlight struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
 type DetailBook struct {
@@ -51,12 +215,51 @@ type DetailBook struct {
 }
 
 type SearchBookRequest struct {
-	Title      string `json:"title,omitempty"`
-	AuthorID   string `json:"author_id,omitempty"`
-	CategoryID string `json:"category_id,omitempty"`
-	Isbn       string `json:"isbn,omitempty"`
-	Page       int    `json:"page,omitempty"`
-	Limit      int    `json:"limit,omitempty"`
+	Q string `json:"q,omitempty" form:"q" validate:"omitempty,max=255"`
+	// Query is the field-scoped syntax, e.g. author:<uuid> year:1990..1999 -format:ebook
+	Query      string  `json:"query,omitempty" form:"query" validate:"omitempty,max=500"`
+	Title      string  `json:"title,omitempty" form:"title"`
+	AuthorID   string  `json:"author_id,omitempty" form:"author_id" validate:"omitempty,uuid"`
+	CategoryID string  `json:"category_id,omitempty" form:"category_id" validate:"omitempty,uuid"`
+	Isbn       string  `json:"isbn,omitempty" form:"isbn"`
+	Mode       string  `json:"mode,omitempty" form:"mode" validate:"omitempty,oneof=exact fuzzy"`
+	Threshold  float64 `json:"threshold,omitempty" form:"threshold" validate:"omitempty,gt=0,lte=1"`
+	Page       int     `json:"page,omitempty" form:"page"`
+	Limit      int     `json:"limit,omitempty" form:"limit" validate:"omitempty,max=100"`
+	Cursor     string  `json:"cursor,omitempty" form:"cursor"`
+
+	// CategoryIDs and Tags accept repeated or comma separated values
+	CategoryIDs   []string `json:"category_ids,omitempty" form:"category_ids" validate:"omitempty,max=10,dive,uuid"`
+	CategoryMatch string   `json:"category_match,omitempty" form:"category_match" validate:"omitempty,oneof=any all"`
+	Tags          []string `json:"tags,omitempty" form:"tags" validate:"omitempty,max=20,dive,max=50"`
+	TagMatch      string   `json:"tag_match,omitempty" form:"tag_match" validate:"omitempty,oneof=any all"`
+
+	Language  string `json:"language,omitempty" form:"language" validate:"omitempty,valid_language"`
+	Format    string `json:"format,omitempty" form:"format" validate:"omitempty,oneof=hardcover paperback ebook audio"`
+	Publisher string `json:"publisher,omitempty" form:"publisher" validate:"omitempty,max=255"`
+
+	// GroupBy work returns one result per work with its best matching edition
+	GroupBy string `json:"group_by,omitempty" form:"group_by" validate:"omitempty,oneof=work"`
+
+	// Expand accepts repeated or comma separated values
+	Expand []string `json:"expand,omitempty" form:"expand" validate:"omitempty,max=3,dive,oneof=author category stock"`
+
+	// Facets adds counts over the whole result set for refining the search
+	Facets bool `json:"facets,omitempty" form:"facets"`
+}
+
+type SearchFacets struct {
+	Categories     []FacetValue `json:"categories"`
+	Authors        []FacetValue `json:"authors"`
+	PublishedYears []FacetValue `json:"published_years"`
+	Languages      []FacetValue `json:"languages"`
+	Availability   []FacetValue `json:"availability"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
 }
 
 type GetListRecommendationsResponse struct {
@@ -71,4 +274,62 @@ type Recommendations struct {
 	CategoryID    string `json:"category_id"`
 	Description   string `json:"description"`
 	PublishedDate string `json:"published_date"`
+	Cover         *Cover `json:"cover,omitempty"`
+}
+
+type SuggestBookRequest struct {
+	Prefix string `form:"prefix" validate:"required,min=2,max=100"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=20"`
+}
+
+type BookSuggestion struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Isbn  string `json:"isbn"`
+}
+
+type ImportBookRow struct {
+	Row     int
+	Request CreateBookRequest
+	Errors  map[string][]string
+}
+
+type ImportBookResponse struct {
+	DryRun   bool               `json:"dry_run"`
+	Total    int                `json:"total"`
+	Created  int                `json:"created"`
+	Valid    int                `json:"valid"`
+	Skipped  int                `json:"skipped"`
+	Rejected int                `json:"rejected"`
+	Results  []ImportBookResult `json:"results"`
+}
+
+type ImportBookResult struct {
+	Row    int                 `json:"row"`
+	Isbn   string              `json:"isbn"`
+	Status string              `json:"status"`
+	BookID string              `json:"book_id,omitempty"`
+	Errors map[string][]string `json:"errors,omitempty"`
+}
+
+type ExportBookRequest struct {
+	Format      string `form:"format" validate:"required,oneof=csv jsonl marcxml"`
+	CategoryID  string `form:"category_id" validate:"omitempty,uuid"`
+	AuthorID    string `form:"author_id" validate:"omitempty,uuid"`
+	UpdatedFrom string `form:"updated_from" validate:"omitempty,datetime=2006-01-02"`
+	UpdatedTo   string `form:"updated_to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type ExportBook struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	AuthorID       string `json:"author_id"`
+	CategoryID     string `json:"category_id"`
+	Isbn           string `json:"isbn"`
+	Description    string `json:"description"`
+	PublishedDate  string `json:"published_date"`
+	TotalStock     int    `json:"total_stock"`
+	AvailableStock int    `json:"available_stock"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
 }
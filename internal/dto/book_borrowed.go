@@ -1,8 +1,11 @@
 package dto
 
 type BookBorrowedRequest struct {
-	BookID  string `json:"book_id" validate:"required"`
-	DueDate string `json:"due_date" validate:"required"`
+	BookID   string `json:"book_id" validate:"required"`
+	BranchID string `json:"branch_id" validate:"required,uuid"`
+	DueDate  string `json:"due_date" validate:"required"`
+	// Barcode checks out that copy, without it any available copy is taken
+	Barcode string `json:"barcode" validate:"omitempty,max=64"`
 }
 
 type UpdateBookBorrowedRequest struct {
@@ -14,6 +17,10 @@ type UpdateBookBorrowedRequest struct {
 type BookReturnedRequest struct {
 	BookID       string `json:"book_id" validate:"required"`
 	ReturnedDate string `json:"returned_date" validate:"required"`
+	// BranchID is where the book is handed in, the branch it was borrowed from when empty
+	BranchID string `json:"branch_id" validate:"omitempty,uuid"`
+	// Damaged sends the returned copy to repair instead of back on the shelf
+	Damaged bool `json:"damaged"`
 }
 
 // type GetDetailBookStockResponse struct {
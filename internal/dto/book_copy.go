@@ -0,0 +1,54 @@
+package dto
+
+type CreateBookCopyRequest struct {
+	BookID          string `json:"book_id" validate:"required,uuid"`
+	BranchID        string `json:"branch_id" validate:"required,uuid"`
+	Barcode         string `json:"barcode" validate:"required,max=64"`
+	AccessionNumber string `json:"accession_number" validate:"required,max=64"`
+	AcquisitionDate string `json:"acquisition_date" validate:"omitempty,datetime=2006-01-02"`
+	ShelfLocation   string `json:"shelf_location" validate:"omitempty,max=100"`
+	// Status defaults to available, a copy can also arrive in repair
+	Status string `json:"status" validate:"omitempty,oneof=available in_repair"`
+}
+
+// PatchBookCopyRequest is a JSON Merge Patch document, absent members stay nil.
+// Copies go on loan through borrowing and leave through withdraw only.
+type PatchBookCopyRequest struct {
+	Barcode         *string `json:"barcode" validate:"omitnil,min=1,max=64"`
+	AccessionNumber *string `json:"accession_number" validate:"omitnil,min=1,max=64"`
+	Status          *string `json:"status" validate:"omitnil,oneof=available in_repair lost"`
+	Version         int     `json:"version" validate:"omitempty,min=1"`
+
+	// null clears these columns, the handler maps it to an empty value after validation
+	AcquisitionDate *string `json:"acquisition_date" validate:"omitnil,datetime=2006-01-02"`
+	ShelfLocation   *string `json:"shelf_location" validate:"omitnil,min=1,max=100"`
+}
+
+type GetListBookCopyRequest struct {
+	BookID   string `form:"book_id" validate:"omitempty,uuid"`
+	BranchID string `form:"branch_id" validate:"omitempty,uuid"`
+	Status   string `form:"status" validate:"omitempty,oneof=available on_loan in_repair in_transit lost withdrawn"`
+	Page     int    `form:"page"`
+	Limit    int    `form:"limit"`
+	Cursor   string `form:"cursor"`
+}
+
+type GetDetailBookCopyResponse struct {
+	ID              string       `json:"id"`
+	Book            DetailBook   `json:"book"`
+	Branch          DetailBranch `json:"branch"`
+	Barcode         string       `json:"barcode"`
+	AccessionNumber string       `json:"accession_number"`
+	AcquisitionDate string       `json:"acquisition_date"`
+	ShelfLocation   string       `json:"shelf_location"`
+	Status          string       `json:"status"`
+	WithdrawnAt     string       `json:"withdrawn_at,omitempty"`
+	CreatedAt       string       `json:"created_at"`
+	UpdatedAt       string       `json:"updated_at"`
+	Version         int          `json:"version"`
+}
+
+type GetListBookCopyResponse struct {
+	BookCopyList []GetDetailBookCopyResponse `json:"book_copy_list"`
+	Pagination   Pagination                  `json:"pagination"`
+}
@@ -1,23 +1,40 @@
 package dto
 
+// CreateBookStockRequest takes the counts as pointers so that an explicit 0
+// passes required while an absent member does not.
 type CreateBookStockRequest struct {
 	BookID         string `json:"book_id" validate:"required"`
-	TotalStock     int    `json:"total_stock" validate:"required,numeric"`
-	AvailableStock int    `json:"available_stock" validate:"required,numeric"`
+	BranchID       string `json:"branch_id" validate:"required,uuid"`
+	TotalStock     *int   `json:"total_stock" validate:"required,min=0"`
+	AvailableStock *int   `json:"available_stock" validate:"required,min=0"`
 }
 
 type UpdateBookStockRequest struct {
 	ID             string `json:"id" validate:"required"`
 	BookID         string `json:"book_id" validate:"required"`
-	TotalStock     int    `json:"total_stock" validate:"required,numeric"`
-	AvailableStock int    `json:"available_stock" validate:"required,numeric"`
+	TotalStock     *int   `json:"total_stock" validate:"required,min=0"`
+	AvailableStock *int   `json:"available_stock" validate:"required,min=0"`
+	Version        int    `json:"version" validate:"omitempty,min=1"`
+	// Reason is kept on the correction recorded for the change
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+// PatchBookStockRequest is a JSON Merge Patch document, absent members stay nil.
+type PatchBookStockRequest struct {
+	TotalStock     *int `json:"total_stock" validate:"omitnil,min=0"`
+	AvailableStock *int `json:"available_stock" validate:"omitnil,min=0"`
+	Version        int  `json:"version" validate:"omitempty,min=1"`
+	// Reason is kept on the correction recorded for the change
+	Reason *string `json:"reason" validate:"omitnil,max=255"`
 }
 
 type GetDetailBookStockResponse struct {
-	ID             string     `json:"id"`
-	Book           DetailBook `json:"book"`
-	TotalStock     int        `json:"total_stock"`
-	AvailableStock int        `json:"available_stock"`
+	ID             string       `json:"id"`
+	Book           DetailBook   `json:"book"`
+	Branch         DetailBranch `json:"branch"`
+	TotalStock     int          `json:"total_stock"`
+	AvailableStock int          `json:"available_stock"`
+	Version        int          `json:"version"`
 }
 
 type GetListBookStockResponse struct {
@@ -26,8 +43,52 @@ type GetListBookStockResponse struct {
 }
 
 type BookStock struct {
-	ID             string     `json:"id"`
-	Book           DetailBook `json:"book"`
-	TotalStock     int        `json:"total_stock"`
-	AvailableStock int        `json:"available_stock"`
+	ID             string       `json:"id"`
+	Book           DetailBook   `json:"book"`
+	Branch         DetailBranch `json:"branch"`
+	TotalStock     int          `json:"total_stock"`
+	AvailableStock int          `json:"available_stock"`
+}
+
+// AdjustBookStockRequest moves total_stock and available_stock together by
+// Delta, acquisitions add and write-offs remove stock.
+type AdjustBookStockRequest struct {
+	Type   string `json:"type" validate:"required,oneof=acquisition write_off correction"`
+	Delta  int    `json:"delta" validate:"required"`
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type StockMovement struct {
+	ID             string `json:"id"`
+	Type           string `json:"type"`
+	TotalDelta     int    `json:"total_delta"`
+	AvailableDelta int    `json:"available_delta"`
+	Reason         string `json:"reason"`
+	ActorID        string `json:"actor_id"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type GetListStockMovementResponse struct {
+	StockMovementList []StockMovement `json:"stock_movement_list"`
+	Pagination        Pagination      `json:"pagination"`
+}
+
+// ReconcileBookStockResponse lists the stock rows whose counts differ from the
+// ones derived from loans and copies, Fixed tells whether they were corrected.
+type ReconcileBookStockResponse struct {
+	Fixed   bool             `json:"fixed"`
+	Drifted int              `json:"drifted"`
+	Items   []BookStockDrift `json:"items"`
+}
+
+type BookStockDrift struct {
+	ID                     string       `json:"id"`
+	Book                   DetailBook   `json:"book"`
+	Branch                 DetailBranch `json:"branch"`
+	ManagedByCopies        bool         `json:"managed_by_copies"`
+	ActiveLoans            int          `json:"active_loans"`
+	TotalStock             int          `json:"total_stock"`
+	ExpectedTotalStock     int          `json:"expected_total_stock"`
+	AvailableStock         int          `json:"available_stock"`
+	ExpectedAvailableStock int          `json:"expected_available_stock"`
 }
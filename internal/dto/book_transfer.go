@@ -0,0 +1,35 @@
+package dto
+
+type CreateBookTransferRequest struct {
+	BookID              string `json:"book_id" validate:"required,uuid"`
+	SourceBranchID      string `json:"source_branch_id" validate:"required,uuid"`
+	DestinationBranchID string `json:"destination_branch_id" validate:"required,uuid"`
+	Quantity            int    `json:"quantity" validate:"required,min=1"`
+}
+
+// GetListBookTransferRequest lists the pending transfers unless a status is given.
+type GetListBookTransferRequest struct {
+	BranchID string `form:"branch_id" validate:"omitempty,uuid"`
+	Status   string `form:"status" validate:"omitempty,oneof=requested in_transit received"`
+	Page     int    `form:"page"`
+	Limit    int    `form:"limit"`
+	Cursor   string `form:"cursor"`
+}
+
+type GetDetailBookTransferResponse struct {
+	ID                string       `json:"id"`
+	Book              DetailBook   `json:"book"`
+	SourceBranch      DetailBranch `json:"source_branch"`
+	DestinationBranch DetailBranch `json:"destination_branch"`
+	Quantity          int          `json:"quantity"`
+	Status            string       `json:"status"`
+	ShippedAt         string       `json:"shipped_at,omitempty"`
+	ReceivedAt        string       `json:"received_at,omitempty"`
+	CreatedAt         string       `json:"created_at"`
+	UpdatedAt         string       `json:"updated_at"`
+}
+
+type GetListBookTransferResponse struct {
+	BookTransferList []GetDetailBookTransferResponse `json:"book_transfer_list"`
+	Pagination       Pagination                      `json:"pagination"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+type CreateBranchRequest struct {
+	Code    string `json:"code" validate:"required,max=20"`
+	Name    string `json:"name" validate:"required,max=100"`
+	Address string `json:"address" validate:"omitempty,max=255"`
+}
+
+type GetDetailBranchResponse struct {
+	ID        string `json:"id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type GetListBranchResponse struct {
+	BranchList []GetDetailBranchResponse `json:"branch_list"`
+}
+
+type DetailBranch struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
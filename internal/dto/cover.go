@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+// Cover holds the URLs of the resized cover variants, they change on every upload.
+type Cover struct {
+	Thumbnail string `json:"thumbnail"`
+	Large     string `json:"large"`
+}
+
+type CoverFile struct {
+	Data        []byte
+	ContentType string
+	UpdatedAt   time.Time
+}
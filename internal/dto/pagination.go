@@ -1,6 +1,18 @@
 package dto
 
+// PaginationRequest selects a page either by page and limit or, preferred,
+// by the opaque cursor returned in a previous Pagination.
+type PaginationRequest struct {
+	Page   int
+	Limit  int
+	Cursor string
+}
+
 type Pagination struct {
-	Page  int `json:"page"`
-	Limit int `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalItems int    `json:"total_items"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
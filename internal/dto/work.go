@@ -0,0 +1,33 @@
+package dto
+
+type CreateWorkRequest struct {
+	Title       string `json:"title" validate:"required,min=2,max=255"`
+	Description string `json:"description"`
+}
+
+type CreateWorkResponse struct {
+	ID string `json:"id"`
+}
+
+type GetDetailWorkResponse struct {
+	ID             string        `json:"id"`
+	Title          string        `json:"title"`
+	Description    string        `json:"description"`
+	TotalStock     int           `json:"total_stock"`
+	AvailableStock int           `json:"available_stock"`
+	Editions       []WorkEdition `json:"editions"`
+	CreatedAt      string        `json:"created_at"`
+	UpdatedAt      string        `json:"updated_at"`
+}
+
+type WorkEdition struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Isbn           string `json:"isbn"`
+	Edition        string `json:"edition"`
+	Format         string `json:"format"`
+	Publisher      string `json:"publisher"`
+	PublishedDate  string `json:"published_date"`
+	TotalStock     int    `json:"total_stock"`
+	AvailableStock int    `json:"available_stock"`
+}
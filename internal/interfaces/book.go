@@ -2,8 +2,11 @@ package interfaces
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/external/proto/book"
 	"github.com/hilmiikhsan/library-book-service/internal/dto"
 	"github.com/hilmiikhsan/library-book-service/internal/models"
 )
@@ -11,29 +14,75 @@ import (
 type IBookRepository interface {
 	InsertNewBook(ctx context.Context, book *models.Book) error
 	FindBookByID(ctx context.Context, id string) (*models.Book, error)
-	FindAllBook(ctx context.Context, limit, offset int) ([]models.Book, error)
+	RefreshBookCache(ctx context.Context, id string) error
+	FindBookByIsbn(ctx context.Context, isbn string) (*models.Book, error)
+	FindAllBook(ctx context.Context, filter *models.ListBookFilter, page *models.PageRequest) ([]models.Book, error)
+	CountAllBook(ctx context.Context, filter *models.ListBookFilter) (int, error)
+	FindBooksByIDs(ctx context.Context, ids []string) ([]models.Book, error)
 	UpdateNewBook(ctx context.Context, book *models.Book) error
-	DeleteBookByID(ctx context.Context, id string) error
-	SearchBooks(ctx context.Context, title *string, categoryID *string, authorID *string, limit, offset int) ([]models.Book, error)
-	GetRecommendations(ctx context.Context, userID string, limit, offset int) ([]models.Book, error)
+	PatchBookByID(ctx context.Context, patch *models.BookPatch) error
+	DeleteBookByID(ctx context.Context, id, deletedBy string) error
+	CountActiveLoansByBookID(ctx context.Context, bookID string) (int, error)
+	RestoreBookByID(ctx context.Context, id string) error
+	FindAllDeletedBook(ctx context.Context, limit, offset int) ([]models.Book, error)
+	SearchBooks(ctx context.Context, filter *models.SearchBookFilter, page *models.PageRequest) ([]models.Book, error)
+	CountSearchBooks(ctx context.Context, filter *models.SearchBookFilter) (int, error)
+	SearchBookFacets(ctx context.Context, filter *models.SearchBookFilter) ([]models.SearchFacet, error)
+	GetRecommendations(ctx context.Context, userID string, page *models.PageRequest) ([]models.Book, error)
+	CountRecommendations(ctx context.Context, userID string) (int, error)
+	SuggestBooks(ctx context.Context, prefix string, limit int) ([]models.Book, error)
+	FindExistingIsbns(ctx context.Context, isbns []string) ([]string, error)
+	InsertNewBooks(ctx context.Context, books []models.Book) ([]models.Book, error)
+	StreamBooksForExport(ctx context.Context, filter *models.ExportBookFilter, fn func(book *models.BookExport) error) error
+	InsertNewWork(ctx context.Context, work *models.Work) error
+	FindWorkByID(ctx context.Context, id string) (*models.Work, error)
+	FindEditionsByWorkID(ctx context.Context, workID string) ([]models.WorkEdition, error)
+	UpdateBookCover(ctx context.Context, id string) (time.Time, error)
 }
 
 type IBookService interface {
 	CreateBook(ctx context.Context, req *dto.CreateBookRequest) error
 	GetDetailBook(ctx context.Context, id string) (*dto.GetDetailBookResponse, error)
-	GetListBook(ctx context.Context, limit, offset int) (*dto.GetListBookResponse, error)
+	GetDetailBookByIsbn(ctx context.Context, isbn string) (*dto.GetDetailBookResponse, error)
+	GetListBook(ctx context.Context, req *dto.GetListBookRequest) (*dto.GetListBookResponse, error)
 	UpdateBook(ctx context.Context, req *dto.UpdateBookRequest) error
-	DeleteBook(ctx context.Context, id string) error
+	PatchBook(ctx context.Context, id string, req *dto.PatchBookRequest) error
+	DeleteBook(ctx context.Context, id, deletedBy string) error
+	RestoreBook(ctx context.Context, id string) error
+	GetListDeletedBook(ctx context.Context, limit, offset int) (*dto.GetListDeletedBookResponse, error)
 	SearchBooks(ctx context.Context, req *dto.SearchBookRequest) (*dto.GetListBookResponse, error)
-	GetRecommendations(ctx context.Context, userID string, limit, offset int) (*dto.GetListRecommendationsResponse, error)
+	GetRecommendations(ctx context.Context, userID string, req *dto.PaginationRequest) (*dto.GetListRecommendationsResponse, error)
+	GetBooksByIDs(ctx context.Context, ids []string) ([]dto.Book, error)
+	SuggestBooks(ctx context.Context, req *dto.SuggestBookRequest) ([]dto.BookSuggestion, error)
+	ImportBooks(ctx context.Context, rows []dto.ImportBookRow, dryRun bool) (*dto.ImportBookResponse, error)
+	ExportBooks(ctx context.Context, req *dto.ExportBookRequest, w io.Writer) error
+	CreateWork(ctx context.Context, req *dto.CreateWorkRequest) (*dto.CreateWorkResponse, error)
+	GetDetailWork(ctx context.Context, id string) (*dto.GetDetailWorkResponse, error)
+	UploadCover(ctx context.Context, id string, data []byte) (*dto.Cover, error)
+	GetCover(ctx context.Context, id, size string) (*dto.CoverFile, error)
 }
 
 type IBookHandler interface {
 	CreateBook(*gin.Context)
 	GetDetailBook(*gin.Context)
+	GetDetailBookByIsbn(*gin.Context)
 	GetListBook(*gin.Context)
 	UpdateBook(*gin.Context)
+	PatchBook(*gin.Context)
 	DeleteBook(*gin.Context)
+	RestoreBook(*gin.Context)
+	GetListDeletedBook(*gin.Context)
 	SearchBooks(*gin.Context)
 	GetRecommendations(*gin.Context)
+	SuggestBooks(*gin.Context)
+	ImportBooks(*gin.Context)
+	ExportBooks(*gin.Context)
+	CreateWork(*gin.Context)
+	GetDetailWork(*gin.Context)
+	UploadCover(*gin.Context)
+	GetCover(*gin.Context)
+}
+
+type IBookGRPCHandler interface {
+	book.BookServiceServer
 }
@@ -13,8 +13,10 @@ import (
 type IBookBorrowedRepository interface {
 	InsertNewBookBorrowed(ctx context.Context, tx *sql.Tx, bookBorrowed *models.BookBorrowed) error
 	ValidateBookBorrowed(ctx context.Context, tx *sql.Tx, bookID, userID string) error
-	UpdateBookReturned(ctx context.Context, tx *sql.Tx, returnedDate time.Time, bookID, userID string) error
+	UpdateBookReturned(ctx context.Context, tx *sql.Tx, returnedDate time.Time, id, returnedBranchID string) error
 	ValidateBookReturned(ctx context.Context, tx *sql.Tx, bookID, userID string) error
+	FindActiveLoan(ctx context.Context, tx *sql.Tx, bookID, userID string) (*models.BookBorrowed, error)
+	ValidateLoansWithoutCopy(ctx context.Context, tx *sql.Tx, bookID, branchID string) error
 }
 
 type IBookBorrowedService interface {
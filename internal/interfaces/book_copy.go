@@ -0,0 +1,40 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+type IBookCopyRepository interface {
+	InsertNewBookCopy(ctx context.Context, tx *sql.Tx, bookCopy *models.BookCopy) error
+	FindBookCopyByID(ctx context.Context, id string) (*models.BookCopy, error)
+	FindAllBookCopy(ctx context.Context, filter *models.BookCopyFilter, page *models.PageRequest) ([]models.BookCopy, error)
+	CountAllBookCopy(ctx context.Context, filter *models.BookCopyFilter) (int, error)
+	PatchBookCopyByID(ctx context.Context, tx *sql.Tx, patch *models.BookCopyPatch) error
+	LockBookCopy(ctx context.Context, tx *sql.Tx, id string) (*models.BookCopy, error)
+	LockAvailableBookCopy(ctx context.Context, tx *sql.Tx, bookID, branchID, barcode string) (*models.BookCopy, error)
+	LockAvailableBookCopies(ctx context.Context, tx *sql.Tx, bookID, branchID string, limit int) ([]models.BookCopy, error)
+	UpdateBookCopyStatus(ctx context.Context, tx *sql.Tx, id, status string) error
+	UpdateBookCopyBranch(ctx context.Context, tx *sql.Tx, id, branchID string) error
+	CountBookCopiesByBranch(ctx context.Context, tx *sql.Tx, bookID, branchID string) (int, error)
+}
+
+type IBookCopyService interface {
+	CreateBookCopy(ctx context.Context, req *dto.CreateBookCopyRequest, actorID string) (*dto.GetDetailBookCopyResponse, error)
+	GetDetailBookCopy(ctx context.Context, id string) (*dto.GetDetailBookCopyResponse, error)
+	GetListBookCopy(ctx context.Context, req *dto.GetListBookCopyRequest) (*dto.GetListBookCopyResponse, error)
+	PatchBookCopy(ctx context.Context, id string, req *dto.PatchBookCopyRequest, actorID string) error
+	WithdrawBookCopy(ctx context.Context, id, actorID string) error
+}
+
+type IBookCopyHandler interface {
+	CreateBookCopy(*gin.Context)
+	GetDetailBookCopy(*gin.Context)
+	GetListBookCopy(*gin.Context)
+	PatchBookCopy(*gin.Context)
+	WithdrawBookCopy(*gin.Context)
+}
@@ -10,24 +10,40 @@ import (
 )
 
 type IBookStockRepository interface {
-	InsertNewBookStock(ctx context.Context, bookStock *models.BookStock) error
+	InsertNewBookStock(ctx context.Context, tx *sql.Tx, bookStock *models.BookStock, movement *models.StockMovement) error
 	FindBookStockByID(ctx context.Context, id string) (*models.BookStock, error)
-	FindAllBookStock(ctx context.Context, limit, offset int) ([]models.BookStock, error)
-	UpdateNewBookStock(ctx context.Context, bookStock *models.BookStock) error
-	DeleteBookStockByID(ctx context.Context, id string) error
-	ValidateBookStockByBookID(ctx context.Context, bookID string) (int, error)
-	DecrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID string, stock int) error
-	LockBookStock(ctx context.Context, tx *sql.Tx, bookID string) error
-	IncrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID string, stock int) error
-	LockBookStockReturned(ctx context.Context, tx *sql.Tx, bookID string) error
+	FindAllBookStock(ctx context.Context, page *models.PageRequest) ([]models.BookStock, error)
+	CountAllBookStock(ctx context.Context) (int, error)
+	UpdateNewBookStock(ctx context.Context, tx *sql.Tx, bookStock *models.BookStock, movement *models.StockMovement) error
+	PatchBookStockByID(ctx context.Context, tx *sql.Tx, patch *models.BookStockPatch, movement *models.StockMovement) error
+	DeleteBookStockByID(ctx context.Context, tx *sql.Tx, id string, movement *models.StockMovement) error
+	ValidateBookStockByBookID(ctx context.Context, bookID, branchID string) (int, error)
+	DecrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID, branchID string, stock int, movement *models.StockMovement) error
+	LockBookStock(ctx context.Context, tx *sql.Tx, bookID, branchID string) error
+	IncrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID, branchID string, stock int, movement *models.StockMovement) error
+	MoveStockToBranch(ctx context.Context, tx *sql.Tx, bookID, fromBranchID, toBranchID string, stock int, movement *models.StockMovement) error
+	LockBookStockReturned(ctx context.Context, tx *sql.Tx, bookID, branchID, returnBranchID string) error
+	LockBookStockTransfer(ctx context.Context, tx *sql.Tx, bookID, sourceBranchID, destinationBranchID string) error
+	SyncBookStockWithCopies(ctx context.Context, tx *sql.Tx, bookID, branchID string, movement *models.StockMovement) error
+	LockBookStockByID(ctx context.Context, tx *sql.Tx, id string) (*models.BookStock, error)
+	CountHeldStock(ctx context.Context, tx *sql.Tx, bookID, branchID string) (int, error)
+	AdjustBookStock(ctx context.Context, tx *sql.Tx, id string, delta int, movement *models.StockMovement) error
+	FindStockMovementsByBookStockID(ctx context.Context, bookStockID string, page *models.PageRequest) ([]models.StockMovement, error)
+	CountStockMovementsByBookStockID(ctx context.Context, bookStockID string) (int, error)
+	FindBookStockDrift(ctx context.Context) ([]models.BookStockDrift, error)
+	ReconcileBookStock(ctx context.Context, movement *models.StockMovement) ([]models.BookStockDrift, error)
 }
 
 type IBookStockService interface {
-	CreateBookStock(ctx context.Context, req *dto.CreateBookStockRequest) error
+	CreateBookStock(ctx context.Context, req *dto.CreateBookStockRequest, actorID string) error
 	GetDetailBookStock(ctx context.Context, id string) (*dto.GetDetailBookStockResponse, error)
-	GetListBookStock(ctx context.Context, limit, offset int) (*dto.GetListBookStockResponse, error)
-	UpdateBookStock(ctx context.Context, req *dto.UpdateBookStockRequest) error
-	DeleteBookStock(ctx context.Context, id string) error
+	GetListBookStock(ctx context.Context, req *dto.PaginationRequest) (*dto.GetListBookStockResponse, error)
+	UpdateBookStock(ctx context.Context, req *dto.UpdateBookStockRequest, actorID string) error
+	PatchBookStock(ctx context.Context, id string, req *dto.PatchBookStockRequest, actorID string) error
+	DeleteBookStock(ctx context.Context, id, actorID string) error
+	AdjustBookStock(ctx context.Context, id string, req *dto.AdjustBookStockRequest, actorID string) (*dto.GetDetailBookStockResponse, error)
+	GetListStockMovement(ctx context.Context, id string, req *dto.PaginationRequest) (*dto.GetListStockMovementResponse, error)
+	ReconcileBookStock(ctx context.Context, fix bool, actorID string) (*dto.ReconcileBookStockResponse, error)
 }
 
 type IBookStockHandler interface {
@@ -35,5 +51,9 @@ type IBookStockHandler interface {
 	GetDetailBookStock(*gin.Context)
 	GetListBookStock(*gin.Context)
 	UpdateBookStock(*gin.Context)
+	PatchBookStock(*gin.Context)
 	DeleteBookStock(*gin.Context)
+	AdjustBookStock(*gin.Context)
+	GetListStockMovement(*gin.Context)
+	ReconcileBookStock(*gin.Context)
 }
@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+type IBookTransferRepository interface {
+	InsertNewBookTransfer(ctx context.Context, tx *sql.Tx, bookTransfer *models.BookTransfer) error
+	FindBookTransferByID(ctx context.Context, id string) (*models.BookTransfer, error)
+	FindAllBookTransfer(ctx context.Context, filter *models.BookTransferFilter, page *models.PageRequest) ([]models.BookTransfer, error)
+	CountAllBookTransfer(ctx context.Context, filter *models.BookTransferFilter) (int, error)
+	LockBookTransfer(ctx context.Context, tx *sql.Tx, id string) (*models.BookTransfer, error)
+	UpdateBookTransferShipped(ctx context.Context, tx *sql.Tx, id string, actorID *uuid.UUID) error
+	UpdateBookTransferReceived(ctx context.Context, tx *sql.Tx, id string, actorID *uuid.UUID) error
+	InsertBookTransferCopies(ctx context.Context, tx *sql.Tx, id string, copyIDs []uuid.UUID) error
+	FindBookTransferCopyIDs(ctx context.Context, tx *sql.Tx, id string) ([]uuid.UUID, error)
+}
+
+type IBookTransferService interface {
+	CreateBookTransfer(ctx context.Context, req *dto.CreateBookTransferRequest, actorID string) (*dto.GetDetailBookTransferResponse, error)
+	GetDetailBookTransfer(ctx context.Context, id string) (*dto.GetDetailBookTransferResponse, error)
+	GetListBookTransfer(ctx context.Context, req *dto.GetListBookTransferRequest) (*dto.GetListBookTransferResponse, error)
+	ShipBookTransfer(ctx context.Context, id, actorID string) (*dto.GetDetailBookTransferResponse, error)
+	ReceiveBookTransfer(ctx context.Context, id, actorID string) (*dto.GetDetailBookTransferResponse, error)
+}
+
+type IBookTransferHandler interface {
+	CreateBookTransfer(*gin.Context)
+	GetDetailBookTransfer(*gin.Context)
+	GetListBookTransfer(*gin.Context)
+	ShipBookTransfer(*gin.Context)
+	ReceiveBookTransfer(*gin.Context)
+}
@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+type IBranchRepository interface {
+	InsertNewBranch(ctx context.Context, branch *models.Branch) error
+	FindBranchByID(ctx context.Context, id string) (*models.Branch, error)
+	FindAllBranch(ctx context.Context) ([]models.Branch, error)
+}
+
+type IBranchService interface {
+	CreateBranch(ctx context.Context, req *dto.CreateBranchRequest) (*dto.GetDetailBranchResponse, error)
+	GetDetailBranch(ctx context.Context, id string) (*dto.GetDetailBranchResponse, error)
+	GetListBranch(ctx context.Context) (*dto.GetListBranchResponse, error)
+}
+
+type IBranchHandler interface {
+	CreateBranch(*gin.Context)
+	GetDetailBranch(*gin.Context)
+	GetListBranch(*gin.Context)
+}
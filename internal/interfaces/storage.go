@@ -0,0 +1,8 @@
+package interfaces
+
+import "context"
+
+type IStorage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
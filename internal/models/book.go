@@ -16,4 +16,145 @@ type Book struct {
 	PublishedDate time.Time `db:"published_date"`
 	CreatedAt     time.Time `db:"created_at"`
 	UpdatedAt     time.Time `db:"updated_at"`
+	Version       int       `db:"version"`
+
+	// WorkID groups the editions of one work, nil for a standalone book
+	WorkID    *uuid.UUID `db:"work_id"`
+	Edition   *string    `db:"edition"`
+	Format    *string    `db:"format"`
+	Publisher *string    `db:"publisher"`
+
+	Subtitle      *string `db:"subtitle"`
+	OriginalTitle *string `db:"original_title"`
+	// Language is an ISO 639-1 code
+	Language  *string `db:"language"`
+	PageCount *int    `db:"page_count"`
+
+	// CoverUpdatedAt is set once a cover has been uploaded
+	CoverUpdatedAt *time.Time `db:"cover_updated_at"`
+
+	DeletedAt *time.Time `db:"deleted_at"`
+	DeletedBy *uuid.UUID `db:"deleted_by"`
+
+	// Contributors are ordered by position, nil on writes leaves them unchanged
+	Contributors []BookContributor `db:"-"`
+	// CategoryIDs always include CategoryID, nil on writes leaves them unchanged
+	CategoryIDs []uuid.UUID `db:"-"`
+	Tags        []string    `db:"-"`
+
+	Rank                 float64 `db:"rank"`
+	TitleHighlight       string  `db:"title_highlight"`
+	DescriptionHighlight string  `db:"description_highlight"`
+
+	// EditionCount is only set when search results are grouped by work
+	EditionCount int `db:"edition_count"`
+	// Popularity counts every loan of the book, only set when a list is sorted by it
+	Popularity int `db:"popularity"`
+	// TotalStock and AvailableStock are set on single book reads and when a list expands stock
+	TotalStock     int `db:"total_stock"`
+	AvailableStock int `db:"available_stock"`
+	// ActiveLoans and ExpectedAvailableAt, the earliest due date of those loans, are only set on single book reads
+	ActiveLoans         int        `db:"active_loans"`
+	ExpectedAvailableAt *time.Time `db:"expected_available_at"`
+	// BranchStocks splits the stock by branch, only set on single book reads
+	BranchStocks []BookBranchStock `db:"-"`
+}
+
+// BookPatch holds the columns to write on a partial update, nil means unchanged.
+type BookPatch struct {
+	ID            uuid.UUID
+	Version       int
+	Title         *string
+	AuthorID      *uuid.UUID
+	CategoryID    *uuid.UUID
+	Isbn          *string
+	Description   *string
+	PublishedDate *time.Time
+	// WorkID set to uuid.Nil and empty Edition, Format or Publisher clear the column
+	WorkID    *uuid.UUID
+	Edition   *string
+	Format    *string
+	Publisher *string
+	// empty Subtitle, OriginalTitle or Language and a zero PageCount clear the column
+	Subtitle      *string
+	OriginalTitle *string
+	Language      *string
+	PageCount     *int
+	Contributors  []BookContributor
+	CategoryIDs   []uuid.UUID
+	Tags          []string
+}
+
+type BookContributor struct {
+	BookID   uuid.UUID `db:"book_id"`
+	AuthorID uuid.UUID `db:"author_id"`
+	Role     string    `db:"role"`
+	Position int       `db:"position"`
+}
+
+type BookExport struct {
+	Book
+	TotalStock     int `db:"total_stock"`
+	AvailableStock int `db:"available_stock"`
+}
+
+type ExportBookFilter struct {
+	CategoryID  string
+	AuthorID    string
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+}
+
+// SearchFacet is the number of search results sharing one value of a facet.
+type SearchFacet struct {
+	Facet string `db:"facet"`
+	Value string `db:"value"`
+	Count int    `db:"count"`
+}
+
+// ListBookFilter narrows and orders GET /book/v1/, an empty SortBy keeps the
+// most recently updated books first.
+type ListBookFilter struct {
+	SortBy   string
+	SortDesc bool
+
+	// PublishedFrom and PublishedTo are inclusive
+	PublishedFrom *time.Time
+	PublishedTo   *time.Time
+	CategoryID    string
+	AuthorID      string
+	Language      string
+	// AvailableOnly keeps books with at least one copy on the shelf
+	AvailableOnly bool
+	// WithStock joins the stock totals of every book
+	WithStock bool
+}
+
+type SearchBookFilter struct {
+	Title    string
+	AuthorID string
+	Query    string
+
+	// CategoryIDs and Tags match any of the values unless the MatchAll flag is set
+	CategoryIDs        []string
+	MatchAllCategories bool
+	Tags               []string
+	MatchAllTags       bool
+
+	// Language, Format and Publisher match exactly, Publisher ignoring case
+	Language  string
+	Format    string
+	Publisher string
+
+	// GroupByWork collapses the editions of a work into its best matching edition
+	GroupByWork bool
+	// WithStock joins the stock totals of every book
+	WithStock bool
+
+	// Fuzzy matches Title by trigram word similarity instead of ILIKE
+	Fuzzy               bool
+	SimilarityThreshold float64
+
+	// Expression is the parsed field-scoped query, ANDed with the other filters
+	Expression *SearchQueryNode
 }
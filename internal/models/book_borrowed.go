@@ -7,12 +7,17 @@ import (
 )
 
 type BookBorrowed struct {
-	ID           uuid.UUID `db:"id"`
-	UserID       uuid.UUID `db:"user_id"`
-	BookID       uuid.UUID `db:"book_io"`
-	BorrowedDate time.Time `db:"borrowed_date"`
-	DueDate      time.Time `db:"due_date"`
-	ReturnedDate time.Time `db:"returned_date"`
-	CreatedAt    time.Time `db:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at"`
+	ID     uuid.UUID `db:"id"`
+	UserID uuid.UUID `db:"user_id"`
+	BookID uuid.UUID `db:"book_io"`
+	// CopyID is nil for loans of books without copies
+	CopyID   *uuid.UUID `db:"copy_id"`
+	BranchID uuid.UUID  `db:"branch_id"`
+	// ReturnedBranchID differs from BranchID when the book was returned elsewhere
+	ReturnedBranchID *uuid.UUID `db:"returned_branch_id"`
+	BorrowedDate     time.Time  `db:"borrowed_date"`
+	DueDate          time.Time  `db:"due_date"`
+	ReturnedDate     time.Time  `db:"returned_date"`
+	CreatedAt        time.Time  `db:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookCopy is one physical copy of a book, the stock counts of a book with
+// copies are derived from their statuses.
+type BookCopy struct {
+	ID              uuid.UUID  `db:"id"`
+	BookID          uuid.UUID  `db:"book_id"`
+	BookTitle       string     `db:"book_title"`
+	BranchID        uuid.UUID  `db:"branch_id"`
+	BranchName      string     `db:"branch_name"`
+	Barcode         string     `db:"barcode"`
+	AccessionNumber string     `db:"accession_number"`
+	AcquisitionDate *time.Time `db:"acquisition_date"`
+	ShelfLocation   *string    `db:"shelf_location"`
+	Status          string     `db:"status"`
+	WithdrawnAt     *time.Time `db:"withdrawn_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	Version         int        `db:"version"`
+}
+
+// BookCopyPatch holds the columns to write on a partial update, nil means unchanged.
+type BookCopyPatch struct {
+	ID              uuid.UUID
+	Version         int
+	Barcode         *string
+	AccessionNumber *string
+	// a zero AcquisitionDate and an empty ShelfLocation clear the column
+	AcquisitionDate *time.Time
+	ShelfLocation   *string
+	Status          *string
+}
+
+type BookCopyFilter struct {
+	BookID   string
+	BranchID string
+	Status   string
+}
@@ -10,8 +10,67 @@ type BookStock struct {
 	ID             uuid.UUID `db:"id"`
 	BookID         uuid.UUID `db:"book_id"`
 	BookTitle      string    `db:"book_title"`
+	BranchID       uuid.UUID `db:"branch_id"`
+	BranchName     string    `db:"branch_name"`
 	TotalStock     int       `db:"total_stock"`
 	AvailableStock int       `db:"available_stock"`
 	CreatedAt      time.Time `db:"created_at"`
 	UpdatedAt      time.Time `db:"updated_at"`
+	Version        int       `db:"version"`
+}
+
+// StockMovement is one append-only change of a stock row. The service sets
+// Type, Reason and ActorID, the repository fills in the row and its deltas.
+type StockMovement struct {
+	ID             uuid.UUID  `db:"id"`
+	BookStockID    uuid.UUID  `db:"book_stock_id"`
+	BookID         uuid.UUID  `db:"book_id"`
+	Type           string     `db:"type"`
+	TotalDelta     int        `db:"total_delta"`
+	AvailableDelta int        `db:"available_delta"`
+	Reason         *string    `db:"reason"`
+	ActorID        *uuid.UUID `db:"actor_id"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+// NewStockMovement starts a ledger entry for a stock change, the repository
+// fills in the stock row and its deltas. An empty reason or an actor that is
+// not a UUID is stored as NULL.
+func NewStockMovement(movementType, reason, actorID string) *StockMovement {
+	movement := &StockMovement{Type: movementType}
+
+	if reason != "" {
+		movement.Reason = &reason
+	}
+
+	if id, err := uuid.Parse(actorID); err == nil {
+		movement.ActorID = &id
+	}
+
+	return movement
+}
+
+// BookStockPatch holds the columns to write on a partial update, nil means unchanged.
+type BookStockPatch struct {
+	ID             uuid.UUID
+	Version        int
+	TotalStock     *int
+	AvailableStock *int
+}
+
+// BookStockDrift is a stock row next to the counts derived from its book at
+// that branch: the copy statuses when the book has copies, otherwise
+// total_stock less the active loans.
+type BookStockDrift struct {
+	ID                     uuid.UUID `db:"id"`
+	BookID                 uuid.UUID `db:"book_id"`
+	BookTitle              string    `db:"book_title"`
+	BranchID               uuid.UUID `db:"branch_id"`
+	BranchName             string    `db:"branch_name"`
+	ManagedByCopies        bool      `db:"managed_by_copies"`
+	ActiveLoans            int       `db:"active_loans"`
+	TotalStock             int       `db:"total_stock"`
+	ExpectedTotalStock     int       `db:"expected_total_stock"`
+	AvailableStock         int       `db:"available_stock"`
+	ExpectedAvailableStock int       `db:"expected_available_stock"`
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookTransfer moves stock of a book from one branch to another, it leaves
+// the source when shipped and reaches the destination when received.
+type BookTransfer struct {
+	ID                    uuid.UUID  `db:"id"`
+	BookID                uuid.UUID  `db:"book_id"`
+	BookTitle             string     `db:"book_title"`
+	SourceBranchID        uuid.UUID  `db:"source_branch_id"`
+	SourceBranchName      string     `db:"source_branch_name"`
+	DestinationBranchID   uuid.UUID  `db:"destination_branch_id"`
+	DestinationBranchName string     `db:"destination_branch_name"`
+	Quantity              int        `db:"quantity"`
+	Status                string     `db:"status"`
+	RequestedBy           *uuid.UUID `db:"requested_by"`
+	ShippedBy             *uuid.UUID `db:"shipped_by"`
+	ReceivedBy            *uuid.UUID `db:"received_by"`
+	ShippedAt             *time.Time `db:"shipped_at"`
+	ReceivedAt            *time.Time `db:"received_at"`
+	CreatedAt             time.Time  `db:"created_at"`
+	UpdatedAt             time.Time  `db:"updated_at"`
+}
+
+type BookTransferFilter struct {
+	// BranchID matches transfers leaving or reaching the branch
+	BranchID string
+	// Status empty matches the pending transfers, those not yet received
+	Status string
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Branch is a library location, stock rows, copies and loans each belong to one.
+type Branch struct {
+	ID        uuid.UUID `db:"id"`
+	Code      string    `db:"code"`
+	Name      string    `db:"name"`
+	Address   *string   `db:"address"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// BookBranchStock is the stock of a book at one branch.
+type BookBranchStock struct {
+	BranchID       uuid.UUID `db:"branch_id"`
+	BranchName     string    `db:"branch_name"`
+	TotalStock     int       `db:"total_stock"`
+	AvailableStock int       `db:"available_stock"`
+}
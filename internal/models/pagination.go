@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is the keyset position of a row in a list ordered by (Value,
+// updated_at, id). Value is only set when another column leads the order,
+// e.g. the search rank, Sort names that order so a cursor is only accepted
+// by the list sorted the same way.
+type Cursor struct {
+	Value     interface{} `json:"v,omitempty"`
+	Sort      string      `json:"s,omitempty"`
+	UpdatedAt time.Time   `json:"u"`
+	ID        uuid.UUID   `json:"i"`
+	// Before asks for the rows preceding the position instead of following it
+	Before bool `json:"b,omitempty"`
+}
+
+// PageRequest selects a page by Cursor or, for clients still sending
+// page and limit, by Offset. Repositories fetch one row past Limit so the
+// caller can tell whether another page exists.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+}
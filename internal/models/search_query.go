@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+)
+
+// SearchQueryNode is one node of a parsed advanced search query. And and Or
+// nodes hold their operands in Children, a Not node holds exactly one.
+type SearchQueryNode struct {
+	Op       string
+	Children []*SearchQueryNode
+
+	// Field is empty for a bare term, which matches title or description
+	Field string
+	Value string
+	// From and To bound a range, an empty bound leaves that end open
+	IsRange bool
+	From    string
+	To      string
+}
+
+// String renders the node back in query syntax, equivalent queries render the
+// same. A nil node renders empty.
+func (n *SearchQueryNode) String() string {
+	if n == nil {
+		return ""
+	}
+
+	switch n.Op {
+	case constants.SearchQueryAnd, constants.SearchQueryOr:
+		parts := make([]string, 0, len(n.Children))
+		for _, child := range n.Children {
+			parts = append(parts, child.String())
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(n.Op)+" ") + ")"
+	case constants.SearchQueryNot:
+		return "-" + n.Children[0].String()
+	}
+
+	value := strconv.Quote(n.Value)
+	if n.IsRange {
+		value = n.From + ".." + n.To
+	}
+
+	if n.Field == "" {
+		return value
+	}
+	return n.Field + ":" + value
+}
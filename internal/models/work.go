@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Work struct {
+	ID          uuid.UUID `db:"id"`
+	Title       string    `db:"title"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// WorkEdition is a book of a work with its stock summed over book_stocks.
+type WorkEdition struct {
+	ID             uuid.UUID `db:"id"`
+	Title          string    `db:"title"`
+	Isbn           *string   `db:"isbn"`
+	Edition        *string   `db:"edition"`
+	Format         *string   `db:"format"`
+	Publisher      *string   `db:"publisher"`
+	PublishedDate  time.Time `db:"published_date"`
+	TotalStock     int       `db:"total_stock"`
+	AvailableStock int       `db:"available_stock"`
+}
@@ -0,0 +1,243 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+// EncodeCursor returns the opaque form of a cursor handed out to clients.
+func EncodeCursor(cursor *models.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(helpers.MarshalJSON(cursor))
+}
+
+func DecodeCursor(s string) (*models.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New(constants.ErrInvalidCursor)
+	}
+
+	cursor := new(models.Cursor)
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.UpdatedAt.IsZero() {
+		return nil, errors.New(constants.ErrInvalidCursor)
+	}
+
+	return cursor, nil
+}
+
+// CursorValue is what a cursor holds for the leading column of a Keyset once
+// it is decoded from JSON.
+type CursorValue int
+
+const (
+	CursorValueNone CursorValue = iota
+	CursorValueString
+	CursorValueDate
+	CursorValueTime
+	CursorValueNumber
+)
+
+// CheckCursorValue rejects a cursor whose value does not fit the column it is
+// compared with, so a forged cursor is a bad request instead of a failed query.
+func CheckCursorValue(cursor *models.Cursor, kind CursorValue) error {
+	if cursor == nil || kind == CursorValueNone {
+		return nil
+	}
+
+	var ok bool
+	switch kind {
+	case CursorValueString:
+		_, ok = cursor.Value.(string)
+	case CursorValueDate:
+		var value string
+		if value, ok = cursor.Value.(string); ok {
+			_, err := time.Parse(constants.DateTimeFormat, value)
+			ok = err == nil
+		}
+	case CursorValueTime:
+		var value string
+		if value, ok = cursor.Value.(string); ok {
+			_, err := time.Parse(time.RFC3339Nano, value)
+			ok = err == nil
+		}
+	case CursorValueNumber:
+		_, ok = cursor.Value.(float64)
+	}
+
+	if !ok {
+		return errors.New(constants.ErrInvalidCursor)
+	}
+	return nil
+}
+
+// NewPageRequest maps the page, limit and cursor query parameters, a cursor
+// takes precedence over page.
+func NewPageRequest(req *dto.PaginationRequest) (*models.PageRequest, error) {
+	page := &models.PageRequest{
+		Limit:  req.Limit,
+		Offset: (req.Page - 1) * req.Limit,
+	}
+
+	if req.Cursor != "" {
+		cursor, err := DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Offset = 0
+		page.Cursor = cursor
+	}
+
+	return page, nil
+}
+
+// ReversePage puts the rows of a backward page, read in reverse order, back
+// into display order.
+func ReversePage[T any](rows []T, page *models.PageRequest) []T {
+	if page.Cursor != nil && page.Cursor.Before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	return rows
+}
+
+// TrimPage drops the extra row fetched past the limit and reports whether it
+// was there. Backward pages come in display order, so their extra row is first.
+func TrimPage[T any](rows []T, page *models.PageRequest) ([]T, bool) {
+	if len(rows) <= page.Limit {
+		return rows, false
+	}
+
+	if page.Cursor != nil && page.Cursor.Before {
+		return rows[len(rows)-page.Limit:], true
+	}
+	return rows[:page.Limit], true
+}
+
+// PageCursors returns the cursors of the first and last row of a page, nil
+// when the page is empty.
+func PageCursors[T any](rows []T, cursor func(row T) *models.Cursor) (*models.Cursor, *models.Cursor) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return cursor(rows[0]), cursor(rows[len(rows)-1])
+}
+
+// New describes a page of rows, first and last are the cursors of
+// its first and last row and are nil on an empty page.
+func New(req *dto.PaginationRequest, page *models.PageRequest, totalItems int, hasMore bool, first, last *models.Cursor) dto.Pagination {
+	pagination := dto.Pagination{
+		Limit:      page.Limit,
+		TotalItems: totalItems,
+		TotalPages: (totalItems + page.Limit - 1) / page.Limit,
+	}
+
+	hasNext, hasPrev := hasMore, page.Offset > 0
+	if page.Cursor != nil {
+		hasNext, hasPrev = true, hasMore
+		if !page.Cursor.Before {
+			hasNext, hasPrev = hasMore, true
+		}
+	} else {
+		pagination.Page = req.Page
+	}
+
+	if hasNext && last != nil {
+		next := *last
+		next.Before = false
+		pagination.NextCursor = EncodeCursor(&next)
+	}
+
+	if hasPrev && first != nil {
+		prev := *first
+		prev.Before = true
+		pagination.PrevCursor = EncodeCursor(&prev)
+	}
+
+	return pagination
+}
+
+// Keyset orders a list by an optional leading Column, then UpdatedAt and ID
+// descending, which keeps every position unique.
+type Keyset struct {
+	Column    string
+	Desc      bool
+	UpdatedAt string
+	ID        string
+}
+
+// Where returns the condition selecting the rows after the cursor, or before
+// it for a backward cursor.
+func (k Keyset) Where(cursor *models.Cursor) (string, []interface{}) {
+	tieBreak := "<"
+	if cursor.Before {
+		tieBreak = ">"
+	}
+
+	condition := fmt.Sprintf("(%s, %s) %s (?, ?)", k.UpdatedAt, k.ID, tieBreak)
+	if k.Column == "" {
+		return condition, []interface{}{cursor.UpdatedAt, cursor.ID}
+	}
+
+	leading := ">"
+	if k.Desc != cursor.Before {
+		leading = "<"
+	}
+
+	return fmt.Sprintf("(%s %s ? OR (%s = ? AND %s))", k.Column, leading, k.Column, condition),
+		[]interface{}{cursor.Value, cursor.Value, cursor.UpdatedAt, cursor.ID}
+}
+
+// OrderBy returns the ORDER BY list, reversed when reading a page backwards.
+func (k Keyset) OrderBy(reverse bool) string {
+	direction := func(desc bool) string {
+		if desc != reverse {
+			return "DESC"
+		}
+		return "ASC"
+	}
+
+	orderBy := fmt.Sprintf("%s %s, %s %s", k.UpdatedAt, direction(true), k.ID, direction(true))
+	if k.Column == "" {
+		return orderBy
+	}
+	return fmt.Sprintf("%s %s, %s", k.Column, direction(k.Desc), orderBy)
+}
+
+// Paginate appends the keyset condition, order and limit of a page to a
+// query that has no ORDER BY yet. where joins the condition, " WHERE " or " AND ".
+func (k Keyset) Paginate(query, where string, args []interface{}, page *models.PageRequest) (string, []interface{}) {
+	reverse := page.Cursor != nil && page.Cursor.Before
+
+	if page.Cursor != nil {
+		condition, conditionArgs := k.Where(page.Cursor)
+		query += where + condition
+		args = append(args, conditionArgs...)
+	}
+
+	query += " ORDER BY " + k.OrderBy(reverse) + " LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	if page.Cursor == nil {
+		query += " OFFSET ?"
+		args = append(args, page.Offset)
+	}
+
+	return query, args
+}
+
+// CountQuery counts the rows a list query returns before paging.
+func CountQuery(query string) string {
+	return "SELECT COUNT(*) FROM (" + query + ") c"
+}
@@ -0,0 +1,109 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := &models.Cursor{
+		Value:     "Dune",
+		Sort:      "title_asc",
+		UpdatedAt: time.Date(2026, 10, 18, 9, 30, 0, 123000000, time.UTC),
+		ID:        uuid.MustParse("9b2f4c1e-6a43-4d0b-9d5e-1f3a2b4c5d6e"),
+		Before:    true,
+	}
+
+	got, err := DecodeCursor(EncodeCursor(want))
+	if err != nil {
+		t.Fatalf("DecodeCursor error = %v", err)
+	}
+
+	if got.Value != want.Value || got.Sort != want.Sort || !got.UpdatedAt.Equal(want.UpdatedAt) || got.ID != want.ID || got.Before != want.Before {
+		t.Errorf("DecodeCursor = %+v, want %+v", got, want)
+	}
+}
+
+func TestEncodeCursorNil(t *testing.T) {
+	if got := EncodeCursor(nil); got != "" {
+		t.Errorf("EncodeCursor(nil) = %q, want empty", got)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "not json", cursor: encode("cursor")},
+		{name: "missing updated at", cursor: encode(`{"i":"9b2f4c1e-6a43-4d0b-9d5e-1f3a2b4c5d6e"}`)},
+		{name: "bad updated at", cursor: encode(`{"u":"yesterday","i":"9b2f4c1e-6a43-4d0b-9d5e-1f3a2b4c5d6e"}`)},
+		{name: "bad id", cursor: encode(`{"u":"2026-10-18T09:30:00Z","i":"42"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := DecodeCursor(tt.cursor); err == nil {
+				t.Errorf("DecodeCursor(%q) = %+v, want error", tt.cursor, cursor)
+			}
+		})
+	}
+}
+
+func TestCheckCursorValue(t *testing.T) {
+	decode := func(value interface{}) *models.Cursor {
+		cursor, err := DecodeCursor(EncodeCursor(&models.Cursor{
+			Value:     value,
+			UpdatedAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+			ID:        uuid.New(),
+		}))
+		if err != nil {
+			t.Fatalf("DecodeCursor error = %v", err)
+		}
+		return cursor
+	}
+
+	tests := []struct {
+		name    string
+		cursor  *models.Cursor
+		kind    CursorValue
+		wantErr bool
+	}{
+		{name: "no cursor", cursor: nil, kind: CursorValueNumber},
+		{name: "no leading column", cursor: decode(map[string]int{"a": 1}), kind: CursorValueNone},
+		{name: "string", cursor: decode("Dune"), kind: CursorValueString},
+		{name: "date", cursor: decode("1965-08-01"), kind: CursorValueDate},
+		{name: "time", cursor: decode(time.Date(2026, 10, 18, 9, 30, 0, 1, time.UTC)), kind: CursorValueTime},
+		{name: "integer number", cursor: decode(12), kind: CursorValueNumber},
+		{name: "float number", cursor: decode(0.0759), kind: CursorValueNumber},
+		{name: "number for string", cursor: decode(12), kind: CursorValueString, wantErr: true},
+		{name: "missing value", cursor: decode(nil), kind: CursorValueString, wantErr: true},
+		{name: "bad date", cursor: decode("01-08-1965"), kind: CursorValueDate, wantErr: true},
+		{name: "time for date", cursor: decode("1965-08-01T00:00:00Z"), kind: CursorValueDate, wantErr: true},
+		{name: "bad time", cursor: decode("yesterday"), kind: CursorValueTime, wantErr: true},
+		{name: "string for number", cursor: decode("1; DROP TABLE books"), kind: CursorValueNumber, wantErr: true},
+		{name: "object for number", cursor: decode(map[string]int{"a": 1}), kind: CursorValueNumber, wantErr: true},
+		{name: "array for string", cursor: decode([]string{"a"}), kind: CursorValueString, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCursorValue(tt.cursor, tt.kind)
+			if tt.wantErr && err == nil {
+				t.Error("CheckCursorValue want error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("CheckCursorValue error = %v", err)
+			}
+		})
+	}
+}
@@ -6,17 +6,33 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/hilmiikhsan/library-book-service/constants"
 	"github.com/hilmiikhsan/library-book-service/helpers"
 	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
+// recommendations keep their newest-published-first order, updated_at and id
+// only break ties
+var recommendationKeyset = pagination.Keyset{Column: "b.published_date", Desc: true, UpdatedAt: "b.updated_at", ID: "b.id"}
+
+// listBookSortColumns whitelists the sort fields of FindAllBook
+var listBookSortColumns = map[string]string{
+	constants.BookSortTitle:         "p.title",
+	constants.BookSortPublishedDate: "p.published_date",
+	constants.BookSortCreatedAt:     "p.created_at",
+	constants.BookSortPopularity:    "p.popularity",
+}
+
 type BookRepository struct {
 	DB     *sqlx.DB
 	Logger *logrus.Logger
@@ -24,14 +40,29 @@ type BookRepository struct {
 }
 
 func (r *BookRepository) InsertNewBook(ctx context.Context, book *models.Book) error {
-	_, err := r.DB.ExecContext(ctx, r.DB.Rebind(queryInsertNewBook),
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		r.Logger.Error("repo::InsertNewBook - failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowxContext(ctx, r.DB.Rebind(queryInsertNewBook),
 		book.Title,
 		book.AuthorID,
 		book.CategoryID,
 		book.Isbn,
 		book.Description,
 		book.PublishedDate,
-	)
+		book.WorkID,
+		book.Edition,
+		book.Format,
+		book.Publisher,
+		book.Subtitle,
+		book.OriginalTitle,
+		book.Language,
+		book.PageCount,
+	).Scan(&book.ID)
 	if err != nil {
 		pqErr, ok := err.(*pq.Error)
 		if !ok {
@@ -49,13 +80,104 @@ func (r *BookRepository) InsertNewBook(ctx context.Context, book *models.Book) e
 		}
 	}
 
-	return nil
+	err = r.saveBookRelations(ctx, tx, book.ID, book.Contributors, book.CategoryIDs, book.Tags)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *BookRepository) InsertNewBooks(ctx context.Context, books []models.Book) ([]models.Book, error) {
+	var (
+		res          = make([]models.Book, 0)
+		placeholders = make([]string, 0, len(books))
+		args         = make([]interface{}, 0, len(books)*14)
+	)
+
+	if len(books) == 0 {
+		return res, nil
+	}
+
+	for _, book := range books {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			book.Title,
+			book.AuthorID,
+			book.CategoryID,
+			book.Isbn,
+			book.Description,
+			book.PublishedDate,
+			book.WorkID,
+			book.Edition,
+			book.Format,
+			book.Publisher,
+			book.Subtitle,
+			book.OriginalTitle,
+			book.Language,
+			book.PageCount,
+		)
+	}
+
+	// rows skipped by ON CONFLICT are not returned, so callers can tell duplicates apart
+	query := fmt.Sprintf(queryInsertNewBooks, strings.Join(placeholders, ", "))
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		r.Logger.Error("repo::InsertNewBooks - failed to begin transaction: ", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
+	if err != nil {
+		r.Logger.Error("repo::InsertNewBooks - Failed to insert new books : ", err)
+		return nil, err
+	}
+
+	byIsbn := make(map[string]models.Book, len(books))
+	for _, book := range books {
+		byIsbn[helpers.SafeString(book.Isbn)] = book
+	}
+
+	for _, inserted := range res {
+		book := byIsbn[helpers.SafeString(inserted.Isbn)]
+		err = r.saveBookRelations(ctx, tx, inserted.ID, book.Contributors, book.CategoryIDs, book.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Logger.Error("repo::InsertNewBooks - failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookRepository) FindExistingIsbns(ctx context.Context, isbns []string) ([]string, error) {
+	var (
+		res = make([]string, 0)
+	)
+
+	if len(isbns) == 0 {
+		return res, nil
+	}
+
+	err := r.DB.SelectContext(ctx, &res, r.DB.Rebind(queryFindExistingIsbns), pq.Array(isbns))
+	if err != nil {
+		r.Logger.Error("repo::FindExistingIsbns - failed to find existing isbns: ", err)
+		return nil, err
+	}
+
+	return res, nil
 }
 
 func (r *BookRepository) FindBookByID(ctx context.Context, id string) (*models.Book, error) {
 	var (
 		res      = new(models.Book)
-		cacheKey = fmt.Sprintf("book:%s", id)
+		cacheKey = bookCacheKey(id)
 	)
 
 	cachedData, err := r.Redis.Get(ctx, cacheKey).Result()
@@ -79,6 +201,11 @@ func (r *BookRepository) FindBookByID(ctx context.Context, id string) (*models.B
 		return nil, err
 	}
 
+	err = r.loadBookRelations(ctx, res)
+	if err != nil {
+		return nil, err
+	}
+
 	dataToCache, err := json.Marshal(res)
 	if err != nil {
 		r.Logger.Warn("category::FindBookByID - Failed to marshal data for caching: ", err)
@@ -92,10 +219,50 @@ func (r *BookRepository) FindBookByID(ctx context.Context, id string) (*models.B
 	return res, nil
 }
 
-func (r *BookRepository) FindAllBook(ctx context.Context, limit, offset int) ([]models.Book, error) {
+// RefreshBookCache reloads the cached book after a loan or stock change, so
+// its availability is current without waiting for the cache to expire. The
+// cached lists and searches are left behind by bumping the stock generation.
+func (r *BookRepository) RefreshBookCache(ctx context.Context, id string) error {
+	r.deleteCache(ctx, bookCacheKey(id))
+	r.bumpStockGeneration(ctx)
+
+	_, err := r.FindBookByID(ctx, id)
+	if err != nil {
+		r.Logger.Error("repo::RefreshBookCache - failed to reload book: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *BookRepository) FindBookByIsbn(ctx context.Context, isbn string) (*models.Book, error) {
+	var (
+		res = new(models.Book)
+	)
+
+	err := r.DB.GetContext(ctx, res, r.DB.Rebind(queryFindBookByIsbn), isbn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::FindBookByIsbn - Book doesnt exist")
+			return res, errors.New(constants.ErrBookNotFound)
+		}
+
+		r.Logger.Error("repo::FindBookByIsbn - failed to find book by isbn: ", err)
+		return nil, err
+	}
+
+	err = r.loadBookRelations(ctx, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookRepository) FindAllBook(ctx context.Context, filter *models.ListBookFilter, page *models.PageRequest) ([]models.Book, error) {
 	var (
 		res      = make([]models.Book, 0)
-		cacheKey = fmt.Sprintf("books:limit:%d:offset:%d", limit, offset)
+		cacheKey = r.generateListBooksCacheKey(ctx, filter, page)
 	)
 
 	cachedData, err := r.Redis.Get(ctx, cacheKey).Result()
@@ -108,12 +275,17 @@ func (r *BookRepository) FindAllBook(ctx context.Context, limit, offset int) ([]
 		r.Logger.Warn("category::FindAllBook - Failed to unmarshal cache data: ", err)
 	}
 
-	err = r.DB.SelectContext(ctx, &res, r.DB.Rebind(queryFindAllBook), limit, offset)
+	query, args, keyset := buildListBooksQuery(filter)
+	query, args = keyset.Paginate(pageQuery(query, filter.WithStock), " WHERE ", args, page)
+
+	err = r.DB.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
 	if err != nil {
 		r.Logger.Error("repo::FindAllBook - failed to find all book: ", err)
 		return nil, err
 	}
 
+	res = pagination.ReversePage(res, page)
+
 	dataToCache, err := json.Marshal(res)
 	if err != nil {
 		r.Logger.Warn("category::FindAllBook - Failed to marshal data for caching: ", err)
@@ -127,6 +299,84 @@ func (r *BookRepository) FindAllBook(ctx context.Context, limit, offset int) ([]
 	return res, nil
 }
 
+func (r *BookRepository) CountAllBook(ctx context.Context, filter *models.ListBookFilter) (int, error) {
+	query, args, _ := buildListBooksQuery(filter)
+
+	return r.cachedCount(ctx, r.generateListBooksCacheKey(ctx, filter, nil), func(count *int) error {
+		return r.DB.GetContext(ctx, count, r.DB.Rebind(pagination.CountQuery(query)), args...)
+	})
+}
+
+// pageQuery wraps a list query as p, joining the stock totals when asked to.
+func pageQuery(query string, withStock bool) string {
+	if withStock {
+		return fmt.Sprintf(querySelectPageWithStock, query)
+	}
+	return fmt.Sprintf(querySelectPage, query)
+}
+
+// buildListBooksQuery returns the filtered list, without order or limit, and
+// the keyset its rows are paged by. Only whitelisted columns reach the SQL,
+// every value is a bind parameter.
+func buildListBooksQuery(filter *models.ListBookFilter) (string, []interface{}, pagination.Keyset) {
+	var (
+		columns string
+		args    = []interface{}{}
+		keyset  = pagination.Keyset{UpdatedAt: "p.updated_at", ID: "p.id"}
+	)
+
+	if column, ok := listBookSortColumns[filter.SortBy]; ok {
+		keyset.Column, keyset.Desc = column, filter.SortDesc
+	}
+
+	if filter.SortBy == constants.BookSortPopularity {
+		columns = queryBookPopularityColumn
+	}
+
+	query := fmt.Sprintf(queryFindAllBook, columns)
+
+	if filter.PublishedFrom != nil {
+		query += " AND b.published_date >= ?"
+		args = append(args, *filter.PublishedFrom)
+	}
+	if filter.PublishedTo != nil {
+		query += " AND b.published_date <= ?"
+		args = append(args, *filter.PublishedTo)
+	}
+	if filter.CategoryID != "" {
+		query += " AND (b.category_id = ? OR EXISTS (SELECT 1 FROM book_categories bcat WHERE bcat.book_id = b.id AND bcat.category_id = ?))"
+		args = append(args, filter.CategoryID, filter.CategoryID)
+	}
+	if filter.AuthorID != "" {
+		query += " AND (b.author_id = ? OR EXISTS (SELECT 1 FROM book_contributors bc WHERE bc.book_id = b.id AND bc.author_id = ?))"
+		args = append(args, filter.AuthorID, filter.AuthorID)
+	}
+	if filter.Language != "" {
+		query += " AND b.language = ?"
+		args = append(args, filter.Language)
+	}
+	if filter.AvailableOnly {
+		// semi join, a book with several stock rows is still listed once
+		query += " AND EXISTS (SELECT 1 FROM book_stocks bs WHERE bs.book_id = b.id AND bs.available_stock > 0)"
+	}
+
+	return query, args, keyset
+}
+
+func (r *BookRepository) FindBooksByIDs(ctx context.Context, ids []string) ([]models.Book, error) {
+	var (
+		res = make([]models.Book, 0)
+	)
+
+	err := r.DB.SelectContext(ctx, &res, r.DB.Rebind(queryFindBooksByIDs), pq.Array(ids))
+	if err != nil {
+		r.Logger.Error("repo::FindBooksByIDs - failed to find books by ids: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (r *BookRepository) UpdateNewBook(ctx context.Context, book *models.Book) error {
 	query := `
 		UPDATE books
@@ -136,7 +386,8 @@ func (r *BookRepository) UpdateNewBook(ctx context.Context, book *models.Book) e
 			category_id = ?,
 			description = ?,
 			published_date = ?,
-			update_at = now()
+			updated_at = now(),
+			version = version + 1
 	`
 
 	args := []interface{}{
@@ -151,11 +402,51 @@ func (r *BookRepository) UpdateNewBook(ctx context.Context, book *models.Book) e
 		query += ", isbn = ?"
 		args = append(args, *book.Isbn)
 	}
+	if book.WorkID != nil {
+		query += ", work_id = ?"
+		args = append(args, *book.WorkID)
+	}
+	if book.Edition != nil {
+		query += ", edition = ?"
+		args = append(args, *book.Edition)
+	}
+	if book.Format != nil {
+		query += ", format = ?"
+		args = append(args, *book.Format)
+	}
+	if book.Publisher != nil {
+		query += ", publisher = ?"
+		args = append(args, *book.Publisher)
+	}
+	if book.Subtitle != nil {
+		query += ", subtitle = ?"
+		args = append(args, *book.Subtitle)
+	}
+	if book.OriginalTitle != nil {
+		query += ", original_title = ?"
+		args = append(args, *book.OriginalTitle)
+	}
+	if book.Language != nil {
+		query += ", language = ?"
+		args = append(args, *book.Language)
+	}
+	if book.PageCount != nil {
+		query += ", page_count = ?"
+		args = append(args, *book.PageCount)
+	}
 
-	query += " WHERE id = ?"
-	args = append(args, book.ID)
+	// only the version the caller read may be overwritten
+	query += " WHERE id = ? AND version = ? AND deleted_at IS NULL"
+	args = append(args, book.ID, book.Version)
 
-	_, err := r.DB.ExecContext(ctx, r.DB.Rebind(query), args...)
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		r.Logger.Error("repo::UpdateNewBook - failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, r.DB.Rebind(query), args...)
 	if err != nil {
 		pqErr, ok := err.(*pq.Error)
 		if !ok {
@@ -173,22 +464,350 @@ func (r *BookRepository) UpdateNewBook(ctx context.Context, book *models.Book) e
 		}
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.Error("repo::UpdateNewBook - failed to get rows affected: ", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.Logger.Error("repo::UpdateNewBook - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	err = r.saveBookRelations(ctx, tx, book.ID, book.Contributors, book.CategoryIDs, book.Tags)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Logger.Error("repo::UpdateNewBook - failed to commit transaction: ", err)
+		return err
+	}
+
+	r.deleteCache(ctx, bookCacheKey(book.ID.String()))
+
 	return nil
 }
 
-func (r *BookRepository) DeleteBookByID(ctx context.Context, id string) error {
-	_, err := r.DB.ExecContext(ctx, r.DB.Rebind(queryDeleteBookByID), id)
+// PatchBookByID writes only the columns set on the patch, guarded by its version.
+func (r *BookRepository) PatchBookByID(ctx context.Context, patch *models.BookPatch) error {
+	var (
+		query = "UPDATE books SET updated_at = now(), version = version + 1"
+		args  = []interface{}{}
+	)
+
+	if patch.Title != nil {
+		query += ", title = ?"
+		args = append(args, *patch.Title)
+	}
+	if patch.AuthorID != nil {
+		query += ", author_id = ?"
+		args = append(args, *patch.AuthorID)
+	}
+	if patch.CategoryID != nil {
+		query += ", category_id = ?"
+		args = append(args, *patch.CategoryID)
+	}
+	if patch.Isbn != nil {
+		query += ", isbn = ?"
+		args = append(args, *patch.Isbn)
+	}
+	if patch.Description != nil {
+		query += ", description = ?"
+		args = append(args, *patch.Description)
+	}
+	if patch.PublishedDate != nil {
+		query += ", published_date = ?"
+		args = append(args, *patch.PublishedDate)
+	}
+	if patch.WorkID != nil {
+		if *patch.WorkID == uuid.Nil {
+			query += ", work_id = NULL"
+		} else {
+			query += ", work_id = ?"
+			args = append(args, *patch.WorkID)
+		}
+	}
+	if patch.Edition != nil {
+		query += ", edition = NULLIF(?, '')"
+		args = append(args, *patch.Edition)
+	}
+	if patch.Format != nil {
+		query += ", format = NULLIF(?, '')"
+		args = append(args, *patch.Format)
+	}
+	if patch.Publisher != nil {
+		query += ", publisher = NULLIF(?, '')"
+		args = append(args, *patch.Publisher)
+	}
+	if patch.Subtitle != nil {
+		query += ", subtitle = NULLIF(?, '')"
+		args = append(args, *patch.Subtitle)
+	}
+	if patch.OriginalTitle != nil {
+		query += ", original_title = NULLIF(?, '')"
+		args = append(args, *patch.OriginalTitle)
+	}
+	if patch.Language != nil {
+		query += ", language = NULLIF(?, '')"
+		args = append(args, *patch.Language)
+	}
+	if patch.PageCount != nil {
+		query += ", page_count = NULLIF(?, 0)"
+		args = append(args, *patch.PageCount)
+	}
+
+	query += " WHERE id = ? AND version = ? AND deleted_at IS NULL"
+	args = append(args, patch.ID, patch.Version)
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		r.Logger.Error("repo::PatchBookByID - failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, r.DB.Rebind(query), args...)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code.Name() == "unique_violation" {
+			r.Logger.Error("repo::PatchBookByID - isbn already exist: ", err)
+			return errors.New(constants.ErrIsbnAlreadyExist)
+		}
+
+		r.Logger.Error("repo::PatchBookByID - failed to patch book: ", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.Error("repo::PatchBookByID - failed to get rows affected: ", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.Logger.Error("repo::PatchBookByID - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	err = r.saveBookRelations(ctx, tx, patch.ID, patch.Contributors, patch.CategoryIDs, patch.Tags)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Logger.Error("repo::PatchBookByID - failed to commit transaction: ", err)
+		return err
+	}
+
+	r.deleteCache(ctx, bookCacheKey(patch.ID.String()))
+
+	return nil
+}
+
+// loadBookRelations fills the contributors, categories, tags and branch stocks of a book.
+func (r *BookRepository) loadBookRelations(ctx context.Context, book *models.Book) error {
+	book.Contributors = make([]models.BookContributor, 0)
+	err := r.DB.SelectContext(ctx, &book.Contributors, r.DB.Rebind(queryFindBookContributorsByBookID), book.ID)
+	if err != nil {
+		r.Logger.Error("repo::loadBookRelations - failed to find book contributors: ", err)
+		return err
+	}
+
+	book.CategoryIDs = make([]uuid.UUID, 0)
+	err = r.DB.SelectContext(ctx, &book.CategoryIDs, r.DB.Rebind(queryFindBookCategoriesByBookID), book.ID)
+	if err != nil {
+		r.Logger.Error("repo::loadBookRelations - failed to find book categories: ", err)
+		return err
+	}
+
+	book.Tags = make([]string, 0)
+	err = r.DB.SelectContext(ctx, &book.Tags, r.DB.Rebind(queryFindBookTagsByBookID), book.ID)
+	if err != nil {
+		r.Logger.Error("repo::loadBookRelations - failed to find book tags: ", err)
+		return err
+	}
+
+	book.BranchStocks = make([]models.BookBranchStock, 0)
+	err = r.DB.SelectContext(ctx, &book.BranchStocks, r.DB.Rebind(queryFindBookBranchStocksByBookID), book.ID)
+	if err != nil {
+		r.Logger.Error("repo::loadBookRelations - failed to find book branch stocks: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// saveBookRelations replaces each of the given lists inside tx, nil lists are left untouched.
+func (r *BookRepository) saveBookRelations(ctx context.Context, tx *sqlx.Tx, bookID uuid.UUID, contributors []models.BookContributor, categoryIDs []uuid.UUID, tags []string) error {
+	if err := r.replaceBookContributors(ctx, tx, bookID, contributors); err != nil {
+		return err
+	}
+
+	if categoryIDs != nil {
+		rows := make([][]interface{}, 0, len(categoryIDs))
+		for _, categoryID := range categoryIDs {
+			rows = append(rows, []interface{}{bookID, categoryID})
+		}
+
+		if err := r.replaceBookRows(ctx, tx, queryDeleteBookCategoriesByBookID, queryInsertBookCategories, bookID, rows); err != nil {
+			r.Logger.Error("repo::saveBookRelations - failed to replace book categories: ", err)
+			return err
+		}
+	}
+
+	if tags != nil {
+		rows := make([][]interface{}, 0, len(tags))
+		for _, tag := range tags {
+			rows = append(rows, []interface{}{bookID, tag})
+		}
+
+		if err := r.replaceBookRows(ctx, tx, queryDeleteBookTagsByBookID, queryInsertBookTags, bookID, rows); err != nil {
+			r.Logger.Error("repo::saveBookRelations - failed to replace book tags: ", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaceBookRows deletes the rows of a book and inserts the given ones with a single multi-row INSERT.
+func (r *BookRepository) replaceBookRows(ctx context.Context, tx *sqlx.Tx, deleteQuery, insertQuery string, bookID uuid.UUID, rows [][]interface{}) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(deleteQuery), bookID)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var (
+		placeholders = make([]string, 0, len(rows))
+		args         = make([]interface{}, 0, len(rows)*len(rows[0]))
+	)
+
+	for _, row := range rows {
+		placeholders = append(placeholders, "("+strings.TrimSuffix(strings.Repeat("?, ", len(row)), ", ")+")")
+		args = append(args, row...)
+	}
+
+	_, err = tx.ExecContext(ctx, r.DB.Rebind(fmt.Sprintf(insertQuery, strings.Join(placeholders, ", "))), args...)
+	return err
+}
+
+// replaceBookContributors swaps the whole contributor list inside tx, a nil list is left untouched.
+func (r *BookRepository) replaceBookContributors(ctx context.Context, tx *sqlx.Tx, bookID uuid.UUID, contributors []models.BookContributor) error {
+	if contributors == nil {
+		return nil
+	}
+
+	rows := make([][]interface{}, 0, len(contributors))
+	for _, contributor := range contributors {
+		rows = append(rows, []interface{}{bookID, contributor.AuthorID, contributor.Role, contributor.Position})
+	}
+
+	err := r.replaceBookRows(ctx, tx, queryDeleteBookContributorsByBookID, queryInsertBookContributors, bookID, rows)
+	if err != nil {
+		r.Logger.Error("repo::replaceBookContributors - failed to replace book contributors: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *BookRepository) CountActiveLoansByBookID(ctx context.Context, bookID string) (int, error) {
+	var count int
+
+	err := r.DB.GetContext(ctx, &count, r.DB.Rebind(queryCountActiveLoansByBookID), bookID)
+	if err != nil {
+		r.Logger.Error("repo::CountActiveLoansByBookID - failed to count active loans: ", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// DeleteBookByID soft deletes the book. The active loan check is repeated in
+// the UPDATE itself so a loan made after the service checked still blocks it.
+func (r *BookRepository) DeleteBookByID(ctx context.Context, id, deletedBy string) error {
+	result, err := r.DB.ExecContext(ctx, r.DB.Rebind(queryDeleteBookByID), deletedBy, id)
 	if err != nil {
 		r.Logger.Error("repo::DeleteBookByID - failed to delete book by id: ", err)
 		return err
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.Error("repo::DeleteBookByID - failed to get rows affected: ", err)
+		return err
+	}
+
+	// nothing updated means the book is gone or has active loans, tell them apart
+	if rowsAffected == 0 {
+		var count int
+
+		err = r.DB.GetContext(ctx, &count, r.DB.Rebind(queryCountActiveBookByID), id)
+		if err != nil {
+			r.Logger.Error("repo::DeleteBookByID - failed to count book by id: ", err)
+			return err
+		}
+
+		if count == 0 {
+			r.Logger.Error("repo::DeleteBookByID - Book doesnt exist")
+			return errors.New(constants.ErrBookNotFound)
+		}
+
+		r.Logger.Error("repo::DeleteBookByID - book has active loans")
+		return errors.New(constants.ErrBookHasActiveLoans)
+	}
+
+	r.deleteCache(ctx, bookCacheKey(id))
+
+	return nil
+}
+
+func (r *BookRepository) RestoreBookByID(ctx context.Context, id string) error {
+	result, err := r.DB.ExecContext(ctx, r.DB.Rebind(queryRestoreBookByID), id)
+	if err != nil {
+		r.Logger.Error("repo::RestoreBookByID - failed to restore book by id: ", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.Error("repo::RestoreBookByID - failed to get rows affected: ", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.Logger.Error("repo::RestoreBookByID - deleted book doesnt exist")
+		return errors.New(constants.ErrDeletedBookNotFound)
+	}
+
+	r.deleteCache(ctx, bookCacheKey(id))
+
 	return nil
 }
 
-func (r *BookRepository) SearchBooks(ctx context.Context, title *string, categoryID *string, authorID *string, limit, offset int) ([]models.Book, error) {
+func (r *BookRepository) FindAllDeletedBook(ctx context.Context, limit, offset int) ([]models.Book, error) {
+	var (
+		res = make([]models.Book, 0)
+	)
+
+	err := r.DB.SelectContext(ctx, &res, r.DB.Rebind(queryFindAllDeletedBook), limit, offset)
+	if err != nil {
+		r.Logger.Error("repo::FindAllDeletedBook - failed to find all deleted book: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookRepository) SearchBooks(ctx context.Context, filter *models.SearchBookFilter, page *models.PageRequest) ([]models.Book, error) {
 	var res []models.Book
-	cacheKey := r.generateSearchBooksCacheKey(title, categoryID, authorID, limit, offset)
+	cacheKey := r.generateSearchBooksCacheKey(ctx, filter, page)
 
 	cachedData, err := r.getCache(ctx, cacheKey)
 	if err == nil && cachedData != nil {
@@ -198,47 +817,305 @@ func (r *BookRepository) SearchBooks(ctx context.Context, title *string, categor
 		}
 	}
 
-	query := `
-        SELECT id, title, author_id, category_id, isbn, description, published_date, created_at, updated_at
-        FROM books
-        WHERE TRUE
-    `
+	query, args, keyset := buildSearchBooksQuery(filter)
+	query, args = keyset.Paginate(pageQuery(query, filter.WithStock), " WHERE ", args, page)
+
+	if filter.Fuzzy && filter.Title != "" {
+		err = r.withSimilarityThreshold(ctx, filter.SimilarityThreshold, func(tx *sqlx.Tx) error {
+			return tx.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
+		})
+	} else {
+		err = r.DB.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
+	}
+	if err != nil {
+		r.Logger.Error("repo::SearchBooks - failed to search books: ", err)
+		return nil, err
+	}
+
+	res = pagination.ReversePage(res, page)
+
+	if err := r.setCache(ctx, cacheKey, helpers.MarshalJSON(res), 300); err != nil {
+		r.Logger.Warn("repo::SearchBooks - failed to set cache: ", err)
+	}
+
+	return res, nil
+}
 
-	args := []interface{}{}
+func (r *BookRepository) CountSearchBooks(ctx context.Context, filter *models.SearchBookFilter) (int, error) {
+	query, args, _ := buildSearchBooksQuery(filter)
+	query = r.DB.Rebind(pagination.CountQuery(query))
 
-	if title != nil {
-		query += " AND title ILIKE ?"
-		args = append(args, "%"+*title+"%")
+	return r.cachedCount(ctx, r.generateSearchBooksCacheKey(ctx, filter, nil), func(count *int) error {
+		if filter.Fuzzy && filter.Title != "" {
+			return r.withSimilarityThreshold(ctx, filter.SimilarityThreshold, func(tx *sqlx.Tx) error {
+				return tx.GetContext(ctx, count, query, args...)
+			})
+		}
+		return r.DB.GetContext(ctx, count, query, args...)
+	})
+}
+
+// SearchBookFacets counts the whole filtered search, not only one page, per facet value.
+func (r *BookRepository) SearchBookFacets(ctx context.Context, filter *models.SearchBookFilter) ([]models.SearchFacet, error) {
+	var res []models.SearchFacet
+	cacheKey := r.generateSearchBooksCacheKey(ctx, filter, nil) + ":facets"
+
+	cachedData, err := r.getCache(ctx, cacheKey)
+	if err == nil && cachedData != nil {
+		if err := helpers.UnmarshalJSON(cachedData, &res); err == nil {
+			r.Logger.Info("repo::SearchBookFacets - returned data from cache")
+			return res, nil
+		}
 	}
-	if categoryID != nil && *categoryID != "" {
-		query += " AND category_id = ?"
-		args = append(args, *categoryID)
+
+	query, args, _ := buildSearchBooksQuery(filter)
+	query = r.DB.Rebind(fmt.Sprintf(querySearchBookFacets, query))
+	args = append(args, constants.FacetYearBucketSize, constants.FacetYearBucketSize)
+
+	if filter.Fuzzy && filter.Title != "" {
+		err = r.withSimilarityThreshold(ctx, filter.SimilarityThreshold, func(tx *sqlx.Tx) error {
+			return tx.SelectContext(ctx, &res, query, args...)
+		})
+	} else {
+		err = r.DB.SelectContext(ctx, &res, query, args...)
 	}
-	if authorID != nil && *authorID != "" {
-		query += " AND author_id = ?"
-		args = append(args, *authorID)
+	if err != nil {
+		r.Logger.Error("repo::SearchBookFacets - failed to count facets: ", err)
+		return nil, err
 	}
 
-	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	if err := r.setCache(ctx, cacheKey, helpers.MarshalJSON(res), 300); err != nil {
+		r.Logger.Warn("repo::SearchBookFacets - failed to set cache: ", err)
+	}
 
-	err = r.DB.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
+	return res, nil
+}
+
+// buildSearchBooksQuery returns the filtered search, without order or limit,
+// and the keyset its rows are paged by.
+func buildSearchBooksQuery(filter *models.SearchBookFilter) (string, []interface{}, pagination.Keyset) {
+	var (
+		query   string
+		args    = []interface{}{}
+		orderBy = "updated_at DESC, id DESC"
+		keyset  = pagination.Keyset{UpdatedAt: "p.updated_at", ID: "p.id"}
+	)
+
+	if filter.Query != "" {
+		// full-text search goes through idx_books_title_description_gin and is ordered by relevance
+		query = querySearchBooksFullText
+		args = append(args, filter.Query)
+		orderBy = "rank DESC, " + orderBy
+		keyset.Column, keyset.Desc = "p.rank", true
+	} else if filter.Fuzzy && filter.Title != "" {
+		// rank holds the trigram word similarity of the title
+		query = querySearchBooksFuzzy
+		args = append(args, filter.Title)
+		orderBy = "rank DESC, " + orderBy
+		keyset.Column, keyset.Desc = "p.rank", true
+	} else {
+		query = `
+			SELECT b.id, b.title, b.author_id, b.category_id, b.isbn, b.description, b.published_date, b.created_at, b.updated_at, b.work_id, b.language, b.cover_updated_at
+			FROM books b
+			WHERE b.deleted_at IS NULL
+		`
+	}
+
+	if filter.Title != "" {
+		if filter.Fuzzy {
+			query += " AND ? <% b.title"
+			args = append(args, filter.Title)
+		} else {
+			query += " AND b.title ILIKE ?"
+			args = append(args, "%"+filter.Title+"%")
+		}
+	}
+	if len(filter.CategoryIDs) > 0 {
+		categoryIDs := dedupeStrings(filter.CategoryIDs)
+		if filter.MatchAllCategories {
+			query += " AND (SELECT COUNT(DISTINCT c.category_id) FROM (SELECT b.category_id UNION SELECT bcat.category_id FROM book_categories bcat WHERE bcat.book_id = b.id) c WHERE c.category_id = ANY(?)) = ?"
+			args = append(args, pq.Array(categoryIDs), len(categoryIDs))
+		} else {
+			query += " AND (b.category_id = ANY(?) OR EXISTS (SELECT 1 FROM book_categories bcat WHERE bcat.book_id = b.id AND bcat.category_id = ANY(?)))"
+			args = append(args, pq.Array(categoryIDs), pq.Array(categoryIDs))
+		}
+	}
+	if len(filter.Tags) > 0 {
+		tags := dedupeStrings(filter.Tags)
+		if filter.MatchAllTags {
+			query += " AND (SELECT COUNT(*) FROM book_tags bt WHERE bt.book_id = b.id AND bt.tag = ANY(?)) = ?"
+			args = append(args, pq.Array(tags), len(tags))
+		} else {
+			query += " AND EXISTS (SELECT 1 FROM book_tags bt WHERE bt.book_id = b.id AND bt.tag = ANY(?))"
+			args = append(args, pq.Array(tags))
+		}
+	}
+	if filter.Language != "" {
+		query += " AND b.language = ?"
+		args = append(args, filter.Language)
+	}
+	if filter.Format != "" {
+		query += " AND b.format = ?"
+		args = append(args, filter.Format)
+	}
+	if filter.Publisher != "" {
+		query += " AND lower(b.publisher) = lower(?)"
+		args = append(args, filter.Publisher)
+	}
+	if filter.AuthorID != "" {
+		// any contributor matches, not only the primary author
+		query += " AND (b.author_id = ? OR EXISTS (SELECT 1 FROM book_contributors bc WHERE bc.book_id = b.id AND bc.author_id = ?))"
+		args = append(args, filter.AuthorID, filter.AuthorID)
+	}
+	if filter.Expression != nil {
+		condition, conditionArgs := compileSearchQuery(filter.Expression)
+		query += " AND " + condition
+		args = append(args, conditionArgs...)
+	}
+
+	if filter.GroupByWork {
+		// grouping runs over the filtered rows, so each work keeps its best matching edition
+		query = fmt.Sprintf(querySearchBooksGroupByWork, query, orderBy)
+	}
+
+	return query, args, keyset
+}
+
+// withSimilarityThreshold scopes the pg_trgm threshold to a read only
+// transaction so it never leaks into other pooled connections.
+func (r *BookRepository) withSimilarityThreshold(ctx context.Context, threshold float64, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.DB.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
-		r.Logger.Error("repo::SearchBooks - failed to search books: ", err)
+		r.Logger.Error("repo::withSimilarityThreshold - failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.DB.Rebind(querySetWordSimilarityThreshold), strconv.FormatFloat(threshold, 'f', -1, 64))
+	if err != nil {
+		r.Logger.Error("repo::withSimilarityThreshold - failed to set similarity threshold: ", err)
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		r.Logger.Error("repo::withSimilarityThreshold - failed to query: ", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *BookRepository) SuggestBooks(ctx context.Context, prefix string, limit int) ([]models.Book, error) {
+	var res []models.Book
+	cacheKey := r.generateSuggestBooksCacheKey(prefix, limit)
+
+	cachedData, err := r.getCache(ctx, cacheKey)
+	if err == nil && cachedData != nil {
+		if err := helpers.UnmarshalJSON(cachedData, &res); err == nil {
+			r.Logger.Info("repo::SuggestBooks - returned data from cache")
+			return res, nil
+		}
+	}
+
+	pattern := helpers.EscapeLike(prefix) + "%"
+
+	err = r.DB.SelectContext(ctx, &res, r.DB.Rebind(querySuggestBooks), pattern, pattern, prefix, limit)
+	if err != nil {
+		r.Logger.Error("repo::SuggestBooks - failed to suggest books: ", err)
 		return nil, err
 	}
 
 	if err := r.setCache(ctx, cacheKey, helpers.MarshalJSON(res), 300); err != nil {
-		r.Logger.Warn("repo::SearchBooks - failed to set cache: ", err)
+		r.Logger.Warn("repo::SuggestBooks - failed to set cache: ", err)
 	}
 
 	return res, nil
 }
 
-func (r *BookRepository) GetRecommendations(ctx context.Context, userID string, limit, offset int) ([]models.Book, error) {
+// StreamBooksForExport walks the filtered catalog through a server side cursor,
+// fetching constants.ExportFetchSize rows at a time and handing each to fn.
+func (r *BookRepository) StreamBooksForExport(ctx context.Context, filter *models.ExportBookFilter, fn func(book *models.BookExport) error) error {
+	var (
+		query = queryExportBooks
+		args  = []interface{}{}
+	)
+
+	if filter.CategoryID != "" {
+		query += " AND b.category_id = ?"
+		args = append(args, filter.CategoryID)
+	}
+	if filter.AuthorID != "" {
+		query += " AND b.author_id = ?"
+		args = append(args, filter.AuthorID)
+	}
+	if filter.UpdatedFrom != nil {
+		query += " AND b.updated_at >= ?"
+		args = append(args, *filter.UpdatedFrom)
+	}
+	if filter.UpdatedTo != nil {
+		query += " AND b.updated_at < ?"
+		args = append(args, *filter.UpdatedTo)
+	}
+
+	query += " ORDER BY b.updated_at ASC, b.id ASC"
+
+	tx, err := r.DB.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		r.Logger.Error("repo::StreamBooksForExport - failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.DB.Rebind("DECLARE export_books_cursor NO SCROLL CURSOR FOR "+query), args...)
+	if err != nil {
+		r.Logger.Error("repo::StreamBooksForExport - failed to declare cursor: ", err)
+		return err
+	}
+
+	fetchQuery := fmt.Sprintf("FETCH %d FROM export_books_cursor", constants.ExportFetchSize)
+
+	for {
+		rows, err := tx.QueryxContext(ctx, fetchQuery)
+		if err != nil {
+			r.Logger.Error("repo::StreamBooksForExport - failed to fetch from cursor: ", err)
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var book models.BookExport
+			if err := rows.StructScan(&book); err != nil {
+				rows.Close()
+				r.Logger.Error("repo::StreamBooksForExport - failed to scan book: ", err)
+				return err
+			}
+
+			fetched++
+			if err := fn(&book); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			r.Logger.Error("repo::StreamBooksForExport - failed to iterate cursor: ", err)
+			return err
+		}
+		rows.Close()
+
+		if fetched < constants.ExportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *BookRepository) GetRecommendations(ctx context.Context, userID string, page *models.PageRequest) ([]models.Book, error) {
 	var (
 		books    []models.Book
-		cacheKey = fmt.Sprintf("recommendations:%s:%d:%d", userID, limit, offset)
+		cacheKey = fmt.Sprintf("recommendations:v%d:%s", bookCacheVersion, userID) + pageCacheKey(page)
 	)
 
 	cachedData, err := r.Redis.Get(ctx, cacheKey).Result()
@@ -251,12 +1128,16 @@ func (r *BookRepository) GetRecommendations(ctx context.Context, userID string,
 		r.Logger.Warn("category::GetRecommendations - Failed to unmarshal cache data: ", err)
 	}
 
-	err = r.DB.SelectContext(ctx, &books, r.DB.Rebind(queryGetRecommendations), userID, limit, offset)
+	query, args := recommendationKeyset.Paginate(queryGetRecommendations, " AND ", []interface{}{userID}, page)
+
+	err = r.DB.SelectContext(ctx, &books, r.DB.Rebind(query), args...)
 	if err != nil {
 		r.Logger.Error("repo::GetRecommendations - Failed to fetch recommendations: ", err)
 		return nil, err
 	}
 
+	books = pagination.ReversePage(books, page)
+
 	dataToCache, err := json.Marshal(books)
 	if err != nil {
 		r.Logger.Warn("category::GetRecommendations - Failed to marshal data for caching: ", err)
@@ -269,3 +1150,11 @@ func (r *BookRepository) GetRecommendations(ctx context.Context, userID string,
 
 	return books, nil
 }
+
+func (r *BookRepository) CountRecommendations(ctx context.Context, userID string) (int, error) {
+	cacheKey := fmt.Sprintf("recommendations:v%d:%s:count", bookCacheVersion, userID)
+
+	return r.cachedCount(ctx, cacheKey, func(count *int) error {
+		return r.DB.GetContext(ctx, count, r.DB.Rebind(pagination.CountQuery(queryGetRecommendations)), userID)
+	})
+}
@@ -3,19 +3,158 @@ package book
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/hilmiikhsan/library-book-service/constants"
 	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
 )
 
-func (r *BookRepository) generateSearchBooksCacheKey(title *string, categoryID *string, authorID *string, limit, offset int) string {
-	return fmt.Sprintf("search_books:%s:%s:%s:%d:%d",
-		helpers.SafeString(title),
-		helpers.SafeString(categoryID),
-		helpers.SafeString(authorID),
-		limit, offset,
+// bookCacheVersion is part of every cached book payload key. Bump it whenever
+// models.Book gains or changes fields, or the content of a field changes (like
+// the escaped search highlights), so entries written by the previous release
+// are never served in their old shape after a deploy.
+const bookCacheVersion = 7
+
+// stockGenerationKey counts the loan and stock changes. Cached lists and
+// searches carry stock totals, availability filters and facets, so their keys
+// include it and a change moves every one of them to a fresh key.
+const stockGenerationKey = "books:stock_generation"
+
+func bookCacheKey(id string) string {
+	return fmt.Sprintf("book:v%d:%s", bookCacheVersion, id)
+}
+
+// stockGeneration returns the current stock generation, 0 until the first
+// change or when it cannot be read.
+func (r *BookRepository) stockGeneration(ctx context.Context) int64 {
+	generation, err := r.Redis.Get(ctx, stockGenerationKey).Int64()
+	if err != nil && err != redis.Nil {
+		r.Logger.Warn("repo::stockGeneration - failed to get stock generation: ", err)
+	}
+	return generation
+}
+
+func (r *BookRepository) bumpStockGeneration(ctx context.Context) {
+	if err := r.Redis.Incr(ctx, stockGenerationKey).Err(); err != nil {
+		r.Logger.Warn("repo::bumpStockGeneration - failed to bump stock generation: ", err)
+	}
+}
+
+// generateListBooksCacheKey keys a page of GET /book/v1/, or its total count when page is nil.
+func (r *BookRepository) generateListBooksCacheKey(ctx context.Context, filter *models.ListBookFilter, page *models.PageRequest) string {
+	key := fmt.Sprintf("books:v%d:g%d:%s:%t:%s:%s:%s:%s:%s:%t:%t",
+		bookCacheVersion,
+		r.stockGeneration(ctx),
+		filter.SortBy,
+		filter.SortDesc,
+		cacheKeyDate(filter.PublishedFrom),
+		cacheKeyDate(filter.PublishedTo),
+		filter.CategoryID,
+		filter.AuthorID,
+		filter.Language,
+		filter.AvailableOnly,
+		filter.WithStock,
 	)
+
+	if page == nil {
+		return key + ":count"
+	}
+	return key + pageCacheKey(page)
+}
+
+func cacheKeyDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(constants.DateTimeFormat)
+}
+
+// generateSearchBooksCacheKey keys a page of a search, or its total count when
+// page is nil. The facets of a search are kept under the count key plus ":facets".
+func (r *BookRepository) generateSearchBooksCacheKey(ctx context.Context, filter *models.SearchBookFilter, page *models.PageRequest) string {
+	key := fmt.Sprintf("search_books:v%d:g%d:%s:%s:%s:%s:%t:%s:%t:%s:%s:%s:%t:%t:%g:%t:%s",
+		bookCacheVersion,
+		r.stockGeneration(ctx),
+		filter.Title,
+		filter.AuthorID,
+		filter.Query,
+		cacheKeyList(filter.CategoryIDs),
+		filter.MatchAllCategories,
+		cacheKeyList(filter.Tags),
+		filter.MatchAllTags,
+		filter.Language,
+		filter.Format,
+		strings.ToLower(filter.Publisher),
+		filter.GroupByWork,
+		filter.Fuzzy,
+		filter.SimilarityThreshold,
+		filter.WithStock,
+		filter.Expression.String(),
+	)
+
+	if page == nil {
+		return key + ":count"
+	}
+	return key + pageCacheKey(page)
+}
+
+func pageCacheKey(page *models.PageRequest) string {
+	return fmt.Sprintf(":limit:%d:offset:%d:cursor:%s", page.Limit, page.Offset, pagination.EncodeCursor(page.Cursor))
+}
+
+// cacheKeyList joins a filter list in a stable order so equivalent queries share a key.
+func cacheKeyList(values []string) string {
+	sorted := dedupeStrings(values)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func dedupeStrings(values []string) []string {
+	var (
+		res  = make([]string, 0, len(values))
+		seen = make(map[string]bool, len(values))
+	)
+
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		res = append(res, value)
+	}
+	return res
+}
+
+func (r *BookRepository) generateSuggestBooksCacheKey(prefix string, limit int) string {
+	return fmt.Sprintf("suggest_books:v%d:%s:%d", bookCacheVersion, strings.ToLower(prefix), limit)
+}
+
+// cachedCount returns the cached count under key, running count on a miss.
+func (r *BookRepository) cachedCount(ctx context.Context, key string, count func(dest *int) error) (int, error) {
+	var res int
+
+	cachedData, err := r.getCache(ctx, key)
+	if err == nil && cachedData != nil {
+		if err := helpers.UnmarshalJSON(cachedData, &res); err == nil {
+			return res, nil
+		}
+	}
+
+	if err := count(&res); err != nil {
+		r.Logger.Error("repo::cachedCount - failed to count: ", err)
+		return 0, err
+	}
+
+	if err := r.setCache(ctx, key, helpers.MarshalJSON(res), 300); err != nil {
+		r.Logger.Warn("repo::cachedCount - failed to set cache: ", err)
+	}
+
+	return res, nil
 }
 
 func (r *BookRepository) getCache(ctx context.Context, key string) ([]byte, error) {
@@ -32,3 +171,9 @@ func (r *BookRepository) getCache(ctx context.Context, key string) ([]byte, erro
 func (r *BookRepository) setCache(ctx context.Context, key string, data []byte, ttl int) error {
 	return r.Redis.Set(ctx, key, data, time.Duration(ttl)*time.Second).Err()
 }
+
+func (r *BookRepository) deleteCache(ctx context.Context, key string) {
+	if err := r.Redis.Del(ctx, key).Err(); err != nil {
+		r.Logger.Warn("repo::deleteCache - failed to delete cache: ", err)
+	}
+}
@@ -0,0 +1,31 @@
+package book
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+)
+
+// UpdateBookCover marks the cover as replaced and returns the new timestamp,
+// which versions the cover URLs.
+func (r *BookRepository) UpdateBookCover(ctx context.Context, id string) (time.Time, error) {
+	var updatedAt time.Time
+
+	err := r.DB.QueryRowxContext(ctx, r.DB.Rebind(queryUpdateBookCover), id).Scan(&updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::UpdateBookCover - Book doesnt exist")
+			return updatedAt, errors.New(constants.ErrBookNotFound)
+		}
+
+		r.Logger.Error("repo::UpdateBookCover - failed to update book cover: ", err)
+		return updatedAt, err
+	}
+
+	r.deleteCache(ctx, bookCacheKey(id))
+
+	return updatedAt, nil
+}
@@ -9,39 +9,395 @@ const (
 			category_id,
 			isbn,
 			description,
-			published_date
-		) VALUES (?, ?, ?, ?, ?, ?)
+			published_date,
+			work_id,
+			edition,
+			format,
+			publisher,
+			subtitle,
+			original_title,
+			language,
+			page_count
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	queryFindBookContributorsByBookID = `
+		SELECT
+			book_id,
+			author_id,
+			role,
+			position
+		FROM book_contributors
+		WHERE book_id = ?
+		ORDER BY position ASC
+	`
+
+	queryDeleteBookContributorsByBookID = `
+		DELETE FROM book_contributors WHERE book_id = ?
+	`
+
+	queryFindBookCategoriesByBookID = `
+		SELECT category_id FROM book_categories WHERE book_id = ? ORDER BY created_at ASC, category_id ASC
+	`
+
+	queryDeleteBookCategoriesByBookID = `
+		DELETE FROM book_categories WHERE book_id = ?
+	`
+
+	queryInsertBookCategories = `
+		INSERT INTO book_categories (book_id, category_id) VALUES %s
+	`
+
+	queryFindBookTagsByBookID = `
+		SELECT tag FROM book_tags WHERE book_id = ? ORDER BY tag ASC
+	`
+
+	queryFindBookBranchStocksByBookID = `
+		SELECT
+			bs.branch_id,
+			br.name AS branch_name,
+			bs.total_stock,
+			bs.available_stock
+		FROM book_stocks bs
+		JOIN branches br ON bs.branch_id = br.id
+		WHERE bs.book_id = ?
+		ORDER BY br.name ASC
+	`
+
+	queryDeleteBookTagsByBookID = `
+		DELETE FROM book_tags WHERE book_id = ?
+	`
+
+	queryInsertBookTags = `
+		INSERT INTO book_tags (book_id, tag) VALUES %s
+	`
+
+	queryInsertBookContributors = `
+		INSERT INTO book_contributors
+		(
+			book_id,
+			author_id,
+			role,
+			position
+		) VALUES %s
 	`
 
 	queryFindBookByID = `
 		SELECT
 			id,
+			title,
 			author_id,
 			category_id,
 			isbn,
 			description,
 			published_date,
 			created_at,
-			updated_at
+			updated_at,
+			version,
+			work_id,
+			edition,
+			format,
+			publisher,
+			subtitle,
+			original_title,
+			language,
+			page_count,
+			cover_updated_at,
+			COALESCE((SELECT SUM(bs.total_stock) FROM book_stocks bs WHERE bs.book_id = books.id), 0) AS total_stock,
+			COALESCE((SELECT SUM(bs.available_stock) FROM book_stocks bs WHERE bs.book_id = books.id), 0) AS available_stock,
+			(SELECT COUNT(*) FROM borrowed_books bb WHERE bb.book_id = books.id AND bb.returned_date IS NULL) AS active_loans,
+			(SELECT MIN(bb.due_date) FROM borrowed_books bb WHERE bb.book_id = books.id AND bb.returned_date IS NULL) AS expected_available_at
 		FROM books
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
+	queryFindBookByIsbn = `
+		SELECT
+			id,
+			title,
+			author_id,
+			category_id,
+			isbn,
+			description,
+			published_date,
+			created_at,
+			updated_at,
+			version,
+			work_id,
+			edition,
+			format,
+			publisher,
+			subtitle,
+			original_title,
+			language,
+			page_count,
+			cover_updated_at,
+			COALESCE((SELECT SUM(bs.total_stock) FROM book_stocks bs WHERE bs.book_id = books.id), 0) AS total_stock,
+			COALESCE((SELECT SUM(bs.available_stock) FROM book_stocks bs WHERE bs.book_id = books.id), 0) AS available_stock,
+			(SELECT COUNT(*) FROM borrowed_books bb WHERE bb.book_id = books.id AND bb.returned_date IS NULL) AS active_loans,
+			(SELECT MIN(bb.due_date) FROM borrowed_books bb WHERE bb.book_id = books.id AND bb.returned_date IS NULL) AS expected_available_at
+		FROM books
+		WHERE isbn = ? AND deleted_at IS NULL
+	`
+
+	// queryFindAllBook takes the extra columns a sort needs
 	queryFindAllBook = `
+		SELECT
+			b.id,
+			b.title,
+			b.author_id,
+			b.category_id,
+			b.description,
+			b.isbn,
+			b.published_date,
+			b.created_at,
+			b.updated_at,
+			b.cover_updated_at%s
+		FROM books b
+		WHERE b.deleted_at IS NULL
+	`
+
+	// querySelectPage wraps a list query so it is paged by its output columns
+	querySelectPage = `SELECT * FROM (%s) p`
+
+	// querySelectPageWithStock also joins the stock totals, books without stock have none
+	querySelectPageWithStock = `
+		SELECT
+			p.*,
+			COALESCE(bs.total_stock, 0) AS total_stock,
+			COALESCE(bs.available_stock, 0) AS available_stock
+		FROM (%s) p
+		LEFT JOIN (
+			SELECT book_id, SUM(total_stock) AS total_stock, SUM(available_stock) AS available_stock
+			FROM book_stocks
+			GROUP BY book_id
+		) bs ON bs.book_id = p.id
+	`
+
+	queryBookPopularityColumn = `, (SELECT COUNT(*) FROM borrowed_books bb WHERE bb.book_id = b.id) AS popularity`
+
+	queryFindBooksByIDs = `
 		SELECT
 			id,
 			title,
 			description,
 			isbn,
-			published_date
+			published_date,
+			cover_updated_at
 		FROM books
+		WHERE id = ANY(?) AND deleted_at IS NULL
 		ORDER BY updated_at DESC
+	`
+
+	queryCountActiveLoansByBookID = `
+		SELECT COUNT(id) FROM borrowed_books WHERE book_id = ? AND returned_date IS NULL
+	`
+
+	queryDeleteBookByID = `
+		UPDATE books
+		SET
+			deleted_at = now(),
+			deleted_by = ?,
+			updated_at = now(),
+			version = version + 1
+		WHERE id = ? AND deleted_at IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM borrowed_books WHERE book_id = books.id AND returned_date IS NULL
+		)
+	`
+
+	queryCountActiveBookByID = `
+		SELECT COUNT(id) FROM books WHERE id = ? AND deleted_at IS NULL
+	`
+
+	queryRestoreBookByID = `
+		UPDATE books
+		SET
+			deleted_at = NULL,
+			deleted_by = NULL,
+			updated_at = now(),
+			version = version + 1
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`
+
+	queryFindAllDeletedBook = `
+		SELECT
+			id,
+			title,
+			description,
+			isbn,
+			published_date,
+			deleted_at,
+			deleted_by
+		FROM books
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
 		LIMIT ?
 		OFFSET ?
 	`
 
-	queryDeleteBookByID = `
-		DELETE FROM books WHERE id = ?
+	// querySearchBooksFullText escapes the title and description before they
+	// are highlighted, so only the <mark> tags reach the client as markup
+	querySearchBooksFullText = `
+		SELECT
+			b.id,
+			b.title,
+			b.author_id,
+			b.category_id,
+			b.isbn,
+			b.description,
+			b.published_date,
+			b.created_at,
+			b.updated_at,
+			b.work_id,
+			b.language,
+			b.cover_updated_at,
+			ts_rank(to_tsvector('english', b.title || ' ' || b.description), q) AS rank,
+			ts_headline('english', replace(replace(replace(b.title, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS title_highlight,
+			ts_headline('english', replace(replace(replace(b.description, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), q, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS description_highlight
+		FROM books b
+		CROSS JOIN websearch_to_tsquery('english', ?) q
+		WHERE to_tsvector('english', b.title || ' ' || b.description) @@ q
+		AND b.deleted_at IS NULL
+	`
+
+	// querySearchBooksGroupByWork wraps a filtered search, standalone books are their own group
+	querySearchBooksGroupByWork = `
+		SELECT DISTINCT ON (COALESCE(s.work_id, s.id))
+			s.*,
+			COUNT(*) OVER (PARTITION BY COALESCE(s.work_id, s.id)) AS edition_count
+		FROM (%s) s
+		ORDER BY COALESCE(s.work_id, s.id), %s
+	`
+
+	querySearchBooksFuzzy = `
+		SELECT
+			b.id,
+			b.title,
+			b.author_id,
+			b.category_id,
+			b.isbn,
+			b.description,
+			b.published_date,
+			b.created_at,
+			b.updated_at,
+			b.work_id,
+			b.language,
+			b.cover_updated_at,
+			word_similarity(?, b.title) AS rank
+		FROM books b
+		WHERE b.deleted_at IS NULL
+	`
+
+	// querySearchBookFacets counts the filtered search f per facet value. A book
+	// counts once for each of its categories and contributors, published years
+	// are bucketed by the given size.
+	querySearchBookFacets = `
+		WITH f AS (%s)
+		SELECT 'category' AS facet, c.category_id::text AS value, COUNT(DISTINCT f.id) AS count
+		FROM f
+		CROSS JOIN LATERAL (
+			SELECT f.category_id
+			UNION
+			SELECT bcat.category_id FROM book_categories bcat WHERE bcat.book_id = f.id
+		) c (category_id)
+		GROUP BY 2
+		UNION ALL
+		SELECT 'author', a.author_id::text, COUNT(DISTINCT f.id)
+		FROM f
+		CROSS JOIN LATERAL (
+			SELECT f.author_id
+			UNION
+			SELECT bc.author_id FROM book_contributors bc WHERE bc.book_id = f.id
+		) a (author_id)
+		GROUP BY 2
+		UNION ALL
+		SELECT 'published_year', ((EXTRACT(YEAR FROM f.published_date)::int / ?) * ?)::text, COUNT(*)
+		FROM f
+		WHERE f.published_date IS NOT NULL
+		GROUP BY 2
+		UNION ALL
+		SELECT 'language', f.language, COUNT(*)
+		FROM f
+		WHERE f.language IS NOT NULL
+		GROUP BY 2
+		UNION ALL
+		SELECT
+			'availability',
+			CASE
+				WHEN EXISTS (SELECT 1 FROM book_stocks bs WHERE bs.book_id = f.id AND bs.available_stock > 0) THEN 'available'
+				ELSE 'unavailable'
+			END,
+			COUNT(*)
+		FROM f
+		GROUP BY 2
+	`
+
+	querySetWordSimilarityThreshold = `
+		SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)
+	`
+
+	querySuggestBooks = `
+		SELECT
+			id,
+			title,
+			isbn
+		FROM books
+		WHERE (title ILIKE ? OR isbn LIKE ?) AND deleted_at IS NULL
+		ORDER BY similarity(title, ?) DESC, title ASC
+		LIMIT ?
+	`
+
+	queryFindExistingIsbns = `
+		SELECT isbn FROM books WHERE isbn = ANY(?)
+	`
+
+	queryInsertNewBooks = `
+		INSERT INTO books
+		(
+			title,
+			author_id,
+			category_id,
+			isbn,
+			description,
+			published_date,
+			work_id,
+			edition,
+			format,
+			publisher,
+			subtitle,
+			original_title,
+			language,
+			page_count
+		) VALUES %s
+		ON CONFLICT (isbn) DO NOTHING
+		RETURNING id, isbn
+	`
+
+	queryExportBooks = `
+		SELECT
+			b.id,
+			b.title,
+			b.author_id,
+			b.category_id,
+			b.isbn,
+			b.description,
+			b.published_date,
+			b.created_at,
+			b.updated_at,
+			COALESCE(bs.total_stock, 0) AS total_stock,
+			COALESCE(bs.available_stock, 0) AS available_stock
+		FROM books b
+		LEFT JOIN (
+			SELECT
+				book_id,
+				SUM(total_stock) AS total_stock,
+				SUM(available_stock) AS available_stock
+			FROM book_stocks
+			GROUP BY book_id
+		) bs ON bs.book_id = b.id
+		WHERE b.deleted_at IS NULL
 	`
 
 	queryGetRecommendations = `
@@ -51,11 +407,80 @@ const (
 			b.author_id, 
 			b.category_id, 
 			b.description, 
-			b.published_date
+			b.published_date,
+			b.updated_at,
+			b.cover_updated_at
+		FROM books b
+		WHERE b.deleted_at IS NULL
+		AND EXISTS (
+			SELECT 1
+			FROM book_user_preferences p
+			WHERE p.user_id = ?
+			AND (
+				p.preferred_category = b.category_id
+				OR EXISTS (
+					SELECT 1 FROM book_categories bcat
+					WHERE bcat.book_id = b.id AND bcat.category_id = p.preferred_category
+				)
+			)
+		)
+	`
+)
+
+const (
+	queryInsertNewWork = `
+		INSERT INTO works
+		(
+			title,
+			description
+		) VALUES (?, ?)
+		RETURNING id
+	`
+
+	queryFindWorkByID = `
+		SELECT
+			id,
+			title,
+			description,
+			created_at,
+			updated_at
+		FROM works
+		WHERE id = ?
+	`
+
+	queryFindEditionsByWorkID = `
+		SELECT
+			b.id,
+			b.title,
+			b.isbn,
+			b.edition,
+			b.format,
+			b.publisher,
+			b.published_date,
+			COALESCE(bs.total_stock, 0) AS total_stock,
+			COALESCE(bs.available_stock, 0) AS available_stock
 		FROM books b
-		INNER JOIN book_user_preferences p ON b.category_id = p.preferred_category
-		WHERE p.user_id = ?
-		ORDER BY b.published_date DESC
-		LIMIT ? OFFSET ?
+		LEFT JOIN (
+			SELECT
+				book_id,
+				SUM(total_stock) AS total_stock,
+				SUM(available_stock) AS available_stock
+			FROM book_stocks
+			GROUP BY book_id
+		) bs ON bs.book_id = b.id
+		WHERE b.work_id = ? AND b.deleted_at IS NULL
+		ORDER BY b.published_date DESC, b.created_at DESC
+	`
+)
+
+const (
+	queryUpdateBookCover = `
+		UPDATE books
+		SET
+			cover_updated_at = now(),
+			updated_at = now(),
+			version = version + 1
+		WHERE id = ? AND deleted_at IS NULL
+		RETURNING cover_updated_at
 	`
 )
@@ -0,0 +1,73 @@
+package book
+
+import (
+	"strings"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+// compileSearchQuery turns a parsed advanced query into a condition on books b.
+// Field names only select one of the fixed fragments below, every value is a
+// bind parameter.
+func compileSearchQuery(node *models.SearchQueryNode) (string, []interface{}) {
+	switch node.Op {
+	case constants.SearchQueryAnd, constants.SearchQueryOr:
+		var (
+			parts = make([]string, 0, len(node.Children))
+			args  = make([]interface{}, 0)
+		)
+
+		for _, child := range node.Children {
+			part, childArgs := compileSearchQuery(child)
+			parts = append(parts, part)
+			args = append(args, childArgs...)
+		}
+
+		return "(" + strings.Join(parts, " "+strings.ToUpper(node.Op)+" ") + ")", args
+	case constants.SearchQueryNot:
+		// IS NOT TRUE keeps books whose column is NULL, -language:en still lists books without a language
+		part, args := compileSearchQuery(node.Children[0])
+		return "(" + part + ") IS NOT TRUE", args
+	}
+
+	switch node.Field {
+	case constants.SearchFieldTitle:
+		return "b.title ILIKE ?", []interface{}{"%" + helpers.EscapeLike(node.Value) + "%"}
+	case constants.SearchFieldPublisher:
+		return "b.publisher ILIKE ?", []interface{}{"%" + helpers.EscapeLike(node.Value) + "%"}
+	case constants.SearchFieldAuthor:
+		return "(b.author_id = ? OR EXISTS (SELECT 1 FROM book_contributors bc WHERE bc.book_id = b.id AND bc.author_id = ?))",
+			[]interface{}{node.Value, node.Value}
+	case constants.SearchFieldCategory:
+		return "(b.category_id = ? OR EXISTS (SELECT 1 FROM book_categories bcat WHERE bcat.book_id = b.id AND bcat.category_id = ?))",
+			[]interface{}{node.Value, node.Value}
+	case constants.SearchFieldIsbn:
+		return "b.isbn = ?", []interface{}{node.Value}
+	case constants.SearchFieldYear:
+		if !node.IsRange {
+			return "EXTRACT(YEAR FROM b.published_date) = ?", []interface{}{node.Value}
+		}
+
+		parts, args := make([]string, 0, 2), make([]interface{}, 0, 2)
+		if node.From != "" {
+			parts = append(parts, "EXTRACT(YEAR FROM b.published_date) >= ?")
+			args = append(args, node.From)
+		}
+		if node.To != "" {
+			parts = append(parts, "EXTRACT(YEAR FROM b.published_date) <= ?")
+			args = append(args, node.To)
+		}
+		return "(" + strings.Join(parts, " AND ") + ")", args
+	case constants.SearchFieldLanguage:
+		return "b.language = ?", []interface{}{node.Value}
+	case constants.SearchFieldFormat:
+		return "b.format = ?", []interface{}{node.Value}
+	case constants.SearchFieldTag:
+		return "EXISTS (SELECT 1 FROM book_tags bt WHERE bt.book_id = b.id AND bt.tag = ?)", []interface{}{node.Value}
+	}
+
+	pattern := "%" + helpers.EscapeLike(node.Value) + "%"
+	return "(b.title ILIKE ? OR b.description ILIKE ?)", []interface{}{pattern, pattern}
+}
@@ -0,0 +1,141 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+func term(field, value string) *models.SearchQueryNode {
+	return &models.SearchQueryNode{Op: constants.SearchQueryTerm, Field: field, Value: value}
+}
+
+func TestCompileSearchQuery(t *testing.T) {
+	const (
+		authorID   = "9b2f4c1e-6a43-4d0b-9d5e-1f3a2b4c5d6e"
+		categoryID = "1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+	)
+
+	tests := []struct {
+		name     string
+		node     *models.SearchQueryNode
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "bare term",
+			node:     term("", "dune"),
+			wantSQL:  "(b.title ILIKE ? OR b.description ILIKE ?)",
+			wantArgs: []interface{}{"%dune%", "%dune%"},
+		},
+		{
+			name:     "like wildcards are escaped",
+			node:     term(constants.SearchFieldTitle, `50%_off\`),
+			wantSQL:  "b.title ILIKE ?",
+			wantArgs: []interface{}{`%50\%\_off\\%`},
+		},
+		{
+			name:     "quotes stay in the bind parameter",
+			node:     term(constants.SearchFieldPublisher, `O'Reilly "Media"`),
+			wantSQL:  "b.publisher ILIKE ?",
+			wantArgs: []interface{}{`%O'Reilly "Media"%`},
+		},
+		{
+			name:     "author",
+			node:     term(constants.SearchFieldAuthor, authorID),
+			wantSQL:  "(b.author_id = ? OR EXISTS (SELECT 1 FROM book_contributors bc WHERE bc.book_id = b.id AND bc.author_id = ?))",
+			wantArgs: []interface{}{authorID, authorID},
+		},
+		{
+			name:     "category",
+			node:     term(constants.SearchFieldCategory, categoryID),
+			wantSQL:  "(b.category_id = ? OR EXISTS (SELECT 1 FROM book_categories bcat WHERE bcat.book_id = b.id AND bcat.category_id = ?))",
+			wantArgs: []interface{}{categoryID, categoryID},
+		},
+		{
+			name:     "isbn",
+			node:     term(constants.SearchFieldIsbn, "9780306406157"),
+			wantSQL:  "b.isbn = ?",
+			wantArgs: []interface{}{"9780306406157"},
+		},
+		{
+			name:     "year",
+			node:     term(constants.SearchFieldYear, "1965"),
+			wantSQL:  "EXTRACT(YEAR FROM b.published_date) = ?",
+			wantArgs: []interface{}{"1965"},
+		},
+		{
+			name:     "year range",
+			node:     &models.SearchQueryNode{Op: constants.SearchQueryTerm, Field: constants.SearchFieldYear, IsRange: true, From: "1960", To: "1970"},
+			wantSQL:  "(EXTRACT(YEAR FROM b.published_date) >= ? AND EXTRACT(YEAR FROM b.published_date) <= ?)",
+			wantArgs: []interface{}{"1960", "1970"},
+		},
+		{
+			name:     "year range open start",
+			node:     &models.SearchQueryNode{Op: constants.SearchQueryTerm, Field: constants.SearchFieldYear, IsRange: true, To: "1970"},
+			wantSQL:  "(EXTRACT(YEAR FROM b.published_date) <= ?)",
+			wantArgs: []interface{}{"1970"},
+		},
+		{
+			name:     "year range open end",
+			node:     &models.SearchQueryNode{Op: constants.SearchQueryTerm, Field: constants.SearchFieldYear, IsRange: true, From: "1960"},
+			wantSQL:  "(EXTRACT(YEAR FROM b.published_date) >= ?)",
+			wantArgs: []interface{}{"1960"},
+		},
+		{
+			name:     "language",
+			node:     term(constants.SearchFieldLanguage, "en"),
+			wantSQL:  "b.language = ?",
+			wantArgs: []interface{}{"en"},
+		},
+		{
+			name:     "format",
+			node:     term(constants.SearchFieldFormat, "ebook"),
+			wantSQL:  "b.format = ?",
+			wantArgs: []interface{}{"ebook"},
+		},
+		{
+			name:     "tag",
+			node:     term(constants.SearchFieldTag, "science fiction"),
+			wantSQL:  "EXISTS (SELECT 1 FROM book_tags bt WHERE bt.book_id = b.id AND bt.tag = ?)",
+			wantArgs: []interface{}{"science fiction"},
+		},
+		{
+			name: "not keeps null columns",
+			node: &models.SearchQueryNode{Op: constants.SearchQueryNot, Children: []*models.SearchQueryNode{
+				term(constants.SearchFieldLanguage, "en"),
+			}},
+			wantSQL:  "(b.language = ?) IS NOT TRUE",
+			wantArgs: []interface{}{"en"},
+		},
+		{
+			name: "nested groups keep their order and args",
+			node: &models.SearchQueryNode{Op: constants.SearchQueryAnd, Children: []*models.SearchQueryNode{
+				term(constants.SearchFieldTitle, "dune"),
+				{Op: constants.SearchQueryOr, Children: []*models.SearchQueryNode{
+					term(constants.SearchFieldTag, "scifi"),
+					{Op: constants.SearchQueryNot, Children: []*models.SearchQueryNode{
+						term(constants.SearchFieldFormat, "audio"),
+					}},
+				}},
+				term(constants.SearchFieldIsbn, "9780306406157"),
+			}},
+			wantSQL:  "(b.title ILIKE ? AND (EXISTS (SELECT 1 FROM book_tags bt WHERE bt.book_id = b.id AND bt.tag = ?) OR (b.format = ?) IS NOT TRUE) AND b.isbn = ?)",
+			wantArgs: []interface{}{"%dune%", "scifi", "audio", "9780306406157"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs := compileSearchQuery(tt.node)
+			if gotSQL != tt.wantSQL {
+				t.Errorf("compileSearchQuery(%s) sql = %s, want %s", tt.node, gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("compileSearchQuery(%s) args = %v, want %v", tt.node, gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
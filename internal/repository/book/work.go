@@ -0,0 +1,56 @@
+package book
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+func (r *BookRepository) InsertNewWork(ctx context.Context, work *models.Work) error {
+	err := r.DB.QueryRowxContext(ctx, r.DB.Rebind(queryInsertNewWork),
+		work.Title,
+		work.Description,
+	).Scan(&work.ID)
+	if err != nil {
+		r.Logger.Error("repo::InsertNewWork - failed to insert new work: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *BookRepository) FindWorkByID(ctx context.Context, id string) (*models.Work, error) {
+	var (
+		res = new(models.Work)
+	)
+
+	err := r.DB.GetContext(ctx, res, r.DB.Rebind(queryFindWorkByID), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::FindWorkByID - Work doesnt exist")
+			return res, errors.New(constants.ErrWorkNotFound)
+		}
+
+		r.Logger.Error("repo::FindWorkByID - failed to find work by id: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookRepository) FindEditionsByWorkID(ctx context.Context, workID string) ([]models.WorkEdition, error) {
+	var (
+		res = make([]models.WorkEdition, 0)
+	)
+
+	err := r.DB.SelectContext(ctx, &res, r.DB.Rebind(queryFindEditionsByWorkID), workID)
+	if err != nil {
+		r.Logger.Error("repo::FindEditionsByWorkID - failed to find editions by work id: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -22,6 +22,8 @@ func (r *BookBorrowedRepository) InsertNewBookBorrowed(ctx context.Context, tx *
 		bookBorrowed.UserID,
 		bookBorrowed.BookID,
 		bookBorrowed.DueDate,
+		bookBorrowed.CopyID,
+		bookBorrowed.BranchID,
 	)
 	if err != nil {
 		r.Logger.Error("repo::InsertNewBookBorrowed - Failed to insert new book borrowed : ", err)
@@ -50,8 +52,8 @@ func (r *BookBorrowedRepository) ValidateBookBorrowed(ctx context.Context, tx *s
 	return nil
 }
 
-func (r *BookBorrowedRepository) UpdateBookReturned(ctx context.Context, tx *sql.Tx, returnedDate time.Time, bookID, userID string) error {
-	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryUpdateBookReturned), returnedDate, bookID, userID)
+func (r *BookBorrowedRepository) UpdateBookReturned(ctx context.Context, tx *sql.Tx, returnedDate time.Time, id, returnedBranchID string) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryUpdateBookReturned), returnedDate, returnedBranchID, id)
 	if err != nil {
 		r.Logger.Error("repo::UpdateBookReturned - Failed to update book returned : ", err)
 		return err
@@ -60,6 +62,26 @@ func (r *BookBorrowedRepository) UpdateBookReturned(ctx context.Context, tx *sql
 	return nil
 }
 
+// FindActiveLoan locks the open loan of the user and returns its id, copy and
+// branch, nil when there is no open loan.
+func (r *BookBorrowedRepository) FindActiveLoan(ctx context.Context, tx *sql.Tx, bookID, userID string) (*models.BookBorrowed, error) {
+	var (
+		res = new(models.BookBorrowed)
+	)
+
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryFindActiveLoan), bookID, userID).Scan(&res.ID, &res.CopyID, &res.BranchID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		r.Logger.Error("repo::FindActiveLoan - Failed to find active loan : ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (r *BookBorrowedRepository) ValidateBookReturned(ctx context.Context, tx *sql.Tx, bookID, userID string) error {
 	var (
 		count int
@@ -78,3 +100,24 @@ func (r *BookBorrowedRepository) ValidateBookReturned(ctx context.Context, tx *s
 
 	return nil
 }
+
+// ValidateLoansWithoutCopy rejects a book with loans at the branch counted only
+// against its stock, they were never tied to a copy the copy sync could see.
+func (r *BookBorrowedRepository) ValidateLoansWithoutCopy(ctx context.Context, tx *sql.Tx, bookID, branchID string) error {
+	var (
+		count int
+	)
+
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryValidateLoansWithoutCopy), bookID, branchID).Scan(&count)
+	if err != nil {
+		r.Logger.Error("repo::ValidateLoansWithoutCopy - Failed to validate loans without copy : ", err)
+		return err
+	}
+
+	if count > 0 {
+		r.Logger.Error("repo::ValidateLoansWithoutCopy - Book has loans without copy")
+		return errors.New(constants.ErrBookHasLoansWithoutCopy)
+	}
+
+	return nil
+}
@@ -6,8 +6,10 @@ const (
 		(
 			user_id,
 			book_id,
-			due_date
-		) VALUES (?, ?, ?)
+			due_date,
+			copy_id,
+			branch_id
+		) VALUES (?, ?, ?, ?, ?)
 	`
 
 	queryValidateBookBorrowed = `
@@ -20,8 +22,9 @@ const (
 		UPDATE borrowed_books 
 		SET 
 			returned_date = ?,
+			returned_branch_id = ?,
 			updated_at = NOW()
-		WHERE book_id = ? AND user_id = ?
+		WHERE id = ?
 	`
 
 	queryValidateBookReturned = `
@@ -29,4 +32,19 @@ const (
 		FROM borrowed_books
 		WHERE book_id = ? AND user_id = ? AND returned_date IS NOT NULL
 	`
+
+	queryValidateLoansWithoutCopy = `
+		SELECT COUNT(id)
+		FROM borrowed_books
+		WHERE book_id = ? AND branch_id = ? AND copy_id IS NULL AND returned_date IS NULL
+	`
+
+	queryFindActiveLoan = `
+		SELECT id, copy_id, branch_id
+		FROM borrowed_books
+		WHERE book_id = ? AND user_id = ? AND returned_date IS NULL
+		ORDER BY borrowed_date
+		LIMIT 1
+		FOR UPDATE
+	`
 )
@@ -0,0 +1,319 @@
+package book_copy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var bookCopyKeyset = pagination.Keyset{UpdatedAt: "bc.updated_at", ID: "bc.id"}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type BookCopyRepository struct {
+	DB     *sqlx.DB
+	Logger *logrus.Logger
+}
+
+func (r *BookCopyRepository) InsertNewBookCopy(ctx context.Context, tx *sql.Tx, bookCopy *models.BookCopy) error {
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryInsertNewBookCopy),
+		bookCopy.BookID,
+		bookCopy.BranchID,
+		bookCopy.Barcode,
+		bookCopy.AccessionNumber,
+		bookCopy.AcquisitionDate,
+		bookCopy.ShelfLocation,
+		bookCopy.Status,
+	).Scan(&bookCopy.ID)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code.Name() == "unique_violation" {
+			r.Logger.Error("repo::InsertNewBookCopy - barcode or accession number already exist: ", err)
+			return errors.New(constants.ErrBookCopyAlreadyExist)
+		}
+
+		r.Logger.Error("repo::InsertNewBookCopy - Failed to insert new book copy : ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *BookCopyRepository) FindBookCopyByID(ctx context.Context, id string) (*models.BookCopy, error) {
+	var (
+		res = new(models.BookCopy)
+	)
+
+	err := r.DB.GetContext(ctx, res, r.DB.Rebind(queryFindBookCopyByID), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::FindBookCopyByID - BookCopy doesnt exist")
+			return res, errors.New(constants.ErrBookCopyNotFound)
+		}
+
+		r.Logger.Error("repo::FindBookCopyByID - failed to find book copy by id: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookCopyRepository) FindAllBookCopy(ctx context.Context, filter *models.BookCopyFilter, page *models.PageRequest) ([]models.BookCopy, error) {
+	var (
+		res         = make([]models.BookCopy, 0)
+		where, args = buildBookCopyFilter(filter)
+	)
+
+	query, args := bookCopyKeyset.Paginate(queryFindAllBookCopy+where, " AND ", args, page)
+
+	err := r.DB.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
+	if err != nil {
+		r.Logger.Error("repo::FindAllBookCopy - failed to find all book copy: ", err)
+		return nil, err
+	}
+
+	return pagination.ReversePage(res, page), nil
+}
+
+func (r *BookCopyRepository) CountAllBookCopy(ctx context.Context, filter *models.BookCopyFilter) (int, error) {
+	var (
+		count       int
+		where, args = buildBookCopyFilter(filter)
+	)
+
+	err := r.DB.GetContext(ctx, &count, r.DB.Rebind(pagination.CountQuery(queryFindAllBookCopy+where)), args...)
+	if err != nil {
+		r.Logger.Error("repo::CountAllBookCopy - failed to count book copy: ", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func buildBookCopyFilter(filter *models.BookCopyFilter) (string, []interface{}) {
+	var (
+		where string
+		args  = []interface{}{}
+	)
+
+	if filter.BookID != "" {
+		where += " AND bc.book_id = ?"
+		args = append(args, filter.BookID)
+	}
+	if filter.BranchID != "" {
+		where += " AND bc.branch_id = ?"
+		args = append(args, filter.BranchID)
+	}
+	if filter.Status != "" {
+		where += " AND bc.status = ?"
+		args = append(args, filter.Status)
+	}
+
+	return where, args
+}
+
+// PatchBookCopyByID writes only the columns set on the patch, guarded by its version.
+func (r *BookCopyRepository) PatchBookCopyByID(ctx context.Context, tx *sql.Tx, patch *models.BookCopyPatch) error {
+	var (
+		query = "UPDATE book_copies SET updated_at = NOW(), version = version + 1"
+		args  = []interface{}{}
+	)
+
+	if patch.Barcode != nil {
+		query += ", barcode = ?"
+		args = append(args, *patch.Barcode)
+	}
+	if patch.AccessionNumber != nil {
+		query += ", accession_number = ?"
+		args = append(args, *patch.AccessionNumber)
+	}
+	if patch.AcquisitionDate != nil {
+		if patch.AcquisitionDate.IsZero() {
+			query += ", acquisition_date = NULL"
+		} else {
+			query += ", acquisition_date = ?"
+			args = append(args, *patch.AcquisitionDate)
+		}
+	}
+	if patch.ShelfLocation != nil {
+		query += ", shelf_location = NULLIF(?, '')"
+		args = append(args, *patch.ShelfLocation)
+	}
+	if patch.Status != nil {
+		query += ", status = ?"
+		args = append(args, *patch.Status)
+	}
+
+	query += " WHERE id = ? AND version = ?"
+	args = append(args, patch.ID, patch.Version)
+
+	result, err := tx.ExecContext(ctx, r.DB.Rebind(query), args...)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code.Name() == "unique_violation" {
+			r.Logger.Error("repo::PatchBookCopyByID - barcode or accession number already exist: ", err)
+			return errors.New(constants.ErrBookCopyAlreadyExist)
+		}
+
+		r.Logger.Error("repo::PatchBookCopyByID - failed to patch book copy: ", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.Error("repo::PatchBookCopyByID - failed to get rows affected: ", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.Logger.Error("repo::PatchBookCopyByID - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	return nil
+}
+
+// LockBookCopy holds the copy row until tx ends, so its status cannot change underneath.
+func (r *BookCopyRepository) LockBookCopy(ctx context.Context, tx *sql.Tx, id string) (*models.BookCopy, error) {
+	res, err := r.scanLockedBookCopy(tx.QueryRowContext(ctx, r.DB.Rebind(queryLockBookCopy), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::LockBookCopy - BookCopy doesnt exist")
+			return nil, errors.New(constants.ErrBookCopyNotFound)
+		}
+
+		r.Logger.Error("repo::LockBookCopy - failed to lock book copy: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// LockAvailableBookCopy locks the copy with barcode, or any available copy of
+// the book at the branch when barcode is empty. The caller checks the status
+// and branch of a copy picked by barcode.
+func (r *BookCopyRepository) LockAvailableBookCopy(ctx context.Context, tx *sql.Tx, bookID, branchID, barcode string) (*models.BookCopy, error) {
+	if barcode != "" {
+		res, err := r.scanLockedBookCopy(tx.QueryRowContext(ctx, r.DB.Rebind(queryLockBookCopyByBarcode), bookID, barcode))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				r.Logger.Error("repo::LockAvailableBookCopy - BookCopy doesnt exist")
+				return nil, errors.New(constants.ErrBookCopyNotFound)
+			}
+
+			r.Logger.Error("repo::LockAvailableBookCopy - failed to lock book copy: ", err)
+			return nil, err
+		}
+
+		return res, nil
+	}
+
+	res, err := r.scanLockedBookCopy(tx.QueryRowContext(ctx, r.DB.Rebind(queryLockAvailableBookCopy), bookID, branchID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::LockAvailableBookCopy - no available copy")
+			return nil, errors.New(constants.ErrInsufficientStock)
+		}
+
+		r.Logger.Error("repo::LockAvailableBookCopy - failed to lock book copy: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// LockAvailableBookCopies locks up to limit available copies of the book at
+// the branch, skipping those already locked by a borrower.
+func (r *BookCopyRepository) LockAvailableBookCopies(ctx context.Context, tx *sql.Tx, bookID, branchID string, limit int) ([]models.BookCopy, error) {
+	rows, err := tx.QueryContext(ctx, r.DB.Rebind(queryLockAvailableBookCopies), bookID, branchID, limit)
+	if err != nil {
+		r.Logger.Error("repo::LockAvailableBookCopies - failed to lock book copies: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]models.BookCopy, 0, limit)
+	for rows.Next() {
+		bookCopy, err := r.scanLockedBookCopy(rows)
+		if err != nil {
+			r.Logger.Error("repo::LockAvailableBookCopies - failed to scan book copy: ", err)
+			return nil, err
+		}
+
+		res = append(res, *bookCopy)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.Logger.Error("repo::LockAvailableBookCopies - failed to iterate book copies: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookCopyRepository) scanLockedBookCopy(row rowScanner) (*models.BookCopy, error) {
+	var (
+		res = new(models.BookCopy)
+	)
+
+	err := row.Scan(
+		&res.ID,
+		&res.BookID,
+		&res.BranchID,
+		&res.Barcode,
+		&res.AccessionNumber,
+		&res.Status,
+		&res.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookCopyRepository) UpdateBookCopyStatus(ctx context.Context, tx *sql.Tx, id, status string) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryUpdateBookCopyStatus), status, status, id)
+	if err != nil {
+		r.Logger.Error("repo::UpdateBookCopyStatus - failed to update book copy status: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateBookCopyBranch moves the copy to the branch it was returned at.
+func (r *BookCopyRepository) UpdateBookCopyBranch(ctx context.Context, tx *sql.Tx, id, branchID string) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryUpdateBookCopyBranch), branchID, id)
+	if err != nil {
+		r.Logger.Error("repo::UpdateBookCopyBranch - failed to update book copy branch: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// CountBookCopiesByBranch counts every copy of a book at a branch, withdrawn
+// ones included, so the branch keeps its derived stock once it has copies.
+// Callers hold the stock row lock, which CreateBookCopy takes as well.
+func (r *BookCopyRepository) CountBookCopiesByBranch(ctx context.Context, tx *sql.Tx, bookID, branchID string) (int, error) {
+	var count int
+
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryCountBookCopiesByBranch), bookID, branchID).Scan(&count)
+	if err != nil {
+		r.Logger.Error("repo::CountBookCopiesByBranch - failed to count book copies: ", err)
+		return 0, err
+	}
+
+	return count, nil
+}
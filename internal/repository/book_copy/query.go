@@ -0,0 +1,145 @@
+package book_copy
+
+const (
+	queryInsertNewBookCopy = `
+		INSERT INTO book_copies
+		(
+			book_id,
+			branch_id,
+			barcode,
+			accession_number,
+			acquisition_date,
+			shelf_location,
+			status
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	queryFindBookCopyByID = `
+		SELECT
+			bc.id,
+			bc.book_id,
+			bc.barcode,
+			bc.accession_number,
+			bc.acquisition_date,
+			bc.shelf_location,
+			bc.status,
+			bc.withdrawn_at,
+			bc.created_at,
+			bc.updated_at,
+			bc.version,
+			b.title AS book_title,
+			bc.branch_id,
+			br.name AS branch_name
+		FROM book_copies bc
+		JOIN books b ON bc.book_id = b.id
+		JOIN branches br ON bc.branch_id = br.id
+		WHERE bc.id = ?
+	`
+
+	queryFindAllBookCopy = `
+		SELECT
+			bc.id,
+			bc.book_id,
+			bc.barcode,
+			bc.accession_number,
+			bc.acquisition_date,
+			bc.shelf_location,
+			bc.status,
+			bc.withdrawn_at,
+			bc.created_at,
+			bc.updated_at,
+			bc.version,
+			b.title AS book_title,
+			bc.branch_id,
+			br.name AS branch_name
+		FROM book_copies bc
+		JOIN books b ON bc.book_id = b.id
+		JOIN branches br ON bc.branch_id = br.id
+		WHERE b.deleted_at IS NULL
+	`
+
+	queryLockBookCopy = `
+		SELECT
+			id,
+			book_id,
+			branch_id,
+			barcode,
+			accession_number,
+			status,
+			version
+		FROM book_copies
+		WHERE id = ?
+		FOR UPDATE
+	`
+
+	queryLockBookCopyByBarcode = `
+		SELECT
+			id,
+			book_id,
+			branch_id,
+			barcode,
+			accession_number,
+			status,
+			version
+		FROM book_copies
+		WHERE book_id = ? AND barcode = ?
+		FOR UPDATE
+	`
+
+	// concurrent borrowers skip the copies already locked by each other
+	queryLockAvailableBookCopy = `
+		SELECT
+			id,
+			book_id,
+			branch_id,
+			barcode,
+			accession_number,
+			status,
+			version
+		FROM book_copies
+		WHERE book_id = ? AND branch_id = ? AND status = 'available'
+		ORDER BY barcode
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	queryLockAvailableBookCopies = `
+		SELECT
+			id,
+			book_id,
+			branch_id,
+			barcode,
+			accession_number,
+			status,
+			version
+		FROM book_copies
+		WHERE book_id = ? AND branch_id = ? AND status = 'available'
+		ORDER BY barcode
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+
+	queryUpdateBookCopyStatus = `
+		UPDATE book_copies
+		SET
+			status = ?,
+			withdrawn_at = CASE WHEN status <> 'withdrawn' AND ? = 'withdrawn' THEN NOW() ELSE withdrawn_at END,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = ?
+	`
+
+	queryUpdateBookCopyBranch = `
+		UPDATE book_copies
+		SET
+			branch_id = ?,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = ?
+	`
+
+	queryCountBookCopiesByBranch = `
+		SELECT COUNT(id) FROM book_copies WHERE book_id = ? AND branch_id = ?
+	`
+)
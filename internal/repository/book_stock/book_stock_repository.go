@@ -11,28 +11,40 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/hilmiikhsan/library-book-service/constants"
 	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	bookStockKeyset     = pagination.Keyset{UpdatedAt: "bs.updated_at", ID: "bs.id"}
+	stockMovementKeyset = pagination.Keyset{UpdatedAt: "sm.created_at", ID: "sm.id"}
+)
+
 type BookStockRepository struct {
 	DB     *sqlx.DB
 	Logger *logrus.Logger
 	Redis  *redis.Client
 }
 
-func (r *BookStockRepository) InsertNewBookStock(ctx context.Context, bookStock *models.BookStock) error {
-	_, err := r.DB.ExecContext(ctx, r.DB.Rebind(queryInsertNewBookStock),
+func (r *BookStockRepository) InsertNewBookStock(ctx context.Context, tx *sql.Tx, bookStock *models.BookStock, movement *models.StockMovement) error {
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryInsertNewBookStock),
 		bookStock.BookID,
+		bookStock.BranchID,
 		bookStock.TotalStock,
 		bookStock.AvailableStock,
-	)
+	).Scan(&bookStock.ID)
 	if err != nil {
 		r.Logger.Error("repo::InsertNewBookStock - Failed to insert new book stock : ", err)
 		return err
 	}
 
-	return nil
+	movement.BookStockID = bookStock.ID
+	movement.BookID = bookStock.BookID
+	movement.TotalDelta = bookStock.TotalStock
+	movement.AvailableDelta = bookStock.AvailableStock
+
+	return r.insertStockMovement(ctx, tx, movement)
 }
 
 func (r *BookStockRepository) FindBookStockByID(ctx context.Context, id string) (*models.BookStock, error) {
@@ -75,10 +87,10 @@ func (r *BookStockRepository) FindBookStockByID(ctx context.Context, id string)
 	return res, nil
 }
 
-func (r *BookStockRepository) FindAllBookStock(ctx context.Context, limit, offset int) ([]models.BookStock, error) {
+func (r *BookStockRepository) FindAllBookStock(ctx context.Context, page *models.PageRequest) ([]models.BookStock, error) {
 	var (
 		res      = make([]models.BookStock, 0)
-		cacheKey = fmt.Sprintf("book_stock:limit:%d:offset:%d", limit, offset)
+		cacheKey = fmt.Sprintf("book_stock:limit:%d:offset:%d:cursor:%s", page.Limit, page.Offset, pagination.EncodeCursor(page.Cursor))
 	)
 
 	cachedData, err := r.Redis.Get(ctx, cacheKey).Result()
@@ -91,12 +103,16 @@ func (r *BookStockRepository) FindAllBookStock(ctx context.Context, limit, offse
 		r.Logger.Warn("category::FindAllBookStock - Failed to unmarshal cache data: ", err)
 	}
 
-	err = r.DB.SelectContext(ctx, &res, r.DB.Rebind(queryFindAllBookStock), limit, offset)
+	query, args := bookStockKeyset.Paginate(queryFindAllBookStock, " WHERE ", nil, page)
+
+	err = r.DB.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
 	if err != nil {
 		r.Logger.Error("repo::FindAllBookStock - failed to find all book stock: ", err)
 		return nil, err
 	}
 
+	res = pagination.ReversePage(res, page)
+
 	dataToCache, err := json.Marshal(res)
 	if err != nil {
 		r.Logger.Warn("category::FindAllBookStock - Failed to marshal data for caching: ", err)
@@ -110,35 +126,146 @@ func (r *BookStockRepository) FindAllBookStock(ctx context.Context, limit, offse
 	return res, nil
 }
 
-func (r *BookStockRepository) UpdateNewBookStock(ctx context.Context, bookStock *models.BookStock) error {
-	_, err := r.DB.ExecContext(ctx, r.DB.Rebind(queryUpdateBookStock),
-		bookStock.BookID,
+func (r *BookStockRepository) CountAllBookStock(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.DB.GetContext(ctx, &count, pagination.CountQuery(queryFindAllBookStock))
+	if err != nil {
+		r.Logger.Error("repo::CountAllBookStock - failed to count book stock: ", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *BookStockRepository) UpdateNewBookStock(ctx context.Context, tx *sql.Tx, bookStock *models.BookStock, movement *models.StockMovement) error {
+	current, err := r.LockBookStockByID(ctx, tx, bookStock.ID.String())
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, r.DB.Rebind(queryUpdateBookStock),
 		bookStock.TotalStock,
 		bookStock.AvailableStock,
 		bookStock.ID,
+		bookStock.Version,
 	)
 	if err != nil {
 		r.Logger.Error("repo::UpdateNewBookStock - failed to update book stock: ", err)
 		return err
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.Error("repo::UpdateNewBookStock - failed to get rows affected: ", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.Logger.Error("repo::UpdateNewBookStock - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	movement.BookStockID = bookStock.ID
+	movement.BookID = bookStock.BookID
+	movement.TotalDelta = bookStock.TotalStock - current.TotalStock
+	movement.AvailableDelta = bookStock.AvailableStock - current.AvailableStock
+
+	if err := r.insertStockMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+
+	r.deleteCache(ctx, bookStock.ID.String())
+
 	return nil
 }
 
-func (r *BookStockRepository) DeleteBookStockByID(ctx context.Context, id string) error {
-	_, err := r.DB.ExecContext(ctx, r.DB.Rebind(queryDeleteBookStockByID), id)
+// PatchBookStockByID writes only the columns set on the patch, guarded by its version.
+func (r *BookStockRepository) PatchBookStockByID(ctx context.Context, tx *sql.Tx, patch *models.BookStockPatch, movement *models.StockMovement) error {
+	var (
+		query = "UPDATE book_stocks SET updated_at = NOW(), version = version + 1"
+		args  = []interface{}{}
+	)
+
+	current, err := r.LockBookStockByID(ctx, tx, patch.ID.String())
+	if err != nil {
+		return err
+	}
+
+	movement.BookStockID = current.ID
+	movement.BookID = current.BookID
+
+	if patch.TotalStock != nil {
+		query += ", total_stock = ?"
+		args = append(args, *patch.TotalStock)
+		movement.TotalDelta = *patch.TotalStock - current.TotalStock
+	}
+	if patch.AvailableStock != nil {
+		query += ", available_stock = ?"
+		args = append(args, *patch.AvailableStock)
+		movement.AvailableDelta = *patch.AvailableStock - current.AvailableStock
+	}
+
+	query += " WHERE id = ? AND version = ?"
+	args = append(args, patch.ID, patch.Version)
+
+	result, err := tx.ExecContext(ctx, r.DB.Rebind(query), args...)
+	if err != nil {
+		r.Logger.Error("repo::PatchBookStockByID - failed to patch book stock: ", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.Error("repo::PatchBookStockByID - failed to get rows affected: ", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.Logger.Error("repo::PatchBookStockByID - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	if err := r.insertStockMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+
+	r.deleteCache(ctx, patch.ID.String())
+
+	return nil
+}
+
+// DeleteBookStockByID writes the remaining stock off before the row goes.
+func (r *BookStockRepository) DeleteBookStockByID(ctx context.Context, tx *sql.Tx, id string, movement *models.StockMovement) error {
+	current, err := r.LockBookStockByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, r.DB.Rebind(queryDeleteBookStockByID), id)
 	if err != nil {
 		r.Logger.Error("repo::DeleteBookStockByID - failed to delete BookStock by id: ", err)
 		return err
 	}
 
+	movement.BookStockID = current.ID
+	movement.BookID = current.BookID
+	movement.TotalDelta = -current.TotalStock
+	movement.AvailableDelta = -current.AvailableStock
+
+	if err := r.insertStockMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+
+	r.deleteCache(ctx, id)
+
 	return nil
 }
 
-func (r *BookStockRepository) ValidateBookStockByBookID(ctx context.Context, bookID string) (int, error) {
+func (r *BookStockRepository) ValidateBookStockByBookID(ctx context.Context, bookID, branchID string) (int, error) {
 	var count int
 
-	err := r.DB.GetContext(ctx, &count, r.DB.Rebind(queryCountBookByBookID), bookID)
+	err := r.DB.GetContext(ctx, &count, r.DB.Rebind(queryCountBookByBookID), bookID, branchID)
 	if err != nil {
 		r.Logger.Error("repo::ValidateBookStockByBookID - failed to count book by book id: ", err)
 		return 0, err
@@ -147,29 +274,31 @@ func (r *BookStockRepository) ValidateBookStockByBookID(ctx context.Context, boo
 	return count, nil
 }
 
-func (r *BookStockRepository) DecrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID string, stock int) error {
-	result, err := tx.ExecContext(ctx, r.DB.Rebind(queryDecrementAvailableStock), stock, bookID, stock)
+func (r *BookStockRepository) DecrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID, branchID string, stock int, movement *models.StockMovement) error {
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryDecrementAvailableStock), stock, bookID, branchID, stock).Scan(&movement.BookStockID, &movement.BookID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::UpdateAvailableStock - insufficient stock")
+			return errors.New(constants.ErrInsufficientStock)
+		}
+
 		r.Logger.Error("repo::UpdateAvailableStock - failed to update available stock: ", err)
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		r.Logger.Error("repo::UpdateAvailableStock - failed to get rows affected: ", err)
+	movement.AvailableDelta = -stock
+
+	if err := r.insertStockMovement(ctx, tx, movement); err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		r.Logger.Error("repo::UpdateAvailableStock - insufficient stock")
-		return errors.New(constants.ErrInsufficientStock)
-	}
+	r.deleteCache(ctx, movement.BookStockID.String())
 
 	return nil
 }
 
-func (r *BookStockRepository) LockBookStock(ctx context.Context, tx *sql.Tx, bookID string) error {
-	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryLockBookStock), bookID)
+func (r *BookStockRepository) LockBookStock(ctx context.Context, tx *sql.Tx, bookID, branchID string) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryLockBookStock), bookID, branchID)
 	if err != nil {
 		r.Logger.Error("repo::LockBookStock - failed to lock book stock: ", err)
 		return err
@@ -178,18 +307,104 @@ func (r *BookStockRepository) LockBookStock(ctx context.Context, tx *sql.Tx, boo
 	return nil
 }
 
-func (r *BookStockRepository) IncrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID string, stock int) error {
-	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryIncrementAvailableStock), stock, bookID)
+func (r *BookStockRepository) IncrementAvailableStock(ctx context.Context, tx *sql.Tx, bookID, branchID string, stock int, movement *models.StockMovement) error {
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryIncrementAvailableStock), stock, bookID, branchID, stock).Scan(&movement.BookStockID, &movement.BookID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			exists, err := r.bookStockExists(ctx, tx, bookID, branchID)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				r.Logger.Error("repo::IncrementAvailableStock - BookStock doesnt exist")
+				return errors.New(constants.ErrBookStockNotFound)
+			}
+
+			r.Logger.Error("repo::IncrementAvailableStock - available stock would exceed total stock")
+			return errors.New(constants.ErrStockExceedsTotal)
+		}
+
 		r.Logger.Error("repo::IncrementAvailableStock - failed to increment available stock: ", err)
 		return err
 	}
 
+	movement.AvailableDelta = stock
+
+	if err := r.insertStockMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+
+	r.deleteCache(ctx, movement.BookStockID.String())
+
+	return nil
+}
+
+// MoveStockToBranch moves stock already taken off the available count of
+// fromBranchID, by a loan returned elsewhere or a shipped transfer: it leaves
+// the total of fromBranchID and arrives available at toBranchID. Each side is
+// recorded as its own copy of movement.
+func (r *BookStockRepository) MoveStockToBranch(ctx context.Context, tx *sql.Tx, bookID, fromBranchID, toBranchID string, stock int, movement *models.StockMovement) error {
+	released := *movement
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryReleaseHeldStock), stock, bookID, fromBranchID, stock).Scan(&released.BookStockID, &released.BookID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			exists, err := r.bookStockExists(ctx, tx, bookID, fromBranchID)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				r.Logger.Error("repo::MoveStockToBranch - BookStock doesnt exist")
+				return errors.New(constants.ErrBookStockNotFound)
+			}
+
+			r.Logger.Error("repo::MoveStockToBranch - held stock mismatch")
+			return errors.New(constants.ErrHeldStockMismatch)
+		}
+
+		r.Logger.Error("repo::MoveStockToBranch - failed to release held stock: ", err)
+		return err
+	}
+
+	released.TotalDelta = -stock
+
+	arrived := *movement
+	err = tx.QueryRowContext(ctx, r.DB.Rebind(queryAddBranchStock), bookID, toBranchID, stock, stock).Scan(&arrived.BookStockID, &arrived.BookID)
+	if err != nil {
+		r.Logger.Error("repo::MoveStockToBranch - failed to add branch stock: ", err)
+		return err
+	}
+
+	arrived.TotalDelta = stock
+	arrived.AvailableDelta = stock
+
+	for _, m := range []*models.StockMovement{&released, &arrived} {
+		if err := r.insertStockMovement(ctx, tx, m); err != nil {
+			return err
+		}
+
+		r.deleteCache(ctx, m.BookStockID.String())
+	}
+
 	return nil
 }
 
-func (r *BookStockRepository) LockBookStockReturned(ctx context.Context, tx *sql.Tx, bookID string) error {
-	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryLockBookStockReturned), bookID)
+// LockBookStockTransfer locks the stock of both branches of a transfer.
+func (r *BookStockRepository) LockBookStockTransfer(ctx context.Context, tx *sql.Tx, bookID, sourceBranchID, destinationBranchID string) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryLockBookStockBranches), bookID, sourceBranchID, destinationBranchID)
+	if err != nil {
+		r.Logger.Error("repo::LockBookStockTransfer - failed to lock book stock transfer: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// LockBookStockReturned locks the stock of the branch a loan was made at and
+// of the branch it is returned at, which may be the same.
+func (r *BookStockRepository) LockBookStockReturned(ctx context.Context, tx *sql.Tx, bookID, branchID, returnBranchID string) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryLockBookStockBranches), bookID, branchID, returnBranchID)
 	if err != nil {
 		r.Logger.Error("repo::LockBookStockReturned - failed to lock book stock returned: ", err)
 		return err
@@ -197,3 +412,284 @@ func (r *BookStockRepository) LockBookStockReturned(ctx context.Context, tx *sql
 
 	return nil
 }
+
+// SyncBookStockWithCopies derives the stock counts of a book at a branch from
+// the statuses of its copies there, creating the stock row for the first copy.
+// The change is recorded as movement.
+func (r *BookStockRepository) SyncBookStockWithCopies(ctx context.Context, tx *sql.Tx, bookID, branchID string, movement *models.StockMovement) error {
+	rows, err := tx.QueryContext(ctx, r.DB.Rebind(querySyncBookStockWithCopies), bookID, branchID, bookID, branchID)
+	if err != nil {
+		r.Logger.Error("repo::SyncBookStockWithCopies - failed to sync book stock: ", err)
+		return err
+	}
+
+	movements, err := scanStockMovements(rows, *movement)
+	if err != nil {
+		r.Logger.Error("repo::SyncBookStockWithCopies - failed to sync book stock: ", err)
+		return err
+	}
+
+	if len(movements) == 0 {
+		created := *movement
+
+		err = tx.QueryRowContext(ctx, r.DB.Rebind(queryInsertBookStockFromCopies), bookID, branchID, bookID, branchID).Scan(
+			&created.BookStockID,
+			&created.BookID,
+			&created.TotalDelta,
+			&created.AvailableDelta,
+		)
+		if err != nil {
+			r.Logger.Error("repo::SyncBookStockWithCopies - failed to insert book stock: ", err)
+			return err
+		}
+
+		movements = append(movements, created)
+	}
+
+	for i := range movements {
+		if err := r.insertStockMovement(ctx, tx, &movements[i]); err != nil {
+			return err
+		}
+
+		r.deleteCache(ctx, movements[i].BookStockID.String())
+	}
+
+	return nil
+}
+
+// AdjustBookStock moves total_stock and available_stock together by delta,
+// neither may drop below zero.
+func (r *BookStockRepository) AdjustBookStock(ctx context.Context, tx *sql.Tx, id string, delta int, movement *models.StockMovement) error {
+	current, err := r.LockBookStockByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if current.TotalStock+delta < 0 || current.AvailableStock+delta < 0 {
+		r.Logger.Error("repo::AdjustBookStock - insufficient stock")
+		return errors.New(constants.ErrInsufficientStock)
+	}
+
+	_, err = tx.ExecContext(ctx, r.DB.Rebind(queryAdjustBookStock), delta, delta, id)
+	if err != nil {
+		r.Logger.Error("repo::AdjustBookStock - failed to adjust book stock: ", err)
+		return err
+	}
+
+	movement.BookStockID = current.ID
+	movement.BookID = current.BookID
+	movement.TotalDelta = delta
+	movement.AvailableDelta = delta
+
+	if err := r.insertStockMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+
+	r.deleteCache(ctx, id)
+
+	return nil
+}
+
+func (r *BookStockRepository) FindStockMovementsByBookStockID(ctx context.Context, bookStockID string, page *models.PageRequest) ([]models.StockMovement, error) {
+	var (
+		res = make([]models.StockMovement, 0)
+	)
+
+	query, args := stockMovementKeyset.Paginate(queryFindStockMovementsByBookStockID, " AND ", []interface{}{bookStockID}, page)
+
+	err := r.DB.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
+	if err != nil {
+		r.Logger.Error("repo::FindStockMovementsByBookStockID - failed to find stock movements: ", err)
+		return nil, err
+	}
+
+	return pagination.ReversePage(res, page), nil
+}
+
+func (r *BookStockRepository) CountStockMovementsByBookStockID(ctx context.Context, bookStockID string) (int, error) {
+	var count int
+
+	err := r.DB.GetContext(ctx, &count, r.DB.Rebind(pagination.CountQuery(queryFindStockMovementsByBookStockID)), bookStockID)
+	if err != nil {
+		r.Logger.Error("repo::CountStockMovementsByBookStockID - failed to count stock movements: ", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *BookStockRepository) FindBookStockDrift(ctx context.Context) ([]models.BookStockDrift, error) {
+	var (
+		res = make([]models.BookStockDrift, 0)
+	)
+
+	err := r.DB.SelectContext(ctx, &res, queryFindBookStockDrift)
+	if err != nil {
+		r.Logger.Error("repo::FindBookStockDrift - failed to find book stock drift: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ReconcileBookStock sets every drifted stock row to its expected counts and
+// records each change as movement. All stock rows stay locked until commit,
+// loans lock their row first so none can slip in between the check and the fix.
+func (r *BookStockRepository) ReconcileBookStock(ctx context.Context, movement *models.StockMovement) ([]models.BookStockDrift, error) {
+	var (
+		res = make([]models.BookStockDrift, 0)
+	)
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		r.Logger.Error("repo::ReconcileBookStock - failed to begin transaction: ", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, queryLockAllBookStocks)
+	if err != nil {
+		r.Logger.Error("repo::ReconcileBookStock - failed to lock book stocks: ", err)
+		return nil, err
+	}
+
+	err = tx.SelectContext(ctx, &res, queryFindBookStockDrift)
+	if err != nil {
+		r.Logger.Error("repo::ReconcileBookStock - failed to find book stock drift: ", err)
+		return nil, err
+	}
+
+	for _, drift := range res {
+		_, err = tx.ExecContext(ctx, r.DB.Rebind(queryReconcileBookStock), drift.ExpectedTotalStock, drift.ExpectedAvailableStock, drift.ID)
+		if err != nil {
+			r.Logger.Error("repo::ReconcileBookStock - failed to reconcile book stock: ", err)
+			return nil, err
+		}
+
+		correction := *movement
+		correction.BookStockID = drift.ID
+		correction.BookID = drift.BookID
+		correction.TotalDelta = drift.ExpectedTotalStock - drift.TotalStock
+		correction.AvailableDelta = drift.ExpectedAvailableStock - drift.AvailableStock
+
+		if err := r.insertStockMovement(ctx, tx.Tx, &correction); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Logger.Error("repo::ReconcileBookStock - failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	for _, drift := range res {
+		r.deleteCache(ctx, drift.ID.String())
+	}
+
+	return res, nil
+}
+
+// LockBookStockByID holds the stock row until tx ends and returns its current counts.
+func (r *BookStockRepository) LockBookStockByID(ctx context.Context, tx *sql.Tx, id string) (*models.BookStock, error) {
+	var (
+		res = new(models.BookStock)
+	)
+
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryLockBookStockByID), id).Scan(
+		&res.ID,
+		&res.BookID,
+		&res.BranchID,
+		&res.TotalStock,
+		&res.AvailableStock,
+		&res.Version,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::LockBookStockByID - BookStock doesnt exist")
+			return nil, errors.New(constants.ErrBookStockNotFound)
+		}
+
+		r.Logger.Error("repo::LockBookStockByID - failed to lock book stock: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CountHeldStock counts the stock of a book at a branch that is off the
+// shelf but still part of its total_stock.
+func (r *BookStockRepository) CountHeldStock(ctx context.Context, tx *sql.Tx, bookID, branchID string) (int, error) {
+	var count int
+
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryCountHeldStock), bookID, branchID, bookID, branchID).Scan(&count)
+	if err != nil {
+		r.Logger.Error("repo::CountHeldStock - failed to count held stock: ", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// bookStockExists tells a guarded stock update that matched no row because
+// the row is missing apart from one whose counts failed the guard.
+func (r *BookStockRepository) bookStockExists(ctx context.Context, tx *sql.Tx, bookID, branchID string) (bool, error) {
+	var count int
+
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryCountBookStockByBranch), bookID, branchID).Scan(&count)
+	if err != nil {
+		r.Logger.Error("repo::bookStockExists - failed to count book stock: ", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// insertStockMovement appends movement to the ledger, a change that moved
+// nothing is not recorded.
+func (r *BookStockRepository) insertStockMovement(ctx context.Context, tx *sql.Tx, movement *models.StockMovement) error {
+	if movement.TotalDelta == 0 && movement.AvailableDelta == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryInsertStockMovement),
+		movement.BookStockID,
+		movement.BookID,
+		movement.Type,
+		movement.TotalDelta,
+		movement.AvailableDelta,
+		movement.Reason,
+		movement.ActorID,
+	)
+	if err != nil {
+		r.Logger.Error("repo::insertStockMovement - failed to insert stock movement: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// scanStockMovements reads the stock rows and deltas an UPDATE ... RETURNING
+// touched into copies of movement. The rows are closed before the movements
+// are written, the connection cannot run another statement while they are open.
+func scanStockMovements(rows *sql.Rows, movement models.StockMovement) ([]models.StockMovement, error) {
+	defer rows.Close()
+
+	res := make([]models.StockMovement, 0)
+	for rows.Next() {
+		item := movement
+		if err := rows.Scan(&item.BookStockID, &item.BookID, &item.TotalDelta, &item.AvailableDelta); err != nil {
+			return nil, err
+		}
+
+		res = append(res, item)
+	}
+
+	return res, rows.Err()
+}
+
+// deleteCache drops the cached FindBookStockByID entry so the next read sees the new version.
+func (r *BookStockRepository) deleteCache(ctx context.Context, id string) {
+	if err := r.Redis.Del(ctx, fmt.Sprintf("book_stock:%s", id)).Err(); err != nil {
+		r.Logger.Warn("repo::deleteCache - failed to delete cache: ", err)
+	}
+}
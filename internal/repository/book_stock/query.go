@@ -5,9 +5,11 @@ const (
 		INSERT INTO book_stocks
 		(
 			book_id,
+			branch_id,
 			total_stock,
 			available_stock
-		) VALUES (?, ?, ?)
+		) VALUES (?, ?, ?, ?)
+		RETURNING id
 	`
 
 	queryFindBookStockByID = `
@@ -18,10 +20,14 @@ const (
 			bs.available_stock,
 			bs.created_at,
 			bs.updated_at,
+			bs.version,
 			b.id as book_id,
-			b.title as book_title
+			b.title as book_title,
+			bs.branch_id,
+			br.name AS branch_name
 		FROM book_stocks bs
 		JOIN books b ON bs.book_id = b.id
+		JOIN branches br ON bs.branch_id = br.id
 		WHERE bs.id = ?
 	`
 
@@ -31,22 +37,23 @@ const (
 			bs.book_id,
 			bs.total_stock,
 			bs.available_stock,
-			b.title AS book_title
+			bs.updated_at,
+			b.title AS book_title,
+			bs.branch_id,
+			br.name AS branch_name
 		FROM book_stocks bs
 		JOIN books b ON bs.book_id = b.id
-		ORDER BY bs.updated_at DESC
-		LIMIT ?
-		OFFSET ?
+		JOIN branches br ON bs.branch_id = br.id
 	`
 
 	queryUpdateBookStock = `
 		UPDATE book_stocks
 		SET
-			book_id = ?,
 			total_stock = ?,
 			available_stock = ?,
-			updated_at = NOW()
-		WHERE id = ?
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = ? AND version = ?
 	`
 
 	queryDeleteBookStockByID = `
@@ -54,35 +61,252 @@ const (
 	`
 
 	queryCountBookByBookID = `
-		SELECT COUNT(id) FROM book_stocks WHERE book_id = ?
+		SELECT COUNT(bs.id)
+		FROM book_stocks bs
+		INNER JOIN books b ON b.id = bs.book_id
+		WHERE bs.book_id = ? AND bs.branch_id = ? AND b.deleted_at IS NULL
 	`
 
 	queryDecrementAvailableStock = `
 		UPDATE book_stocks
 		SET 
-			available_stock = available_stock - ?
-		WHERE book_id = ? 
+			available_stock = available_stock - ?,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE book_id = ? AND branch_id = ?
 		AND available_stock >= ?
+		RETURNING id, book_id
 	`
 
 	queryLockBookStock = `
 		SELECT 1
 		FROM book_stocks
-		WHERE book_id = ?
+		WHERE book_id = ? AND branch_id = ?
 		FOR UPDATE
 	`
 
 	queryIncrementAvailableStock = `
 		UPDATE book_stocks
 		SET
-			available_stock = available_stock + ?
-		WHERE book_id = ?
+			available_stock = available_stock + ?,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE book_id = ? AND branch_id = ?
+		AND available_stock + ? <= total_stock
+		RETURNING id, book_id
 	`
 
-	queryLockBookStockReturned = `
+	queryCountBookStockByBranch = `
+		SELECT COUNT(id)
+		FROM book_stocks
+		WHERE book_id = ? AND branch_id = ?
+	`
+
+	// every active loan at the branch holds one unit of total_stock off the
+	// shelf, shipped transfers hold their quantity until received
+	queryCountHeldStock = `
+		SELECT
+			(
+				SELECT COUNT(id)
+				FROM borrowed_books
+				WHERE book_id = ? AND branch_id = ? AND returned_date IS NULL
+			) + (
+				SELECT COALESCE(SUM(quantity), 0)
+				FROM book_transfers
+				WHERE book_id = ? AND source_branch_id = ? AND status = 'in_transit'
+			)
+	`
+
+	// both branches are locked in id order, so returns and transfers locking the same pair cannot deadlock
+	queryLockBookStockBranches = `
 		SELECT available_stock
 		FROM book_stocks
-		WHERE book_id = ?
+		WHERE book_id = ? AND branch_id IN (?, ?)
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	// a loan returned at another branch or a received transfer leaves the total of the branch it left
+	queryReleaseHeldStock = `
+		UPDATE book_stocks
+		SET
+			total_stock = total_stock - ?,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE book_id = ? AND branch_id = ?
+		AND total_stock - available_stock >= ?
+		RETURNING id, book_id
+	`
+
+	// stock arriving at a branch creates its row on the first arrival
+	queryAddBranchStock = `
+		INSERT INTO book_stocks (book_id, branch_id, total_stock, available_stock)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (book_id, branch_id) DO UPDATE
+		SET
+			total_stock = book_stocks.total_stock + EXCLUDED.total_stock,
+			available_stock = book_stocks.available_stock + EXCLUDED.available_stock,
+			updated_at = NOW(),
+			version = book_stocks.version + 1
+		RETURNING id, book_id
+	`
+
+	// lost and withdrawn copies leave the stock, copies in repair or in transit stay in total_stock only
+	querySyncBookStockWithCopies = `
+		WITH old AS (
+			SELECT id, total_stock, available_stock
+			FROM book_stocks
+			WHERE book_id = ? AND branch_id = ?
+			FOR UPDATE
+		)
+		UPDATE book_stocks bs
+		SET
+			total_stock = c.total_stock,
+			available_stock = c.available_stock,
+			updated_at = NOW(),
+			version = bs.version + 1
+		FROM old, (
+			SELECT
+				COUNT(*) FILTER (WHERE status NOT IN ('lost', 'withdrawn')) AS total_stock,
+				COUNT(*) FILTER (WHERE status = 'available') AS available_stock
+			FROM book_copies
+			WHERE book_id = ? AND branch_id = ?
+		) c
+		WHERE bs.id = old.id
+		RETURNING bs.id, bs.book_id, c.total_stock - old.total_stock AS total_delta, c.available_stock - old.available_stock AS available_delta
+	`
+
+	queryInsertBookStockFromCopies = `
+		INSERT INTO book_stocks (book_id, branch_id, total_stock, available_stock)
+		SELECT ?, ?, c.total_stock, c.available_stock
+		FROM (
+			SELECT
+				COUNT(*) FILTER (WHERE status NOT IN ('lost', 'withdrawn')) AS total_stock,
+				COUNT(*) FILTER (WHERE status = 'available') AS available_stock
+			FROM book_copies
+			WHERE book_id = ? AND branch_id = ?
+		) c
+		RETURNING id, book_id, total_stock AS total_delta, available_stock AS available_delta
+	`
+
+	queryLockBookStockByID = `
+		SELECT id, book_id, branch_id, total_stock, available_stock, version
+		FROM book_stocks
+		WHERE id = ?
 		FOR UPDATE
 	`
+
+	queryAdjustBookStock = `
+		UPDATE book_stocks
+		SET
+			total_stock = total_stock + ?,
+			available_stock = available_stock + ?,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = ?
+	`
+
+	queryInsertStockMovement = `
+		INSERT INTO stock_movements
+		(
+			book_stock_id,
+			book_id,
+			type,
+			total_delta,
+			available_delta,
+			reason,
+			actor_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	queryFindStockMovementsByBookStockID = `
+		SELECT
+			sm.id,
+			sm.book_stock_id,
+			sm.book_id,
+			sm.type,
+			sm.total_delta,
+			sm.available_delta,
+			sm.reason,
+			sm.actor_id,
+			sm.created_at
+		FROM stock_movements sm
+		WHERE sm.book_stock_id = ?
+	`
+
+	// the expected counts follow the copy statuses at the branch when the book
+	// has copies there, otherwise every active loan at the branch holds one unit of total_stock
+	queryFindBookStockDrift = `
+		SELECT
+			d.id,
+			d.book_id,
+			d.book_title,
+			d.branch_id,
+			d.branch_name,
+			d.managed_by_copies,
+			d.active_loans,
+			d.total_stock,
+			d.expected_total_stock,
+			d.available_stock,
+			d.expected_available_stock
+		FROM (
+			SELECT
+				bs.id,
+				bs.book_id,
+				b.title AS book_title,
+				bs.branch_id,
+				br.name AS branch_name,
+				c.book_id IS NOT NULL AS managed_by_copies,
+				COALESCE(l.active_loans, 0) AS active_loans,
+				bs.total_stock,
+				CASE WHEN c.book_id IS NOT NULL THEN c.total_stock ELSE bs.total_stock END AS expected_total_stock,
+				bs.available_stock,
+				CASE
+					WHEN c.book_id IS NOT NULL THEN c.available_stock
+					ELSE GREATEST(bs.total_stock - COALESCE(l.active_loans, 0) - COALESCE(t.in_transit, 0), 0)
+				END AS expected_available_stock
+			FROM book_stocks bs
+			JOIN books b ON b.id = bs.book_id
+			JOIN branches br ON br.id = bs.branch_id
+			LEFT JOIN (
+				SELECT book_id, branch_id, COUNT(*) AS active_loans
+				FROM borrowed_books
+				WHERE returned_date IS NULL
+				GROUP BY book_id, branch_id
+			) l ON l.book_id = bs.book_id AND l.branch_id = bs.branch_id
+			-- shipped transfers stay in the total of their source until received
+			LEFT JOIN (
+				SELECT book_id, source_branch_id, SUM(quantity) AS in_transit
+				FROM book_transfers
+				WHERE status = 'in_transit'
+				GROUP BY book_id, source_branch_id
+			) t ON t.book_id = bs.book_id AND t.source_branch_id = bs.branch_id
+			-- a branch with copies of the book derives its stock from them
+			LEFT JOIN (
+				SELECT
+					book_id,
+					branch_id,
+					COUNT(*) FILTER (WHERE status NOT IN ('lost', 'withdrawn')) AS total_stock,
+					COUNT(*) FILTER (WHERE status = 'available') AS available_stock
+				FROM book_copies
+				GROUP BY book_id, branch_id
+			) c ON c.book_id = bs.book_id AND c.branch_id = bs.branch_id
+		) d
+		WHERE d.total_stock <> d.expected_total_stock OR d.available_stock <> d.expected_available_stock
+		ORDER BY d.book_title, d.branch_name, d.id
+	`
+
+	queryLockAllBookStocks = `
+		SELECT id FROM book_stocks ORDER BY id FOR UPDATE
+	`
+
+	queryReconcileBookStock = `
+		UPDATE book_stocks
+		SET
+			total_stock = ?,
+			available_stock = ?,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = ?
+	`
 )
@@ -0,0 +1,200 @@
+package book_transfer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var bookTransferKeyset = pagination.Keyset{UpdatedAt: "bt.updated_at", ID: "bt.id"}
+
+type BookTransferRepository struct {
+	DB     *sqlx.DB
+	Logger *logrus.Logger
+}
+
+func (r *BookTransferRepository) InsertNewBookTransfer(ctx context.Context, tx *sql.Tx, bookTransfer *models.BookTransfer) error {
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryInsertNewBookTransfer),
+		bookTransfer.BookID,
+		bookTransfer.SourceBranchID,
+		bookTransfer.DestinationBranchID,
+		bookTransfer.Quantity,
+		bookTransfer.Status,
+		bookTransfer.RequestedBy,
+	).Scan(&bookTransfer.ID)
+	if err != nil {
+		r.Logger.Error("repo::InsertNewBookTransfer - Failed to insert new book transfer : ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *BookTransferRepository) FindBookTransferByID(ctx context.Context, id string) (*models.BookTransfer, error) {
+	var (
+		res = new(models.BookTransfer)
+	)
+
+	err := r.DB.GetContext(ctx, res, r.DB.Rebind(queryFindBookTransferByID), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::FindBookTransferByID - BookTransfer doesnt exist")
+			return res, errors.New(constants.ErrBookTransferNotFound)
+		}
+
+		r.Logger.Error("repo::FindBookTransferByID - failed to find book transfer by id: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookTransferRepository) FindAllBookTransfer(ctx context.Context, filter *models.BookTransferFilter, page *models.PageRequest) ([]models.BookTransfer, error) {
+	var (
+		res         = make([]models.BookTransfer, 0)
+		where, args = buildBookTransferFilter(filter)
+	)
+
+	query, args := bookTransferKeyset.Paginate(queryFindAllBookTransfer+where, " AND ", args, page)
+
+	err := r.DB.SelectContext(ctx, &res, r.DB.Rebind(query), args...)
+	if err != nil {
+		r.Logger.Error("repo::FindAllBookTransfer - failed to find all book transfer: ", err)
+		return nil, err
+	}
+
+	return pagination.ReversePage(res, page), nil
+}
+
+func (r *BookTransferRepository) CountAllBookTransfer(ctx context.Context, filter *models.BookTransferFilter) (int, error) {
+	var (
+		count       int
+		where, args = buildBookTransferFilter(filter)
+	)
+
+	err := r.DB.GetContext(ctx, &count, r.DB.Rebind(pagination.CountQuery(queryFindAllBookTransfer+where)), args...)
+	if err != nil {
+		r.Logger.Error("repo::CountAllBookTransfer - failed to count book transfer: ", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// buildBookTransferFilter always filters on status, so the clause starts with WHERE.
+func buildBookTransferFilter(filter *models.BookTransferFilter) (string, []interface{}) {
+	var (
+		where string
+		args  = []interface{}{}
+	)
+
+	if filter.Status != "" {
+		where = " WHERE bt.status = ?"
+		args = append(args, filter.Status)
+	} else {
+		where = " WHERE bt.status <> ?"
+		args = append(args, constants.TransferStatusReceived)
+	}
+	if filter.BranchID != "" {
+		where += " AND (bt.source_branch_id = ? OR bt.destination_branch_id = ?)"
+		args = append(args, filter.BranchID, filter.BranchID)
+	}
+
+	return where, args
+}
+
+// LockBookTransfer holds the transfer row until tx ends, so it cannot be
+// shipped or received twice.
+func (r *BookTransferRepository) LockBookTransfer(ctx context.Context, tx *sql.Tx, id string) (*models.BookTransfer, error) {
+	var (
+		res = new(models.BookTransfer)
+	)
+
+	err := tx.QueryRowContext(ctx, r.DB.Rebind(queryLockBookTransfer), id).Scan(
+		&res.ID,
+		&res.BookID,
+		&res.SourceBranchID,
+		&res.DestinationBranchID,
+		&res.Quantity,
+		&res.Status,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::LockBookTransfer - BookTransfer doesnt exist")
+			return nil, errors.New(constants.ErrBookTransferNotFound)
+		}
+
+		r.Logger.Error("repo::LockBookTransfer - failed to lock book transfer: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *BookTransferRepository) UpdateBookTransferShipped(ctx context.Context, tx *sql.Tx, id string, actorID *uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryUpdateBookTransferShipped), actorID, id)
+	if err != nil {
+		r.Logger.Error("repo::UpdateBookTransferShipped - failed to update book transfer: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *BookTransferRepository) UpdateBookTransferReceived(ctx context.Context, tx *sql.Tx, id string, actorID *uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, r.DB.Rebind(queryUpdateBookTransferReceived), actorID, id)
+	if err != nil {
+		r.Logger.Error("repo::UpdateBookTransferReceived - failed to update book transfer: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// InsertBookTransferCopies records the copies a shipment took, receiving the
+// transfer moves exactly those.
+func (r *BookTransferRepository) InsertBookTransferCopies(ctx context.Context, tx *sql.Tx, id string, copyIDs []uuid.UUID) error {
+	for _, copyID := range copyIDs {
+		_, err := tx.ExecContext(ctx, r.DB.Rebind(queryInsertBookTransferCopy), id, copyID)
+		if err != nil {
+			r.Logger.Error("repo::InsertBookTransferCopies - failed to insert book transfer copy: ", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *BookTransferRepository) FindBookTransferCopyIDs(ctx context.Context, tx *sql.Tx, id string) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, r.DB.Rebind(queryFindBookTransferCopyIDs), id)
+	if err != nil {
+		r.Logger.Error("repo::FindBookTransferCopyIDs - failed to find book transfer copies: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var copyID uuid.UUID
+		if err := rows.Scan(&copyID); err != nil {
+			r.Logger.Error("repo::FindBookTransferCopyIDs - failed to scan book transfer copy: ", err)
+			return nil, err
+		}
+
+		res = append(res, copyID)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.Logger.Error("repo::FindBookTransferCopyIDs - failed to iterate book transfer copies: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,106 @@
+package book_transfer
+
+const (
+	queryInsertNewBookTransfer = `
+		INSERT INTO book_transfers
+		(
+			book_id,
+			source_branch_id,
+			destination_branch_id,
+			quantity,
+			status,
+			requested_by
+		) VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	queryFindBookTransferByID = `
+		SELECT
+			bt.id,
+			bt.book_id,
+			b.title AS book_title,
+			bt.source_branch_id,
+			sb.name AS source_branch_name,
+			bt.destination_branch_id,
+			db.name AS destination_branch_name,
+			bt.quantity,
+			bt.status,
+			bt.requested_by,
+			bt.shipped_by,
+			bt.received_by,
+			bt.shipped_at,
+			bt.received_at,
+			bt.created_at,
+			bt.updated_at
+		FROM book_transfers bt
+		JOIN books b ON bt.book_id = b.id
+		JOIN branches sb ON bt.source_branch_id = sb.id
+		JOIN branches db ON bt.destination_branch_id = db.id
+		WHERE bt.id = ?
+	`
+
+	queryFindAllBookTransfer = `
+		SELECT
+			bt.id,
+			bt.book_id,
+			b.title AS book_title,
+			bt.source_branch_id,
+			sb.name AS source_branch_name,
+			bt.destination_branch_id,
+			db.name AS destination_branch_name,
+			bt.quantity,
+			bt.status,
+			bt.requested_by,
+			bt.shipped_by,
+			bt.received_by,
+			bt.shipped_at,
+			bt.received_at,
+			bt.created_at,
+			bt.updated_at
+		FROM book_transfers bt
+		JOIN books b ON bt.book_id = b.id
+		JOIN branches sb ON bt.source_branch_id = sb.id
+		JOIN branches db ON bt.destination_branch_id = db.id
+	`
+
+	queryLockBookTransfer = `
+		SELECT
+			id,
+			book_id,
+			source_branch_id,
+			destination_branch_id,
+			quantity,
+			status
+		FROM book_transfers
+		WHERE id = ?
+		FOR UPDATE
+	`
+
+	queryUpdateBookTransferShipped = `
+		UPDATE book_transfers
+		SET
+			status = 'in_transit',
+			shipped_by = ?,
+			shipped_at = NOW(),
+			updated_at = NOW()
+		WHERE id = ?
+	`
+
+	queryUpdateBookTransferReceived = `
+		UPDATE book_transfers
+		SET
+			status = 'received',
+			received_by = ?,
+			received_at = NOW(),
+			updated_at = NOW()
+		WHERE id = ?
+	`
+
+	queryInsertBookTransferCopy = `
+		INSERT INTO book_transfer_copies (book_transfer_id, copy_id) VALUES (?, ?)
+	`
+
+	queryFindBookTransferCopyIDs = `
+		SELECT copy_id FROM book_transfer_copies WHERE book_transfer_id = ? ORDER BY copy_id
+	`
+)
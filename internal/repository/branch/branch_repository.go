@@ -0,0 +1,72 @@
+package branch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type BranchRepository struct {
+	DB     *sqlx.DB
+	Logger *logrus.Logger
+}
+
+func (r *BranchRepository) InsertNewBranch(ctx context.Context, branch *models.Branch) error {
+	err := r.DB.QueryRowxContext(ctx, r.DB.Rebind(queryInsertNewBranch),
+		branch.Code,
+		branch.Name,
+		branch.Address,
+	).Scan(&branch.ID, &branch.CreatedAt, &branch.UpdatedAt)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code.Name() == "unique_violation" {
+			r.Logger.Error("repo::InsertNewBranch - branch code already exist: ", err)
+			return errors.New(constants.ErrBranchAlreadyExist)
+		}
+
+		r.Logger.Error("repo::InsertNewBranch - Failed to insert new branch : ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *BranchRepository) FindBranchByID(ctx context.Context, id string) (*models.Branch, error) {
+	var (
+		res = new(models.Branch)
+	)
+
+	err := r.DB.GetContext(ctx, res, r.DB.Rebind(queryFindBranchByID), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Error("repo::FindBranchByID - Branch doesnt exist")
+			return res, errors.New(constants.ErrBranchNotFound)
+		}
+
+		r.Logger.Error("repo::FindBranchByID - failed to find branch by id: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// FindAllBranch returns every branch, a library has few enough to skip paging.
+func (r *BranchRepository) FindAllBranch(ctx context.Context) ([]models.Branch, error) {
+	var (
+		res = make([]models.Branch, 0)
+	)
+
+	err := r.DB.SelectContext(ctx, &res, queryFindAllBranch)
+	if err != nil {
+		r.Logger.Error("repo::FindAllBranch - failed to find all branch: ", err)
+		return nil, err
+	}
+
+	return res, nil
+}
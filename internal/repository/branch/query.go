@@ -0,0 +1,37 @@
+package branch
+
+const (
+	queryInsertNewBranch = `
+		INSERT INTO branches
+		(
+			code,
+			name,
+			address
+		) VALUES (?, ?, ?)
+		RETURNING id, created_at, updated_at
+	`
+
+	queryFindBranchByID = `
+		SELECT
+			id,
+			code,
+			name,
+			address,
+			created_at,
+			updated_at
+		FROM branches
+		WHERE id = ?
+	`
+
+	queryFindAllBranch = `
+		SELECT
+			id,
+			code,
+			name,
+			address,
+			created_at,
+			updated_at
+		FROM branches
+		ORDER BY name ASC, id ASC
+	`
+)
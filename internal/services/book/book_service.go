@@ -3,7 +3,9 @@ package book
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hilmiikhsan/library-book-service/constants"
@@ -11,13 +13,17 @@ import (
 	"github.com/hilmiikhsan/library-book-service/internal/dto"
 	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
 	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
 	"github.com/sirupsen/logrus"
 )
 
 type BookService struct {
 	BookRepo interfaces.IBookRepository
 	External interfaces.IExternal
-	Logger   *logrus.Logger
+	Storage  interfaces.IStorage
+	// NameCache holds the author and category names resolved for expand
+	NameCache *helpers.TTLCache[string]
+	Logger    *logrus.Logger
 }
 
 func (s *BookService) CreateBook(ctx context.Context, req *dto.CreateBookRequest) error {
@@ -52,14 +58,56 @@ func (s *BookService) CreateBook(ctx context.Context, req *dto.CreateBookRequest
 		return errors.New(constants.ErrInvalidFormatDate)
 	}
 
-	err = s.BookRepo.InsertNewBook(ctx, &models.Book{
+	isbn, err := helpers.NormalizeIsbn(req.Isbn)
+	if err != nil {
+		s.Logger.Error("service::CreateBook - failed to normalize isbn: ", err)
+		return err
+	}
+
+	contributors := normalizeContributors(authorID, req.Contributors)
+
+	err = s.checkContributors(ctx, contributors, map[string]bool{req.AuthorID: true})
+	if err != nil {
+		s.Logger.Error("service::CreateBook - failed to check contributors: ", err)
+		return err
+	}
+
+	categoryIDs := normalizeCategoryIDs(categoryID, req.CategoryIDs)
+
+	err = s.checkCategories(ctx, categoryIDs, map[string]bool{req.CategoryID: true})
+	if err != nil {
+		s.Logger.Error("service::CreateBook - failed to check categories: ", err)
+		return err
+	}
+
+	bookData := &models.Book{
 		Title:         req.Title,
 		AuthorID:      authorID,
 		CategoryID:    categoryID,
-		Isbn:          &req.Isbn,
+		Isbn:          &isbn,
 		Description:   req.Description,
 		PublishedDate: publishedDate,
-	})
+		Subtitle:      helpers.NullableString(req.Subtitle),
+		OriginalTitle: helpers.NullableString(req.OriginalTitle),
+		Language:      helpers.NullableString(helpers.NormalizeLanguage(req.Language)),
+		PageCount:     helpers.NullableInt(req.PageCount),
+		Edition:       helpers.NullableString(req.Edition),
+		Format:        helpers.NullableString(req.Format),
+		Publisher:     helpers.NullableString(req.Publisher),
+		Contributors:  contributors,
+		CategoryIDs:   categoryIDs,
+		Tags:          helpers.NormalizeTags(req.Tags),
+	}
+
+	if req.WorkID != "" {
+		bookData.WorkID, err = s.checkWork(ctx, req.WorkID)
+		if err != nil {
+			s.Logger.Error("service::CreateBook - failed to check work: ", err)
+			return err
+		}
+	}
+
+	err = s.BookRepo.InsertNewBook(ctx, bookData)
 	if err != nil {
 		s.Logger.Error("service::CreateBook - failed to insert new book: ", err)
 		return err
@@ -75,6 +123,26 @@ func (s *BookService) GetDetailBook(ctx context.Context, id string) (*dto.GetDet
 		return &dto.GetDetailBookResponse{}, err
 	}
 
+	return s.mapDetailBook(ctx, bookData)
+}
+
+func (s *BookService) GetDetailBookByIsbn(ctx context.Context, isbn string) (*dto.GetDetailBookResponse, error) {
+	normalizedIsbn, err := helpers.NormalizeIsbn(isbn)
+	if err != nil {
+		s.Logger.Error("service::GetDetailBookByIsbn - failed to normalize isbn: ", err)
+		return &dto.GetDetailBookResponse{}, err
+	}
+
+	bookData, err := s.BookRepo.FindBookByIsbn(ctx, normalizedIsbn)
+	if err != nil {
+		s.Logger.Error("service::GetDetailBookByIsbn - failed to find book by isbn: ", err)
+		return &dto.GetDetailBookResponse{}, err
+	}
+
+	return s.mapDetailBook(ctx, bookData)
+}
+
+func (s *BookService) mapDetailBook(ctx context.Context, bookData *models.Book) (*dto.GetDetailBookResponse, error) {
 	authorData, err := s.External.GetDetailAuthor(ctx, bookData.AuthorID.String())
 	if err != nil {
 		if strings.Contains(err.Error(), constants.ErrAuthorNotFound) {
@@ -92,35 +160,144 @@ func (s *BookService) GetDetailBook(ctx context.Context, id string) (*dto.GetDet
 		return &dto.GetDetailBookResponse{}, err
 	}
 
-	return &dto.GetDetailBookResponse{
-		ID:    bookData.ID.String(),
-		Title: bookData.Title,
+	res := &dto.GetDetailBookResponse{
+		ID:            bookData.ID.String(),
+		Title:         bookData.Title,
+		Subtitle:      helpers.SafeString(bookData.Subtitle),
+		OriginalTitle: helpers.SafeString(bookData.OriginalTitle),
 		Author: dto.Author{
 			ID:   authorData.ID,
 			Name: authorData.Name,
 		},
+		Contributors: s.mapContributors(ctx, bookData, authorData),
 		Category: dto.Category{
 			ID:   categoryData.ID,
 			Name: categoryData.Name,
 		},
+		Categories:    s.mapCategories(ctx, bookData, categoryData),
+		Tags:          append(make([]string, 0, len(bookData.Tags)), bookData.Tags...),
 		Description:   bookData.Description,
 		Isbn:          *bookData.Isbn,
+		Edition:       helpers.SafeString(bookData.Edition),
+		Format:        helpers.SafeString(bookData.Format),
+		Publisher:     helpers.SafeString(bookData.Publisher),
+		Language:      helpers.SafeString(bookData.Language),
+		Cover:         coverURLs(bookData.ID, bookData.CoverUpdatedAt),
 		PublishedDate: bookData.PublishedDate.Format(constants.DateTimeFormat),
 		CreatedAt:     bookData.CreatedAt.Format(constants.DateTimeFormat),
 		UpdatedAt:     bookData.UpdatedAt.Format(constants.DateTimeFormat),
-	}, nil
+		Version:       bookData.Version,
+	}
+
+	if bookData.WorkID != nil {
+		res.WorkID = bookData.WorkID.String()
+	}
+
+	if bookData.PageCount != nil {
+		res.PageCount = *bookData.PageCount
+	}
+
+	res.Stock = bookData.AvailableStock
+	res.StockInfo = dto.BookStockInfo{
+		TotalStock:     bookData.TotalStock,
+		AvailableStock: bookData.AvailableStock,
+		ActiveLoans:    bookData.ActiveLoans,
+		Branches:       make([]dto.BranchStockInfo, 0, len(bookData.BranchStocks)),
+	}
+	for _, branchStock := range bookData.BranchStocks {
+		res.StockInfo.Branches = append(res.StockInfo.Branches, dto.BranchStockInfo{
+			Branch: dto.DetailBranch{
+				ID:   branchStock.BranchID.String(),
+				Name: branchStock.BranchName,
+			},
+			TotalStock:     branchStock.TotalStock,
+			AvailableStock: branchStock.AvailableStock,
+		})
+	}
+	if bookData.ExpectedAvailableAt != nil {
+		res.StockInfo.ExpectedAvailability = bookData.ExpectedAvailableAt.Format(constants.DateTimeFormat)
+	}
+
+	return res, nil
 }
 
-func (s *BookService) GetListBook(ctx context.Context, limit, offset int) (*dto.GetListBookResponse, error) {
-	pageSize := limit
-	pageIndex := (offset - 1) * limit
+func (s *BookService) GetListBook(ctx context.Context, req *dto.GetListBookRequest) (*dto.GetListBookResponse, error) {
+	pageReq := &dto.PaginationRequest{
+		Page:   req.Page,
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+	}
+
+	page, err := pagination.NewPageRequest(pageReq)
+	if err != nil {
+		s.Logger.Error("service::GetListBook - invalid cursor: ", err)
+		return nil, err
+	}
+
+	filter := &models.ListBookFilter{
+		CategoryID:    req.CategoryID,
+		AuthorID:      req.AuthorID,
+		Language:      helpers.NormalizeLanguage(req.Language),
+		AvailableOnly: req.AvailableOnly,
+		WithStock:     hasExpand(req.Expand, constants.ExpandStock),
+	}
+
+	if req.Sort != "" {
+		i := strings.LastIndex(req.Sort, "_")
+		filter.SortBy, filter.SortDesc = req.Sort[:i], req.Sort[i+1:] == constants.SortDirectionDesc
+	}
 
-	bookData, err := s.BookRepo.FindAllBook(ctx, pageSize, pageIndex)
+	// a cursor only continues the order it was handed out for
+	if page.Cursor != nil && page.Cursor.Sort != req.Sort {
+		s.Logger.Error("service::GetListBook - cursor does not match sort")
+		return nil, errors.New(constants.ErrInvalidCursor)
+	}
+
+	if err := pagination.CheckCursorValue(page.Cursor, listBookCursorValues[filter.SortBy]); err != nil {
+		s.Logger.Error("service::GetListBook - cursor value does not match sort")
+		return nil, err
+	}
+
+	if req.PublishedFrom != "" {
+		publishedFrom, err := helpers.ParseDate(req.PublishedFrom, constants.DateTimeFormat)
+		if err != nil {
+			s.Logger.Error("service::GetListBook - failed to parse published from: ", err)
+			return nil, errors.New(constants.ErrInvalidFormatDate)
+		}
+		filter.PublishedFrom = &publishedFrom
+	}
+
+	if req.PublishedTo != "" {
+		publishedTo, err := helpers.ParseDate(req.PublishedTo, constants.DateTimeFormat)
+		if err != nil {
+			s.Logger.Error("service::GetListBook - failed to parse published to: ", err)
+			return nil, errors.New(constants.ErrInvalidFormatDate)
+		}
+		filter.PublishedTo = &publishedTo
+	}
+
+	if filter.PublishedFrom != nil && filter.PublishedTo != nil && filter.PublishedFrom.After(*filter.PublishedTo) {
+		s.Logger.Error("service::GetListBook - published from is after published to")
+		return nil, helpers.NewCustomErrors(http.StatusBadRequest,
+			helpers.WithMessage(constants.ErrInvalidPublishedRange),
+			helpers.WithErrors("published_from", "published_from tidak boleh lebih besar dari published_to."),
+		)
+	}
+
+	bookData, err := s.BookRepo.FindAllBook(ctx, filter, page)
 	if err != nil {
 		s.Logger.Error("service::GetListBook - failed to find all book: ", err)
 		return nil, err
 	}
 
+	totalItems, err := s.BookRepo.CountAllBook(ctx, filter)
+	if err != nil {
+		s.Logger.Error("service::GetListBook - failed to count all book: ", err)
+		return nil, err
+	}
+
+	bookData, hasMore := pagination.TrimPage(bookData, page)
+
 	books := make([]dto.Book, 0)
 	for _, book := range bookData {
 		books = append(books, dto.Book{
@@ -129,17 +306,33 @@ func (s *BookService) GetListBook(ctx context.Context, limit, offset int) (*dto.
 			Description:   book.Description,
 			Isbn:          *book.Isbn,
 			PublishedDate: book.PublishedDate.Format(constants.DateTimeFormat),
+			Cover:         coverURLs(book.ID, book.CoverUpdatedAt),
 		})
 	}
 
-	pagination := dto.Pagination{
-		Page:  offset,
-		Limit: limit,
-	}
+	s.expandBooks(ctx, bookData, books, req.Expand)
+
+	first, last := pagination.PageCursors(bookData, func(book models.Book) *models.Cursor {
+		res := bookCursor(book)
+		res.Sort = req.Sort
+
+		switch filter.SortBy {
+		case constants.BookSortTitle:
+			res.Value = book.Title
+		case constants.BookSortPublishedDate:
+			res.Value = book.PublishedDate.Format(constants.DateTimeFormat)
+		case constants.BookSortCreatedAt:
+			res.Value = book.CreatedAt
+		case constants.BookSortPopularity:
+			res.Value = book.Popularity
+		}
+
+		return res
+	})
 
 	response := &dto.GetListBookResponse{
 		BookList:   books,
-		Pagination: pagination,
+		Pagination: pagination.New(pageReq, page, totalItems, hasMore, first, last),
 	}
 
 	return response, nil
@@ -157,6 +350,11 @@ func (s *BookService) UpdateBook(ctx context.Context, req *dto.UpdateBookRequest
 		return errors.New(constants.ErrBookNotFound)
 	}
 
+	if bookData.Version != req.Version {
+		s.Logger.Error("service::UpdateBook - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
 	publishedDate, err := helpers.ParseDate(req.PublishedDate, constants.DateTimeFormat)
 	if err != nil {
 		s.Logger.Error("service::UpdateBook - failed to parse published date: ", err)
@@ -195,10 +393,66 @@ func (s *BookService) UpdateBook(ctx context.Context, req *dto.UpdateBookRequest
 		CategoryID:    categoryID,
 		Description:   req.Description,
 		PublishedDate: publishedDate,
+		Version:       req.Version,
+		Subtitle:      helpers.NullableString(req.Subtitle),
+		OriginalTitle: helpers.NullableString(req.OriginalTitle),
+		Language:      helpers.NullableString(helpers.NormalizeLanguage(req.Language)),
+		PageCount:     helpers.NullableInt(req.PageCount),
+		Edition:       helpers.NullableString(req.Edition),
+		Format:        helpers.NullableString(req.Format),
+		Publisher:     helpers.NullableString(req.Publisher),
+	}
+
+	if req.WorkID != "" && (bookData.WorkID == nil || req.WorkID != bookData.WorkID.String()) {
+		mappingBookData.WorkID, err = s.checkWork(ctx, req.WorkID)
+		if err != nil {
+			s.Logger.Error("service::UpdateBook - failed to check work: ", err)
+			return err
+		}
 	}
 
 	if req.Isbn != "" {
-		mappingBookData.Isbn = &req.Isbn
+		isbn, err := helpers.NormalizeIsbn(req.Isbn)
+		if err != nil {
+			s.Logger.Error("service::UpdateBook - failed to normalize isbn: ", err)
+			return err
+		}
+
+		mappingBookData.Isbn = &isbn
+	}
+
+	if req.Contributors != nil {
+		mappingBookData.Contributors = normalizeContributors(authorID, req.Contributors)
+	} else if authorID != bookData.AuthorID {
+		mappingBookData.Contributors = withPrimaryAuthor(authorID, bookData.Contributors)
+	}
+
+	known := knownContributors(bookData)
+	known[req.AuthorID] = true
+
+	err = s.checkContributors(ctx, mappingBookData.Contributors, known)
+	if err != nil {
+		s.Logger.Error("service::UpdateBook - failed to check contributors: ", err)
+		return err
+	}
+
+	if req.CategoryIDs != nil {
+		mappingBookData.CategoryIDs = normalizeCategoryIDs(categoryID, req.CategoryIDs)
+	} else if categoryID != bookData.CategoryID {
+		mappingBookData.CategoryIDs = withPrimaryCategory(categoryID, bookData.CategoryID, bookData.CategoryIDs)
+	}
+
+	knownCategoryIDs := knownCategories(bookData)
+	knownCategoryIDs[req.CategoryID] = true
+
+	err = s.checkCategories(ctx, mappingBookData.CategoryIDs, knownCategoryIDs)
+	if err != nil {
+		s.Logger.Error("service::UpdateBook - failed to check categories: ", err)
+		return err
+	}
+
+	if req.Tags != nil {
+		mappingBookData.Tags = helpers.NormalizeTags(req.Tags)
 	}
 
 	err = s.BookRepo.UpdateNewBook(ctx, mappingBookData)
@@ -210,7 +464,148 @@ func (s *BookService) UpdateBook(ctx context.Context, req *dto.UpdateBookRequest
 	return nil
 }
 
-func (s *BookService) DeleteBook(ctx context.Context, id string) error {
+func (s *BookService) PatchBook(ctx context.Context, id string, req *dto.PatchBookRequest) error {
+	bookData, err := s.BookRepo.FindBookByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::PatchBook - failed to find book by id: ", err)
+		return err
+	}
+
+	if bookData.Version != req.Version {
+		s.Logger.Error("service::PatchBook - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	patch := &models.BookPatch{
+		ID:            bookData.ID,
+		Version:       req.Version,
+		Title:         req.Title,
+		Description:   req.Description,
+		Subtitle:      req.Subtitle,
+		OriginalTitle: req.OriginalTitle,
+		PageCount:     req.PageCount,
+		Edition:       req.Edition,
+		Format:        req.Format,
+		Publisher:     req.Publisher,
+	}
+
+	if req.Language != nil {
+		language := helpers.NormalizeLanguage(*req.Language)
+		patch.Language = &language
+	}
+
+	if req.WorkID != nil {
+		switch {
+		case *req.WorkID == "":
+			patch.WorkID = new(uuid.UUID)
+		case bookData.WorkID == nil || *req.WorkID != bookData.WorkID.String():
+			patch.WorkID, err = s.checkWork(ctx, *req.WorkID)
+			if err != nil {
+				s.Logger.Error("service::PatchBook - failed to check work: ", err)
+				return err
+			}
+		}
+	}
+
+	// author and category live in other services, only look them up when they change
+	if req.AuthorID != nil && *req.AuthorID != bookData.AuthorID.String() {
+		_, err = s.External.GetDetailAuthor(ctx, *req.AuthorID)
+		if err != nil {
+			s.Logger.Error("service::PatchBook - failed to get detail author: ", err)
+			return err
+		}
+
+		authorID, _ := uuid.Parse(*req.AuthorID)
+		patch.AuthorID = &authorID
+	}
+
+	if req.CategoryID != nil && *req.CategoryID != bookData.CategoryID.String() {
+		_, err = s.External.GetDetailCategory(ctx, *req.CategoryID)
+		if err != nil {
+			s.Logger.Error("service::PatchBook - failed to get detail category: ", err)
+			return err
+		}
+
+		categoryID, _ := uuid.Parse(*req.CategoryID)
+		patch.CategoryID = &categoryID
+	}
+
+	primary := bookData.AuthorID
+	if patch.AuthorID != nil {
+		primary = *patch.AuthorID
+	}
+
+	if req.Contributors != nil {
+		patch.Contributors = normalizeContributors(primary, *req.Contributors)
+	} else if patch.AuthorID != nil {
+		patch.Contributors = withPrimaryAuthor(primary, bookData.Contributors)
+	}
+
+	known := knownContributors(bookData)
+	known[primary.String()] = true
+
+	err = s.checkContributors(ctx, patch.Contributors, known)
+	if err != nil {
+		s.Logger.Error("service::PatchBook - failed to check contributors: ", err)
+		return err
+	}
+
+	primaryCategory := bookData.CategoryID
+	if patch.CategoryID != nil {
+		primaryCategory = *patch.CategoryID
+	}
+
+	if req.CategoryIDs != nil {
+		patch.CategoryIDs = normalizeCategoryIDs(primaryCategory, *req.CategoryIDs)
+	} else if patch.CategoryID != nil {
+		patch.CategoryIDs = withPrimaryCategory(primaryCategory, bookData.CategoryID, bookData.CategoryIDs)
+	}
+
+	knownCategoryIDs := knownCategories(bookData)
+	knownCategoryIDs[primaryCategory.String()] = true
+
+	err = s.checkCategories(ctx, patch.CategoryIDs, knownCategoryIDs)
+	if err != nil {
+		s.Logger.Error("service::PatchBook - failed to check categories: ", err)
+		return err
+	}
+
+	if req.Tags != nil {
+		patch.Tags = helpers.NormalizeTags(*req.Tags)
+	}
+
+	if req.PublishedDate != nil {
+		publishedDate, err := helpers.ParseDate(*req.PublishedDate, constants.DateTimeFormat)
+		if err != nil {
+			s.Logger.Error("service::PatchBook - failed to parse published date: ", err)
+			return errors.New(constants.ErrInvalidFormatDate)
+		}
+
+		patch.PublishedDate = &publishedDate
+	}
+
+	if req.Isbn != nil {
+		isbn, err := helpers.NormalizeIsbn(*req.Isbn)
+		if err != nil {
+			s.Logger.Error("service::PatchBook - failed to normalize isbn: ", err)
+			return err
+		}
+
+		if isbn != helpers.SafeString(bookData.Isbn) {
+			patch.Isbn = &isbn
+		}
+	}
+
+	err = s.BookRepo.PatchBookByID(ctx, patch)
+	if err != nil {
+		s.Logger.Error("service::PatchBook - failed to patch book: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *BookService) DeleteBook(ctx context.Context, id, deletedBy string) error {
 	bookData, err := s.BookRepo.FindBookByID(ctx, id)
 	if err != nil {
 		s.Logger.Error("service::DeleteBook - failed to find book by id: ", err)
@@ -222,7 +617,18 @@ func (s *BookService) DeleteBook(ctx context.Context, id string) error {
 		return errors.New(constants.ErrBookNotFound)
 	}
 
-	err = s.BookRepo.DeleteBookByID(ctx, bookData.ID.String())
+	activeLoans, err := s.BookRepo.CountActiveLoansByBookID(ctx, bookData.ID.String())
+	if err != nil {
+		s.Logger.Error("service::DeleteBook - failed to count active loans: ", err)
+		return err
+	}
+
+	if activeLoans > 0 {
+		s.Logger.Error("service::DeleteBook - Book has active loans")
+		return errors.New(constants.ErrBookHasActiveLoans)
+	}
+
+	err = s.BookRepo.DeleteBookByID(ctx, bookData.ID.String(), deletedBy)
 	if err != nil {
 		s.Logger.Error("service::DeleteBook - failed to delete book: ", err)
 		return err
@@ -231,50 +637,213 @@ func (s *BookService) DeleteBook(ctx context.Context, id string) error {
 	return nil
 }
 
+func (s *BookService) RestoreBook(ctx context.Context, id string) error {
+	err := s.BookRepo.RestoreBookByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::RestoreBook - failed to restore book: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *BookService) GetListDeletedBook(ctx context.Context, limit, offset int) (*dto.GetListDeletedBookResponse, error) {
+	pageSize := limit
+	pageIndex := (offset - 1) * limit
+
+	bookData, err := s.BookRepo.FindAllDeletedBook(ctx, pageSize, pageIndex)
+	if err != nil {
+		s.Logger.Error("service::GetListDeletedBook - failed to find all deleted book: ", err)
+		return nil, err
+	}
+
+	books := make([]dto.DeletedBook, 0)
+	for _, book := range bookData {
+		item := dto.DeletedBook{
+			ID:            book.ID.String(),
+			Title:         book.Title,
+			Description:   book.Description,
+			Isbn:          helpers.SafeString(book.Isbn),
+			PublishedDate: book.PublishedDate.Format(constants.DateTimeFormat),
+		}
+
+		if book.DeletedAt != nil {
+			item.DeletedAt = book.DeletedAt.Format(time.RFC3339)
+		}
+
+		if book.DeletedBy != nil {
+			item.DeletedBy = book.DeletedBy.String()
+		}
+
+		books = append(books, item)
+	}
+
+	response := &dto.GetListDeletedBookResponse{
+		BookList: books,
+		Pagination: dto.Pagination{
+			Page:  offset,
+			Limit: limit,
+		},
+	}
+
+	return response, nil
+}
+
 func (s *BookService) SearchBooks(ctx context.Context, req *dto.SearchBookRequest) (*dto.GetListBookResponse, error) {
-	pageSize := req.Limit
-	pageIndex := (req.Page - 1) * req.Limit
+	pageReq := &dto.PaginationRequest{
+		Page:   req.Page,
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+	}
 
-	booksData, err := s.BookRepo.SearchBooks(ctx, &req.Title, &req.CategoryID, &req.AuthorID, pageSize, pageIndex)
+	page, err := pagination.NewPageRequest(pageReq)
+	if err != nil {
+		s.Logger.Error("service::SearchBooks - invalid cursor: ", err)
+		return nil, err
+	}
+
+	// ranked results lead their cursor with the rank
+	if req.Q != "" || (req.Mode == constants.SearchModeFuzzy && req.Title != "") {
+		if err := pagination.CheckCursorValue(page.Cursor, pagination.CursorValueNumber); err != nil {
+			s.Logger.Error("service::SearchBooks - cursor value is not a rank")
+			return nil, err
+		}
+	}
+
+	if req.Mode == constants.SearchModeFuzzy && req.Threshold <= 0 {
+		req.Threshold = helpers.GetEnvFloat("SEARCH_SIMILARITY_THRESHOLD", 0.4)
+	}
+
+	categoryIDs := req.CategoryIDs
+	if req.CategoryID != "" {
+		categoryIDs = append([]string{req.CategoryID}, categoryIDs...)
+	}
+
+	filter := &models.SearchBookFilter{
+		Title:               req.Title,
+		AuthorID:            req.AuthorID,
+		Query:               req.Q,
+		CategoryIDs:         categoryIDs,
+		MatchAllCategories:  req.CategoryMatch == constants.MatchAll,
+		Tags:                helpers.NormalizeTags(req.Tags),
+		MatchAllTags:        req.TagMatch == constants.MatchAll,
+		Language:            helpers.NormalizeLanguage(req.Language),
+		Format:              req.Format,
+		Publisher:           strings.TrimSpace(req.Publisher),
+		GroupByWork:         req.GroupBy == constants.GroupByWork,
+		WithStock:           hasExpand(req.Expand, constants.ExpandStock),
+		Fuzzy:               req.Mode == constants.SearchModeFuzzy,
+		SimilarityThreshold: req.Threshold,
+	}
+
+	if req.Query != "" {
+		filter.Expression, err = parseSearchQuery(req.Query)
+		if err != nil {
+			s.Logger.Error("service::SearchBooks - invalid search query: ", err)
+			return nil, err
+		}
+	}
+
+	booksData, err := s.BookRepo.SearchBooks(ctx, filter, page)
 	if err != nil {
 		s.Logger.Error("service::SearchBooks - failed to search books: ", err)
 		return nil, err
 	}
 
+	totalItems, err := s.BookRepo.CountSearchBooks(ctx, filter)
+	if err != nil {
+		s.Logger.Error("service::SearchBooks - failed to count books: ", err)
+		return nil, err
+	}
+
+	booksData, hasMore := pagination.TrimPage(booksData, page)
+
 	books := make([]dto.Book, 0)
 	for _, book := range booksData {
-		books = append(books, dto.Book{
+		item := dto.Book{
 			ID:            book.ID.String(),
 			Title:         book.Title,
 			Description:   book.Description,
 			Isbn:          *book.Isbn,
 			PublishedDate: book.PublishedDate.Format(constants.DateTimeFormat),
-		})
+			EditionCount:  book.EditionCount,
+			Cover:         coverURLs(book.ID, book.CoverUpdatedAt),
+		}
+
+		if book.WorkID != nil {
+			item.WorkID = book.WorkID.String()
+		}
+
+		if req.Q != "" || req.Mode == constants.SearchModeFuzzy {
+			item.Rank = book.Rank
+		}
+
+		if req.Q != "" {
+			item.Highlight = &dto.BookHighlight{
+				Title:       book.TitleHighlight,
+				Description: book.DescriptionHighlight,
+			}
+		}
+
+		books = append(books, item)
 	}
 
-	pagination := dto.Pagination{
-		Page:  req.Page,
-		Limit: req.Limit,
+	s.expandBooks(ctx, booksData, books, req.Expand)
+
+	// ranked results are ordered by rank first, so it leads their cursor
+	cursor := bookCursor
+	if req.Q != "" || (filter.Fuzzy && req.Title != "") {
+		cursor = func(book models.Book) *models.Cursor {
+			res := bookCursor(book)
+			res.Value = book.Rank
+			return res
+		}
 	}
 
+	first, last := pagination.PageCursors(booksData, cursor)
+
 	response := &dto.GetListBookResponse{
 		BookList:   books,
-		Pagination: pagination,
+		Pagination: pagination.New(pageReq, page, totalItems, hasMore, first, last),
+	}
+
+	if req.Facets {
+		response.Facets, err = s.searchFacets(ctx, filter)
+		if err != nil {
+			s.Logger.Error("service::SearchBooks - failed to get facets: ", err)
+			return nil, err
+		}
 	}
 
 	return response, nil
 }
 
-func (S *BookService) GetRecommendations(ctx context.Context, userID string, limit, offset int) (*dto.GetListRecommendationsResponse, error) {
-	pageSize := limit
-	pageIndex := (offset - 1) * limit
+func (S *BookService) GetRecommendations(ctx context.Context, userID string, req *dto.PaginationRequest) (*dto.GetListRecommendationsResponse, error) {
+	page, err := pagination.NewPageRequest(req)
+	if err != nil {
+		S.Logger.Error("service::GetRecommendations - invalid cursor: ", err)
+		return nil, err
+	}
 
-	booksData, err := S.BookRepo.GetRecommendations(ctx, userID, pageSize, pageIndex)
+	if err := pagination.CheckCursorValue(page.Cursor, pagination.CursorValueDate); err != nil {
+		S.Logger.Error("service::GetRecommendations - cursor value is not a published date")
+		return nil, err
+	}
+
+	booksData, err := S.BookRepo.GetRecommendations(ctx, userID, page)
 	if err != nil {
 		S.Logger.Error("service::GetRecommendations - failed to get recommendations: ", err)
 		return nil, err
 	}
 
+	totalItems, err := S.BookRepo.CountRecommendations(ctx, userID)
+	if err != nil {
+		S.Logger.Error("service::GetRecommendations - failed to count recommendations: ", err)
+		return nil, err
+	}
+
+	booksData, hasMore := pagination.TrimPage(booksData, page)
+
 	recommendations := make([]dto.Recommendations, 0)
 	for _, book := range booksData {
 		recommendations = append(recommendations, dto.Recommendations{
@@ -284,18 +853,76 @@ func (S *BookService) GetRecommendations(ctx context.Context, userID string, lim
 			CategoryID:    book.CategoryID.String(),
 			Description:   book.Description,
 			PublishedDate: book.PublishedDate.Format(constants.DateTimeFormat),
+			Cover:         coverURLs(book.ID, book.CoverUpdatedAt),
 		})
 	}
 
-	pagination := dto.Pagination{
-		Page:  offset,
-		Limit: limit,
-	}
+	first, last := pagination.PageCursors(booksData, func(book models.Book) *models.Cursor {
+		res := bookCursor(book)
+		res.Value = book.PublishedDate.Format(constants.DateTimeFormat)
+		return res
+	})
 
 	response := &dto.GetListRecommendationsResponse{
 		RecommendationList: recommendations,
-		Pagination:         pagination,
+		Pagination:         pagination.New(req, page, totalItems, hasMore, first, last),
 	}
 
 	return response, nil
 }
+
+func (s *BookService) GetBooksByIDs(ctx context.Context, ids []string) ([]dto.Book, error) {
+	booksData, err := s.BookRepo.FindBooksByIDs(ctx, ids)
+	if err != nil {
+		s.Logger.Error("service::GetBooksByIDs - failed to find books by ids: ", err)
+		return nil, err
+	}
+
+	books := make([]dto.Book, 0)
+	for _, book := range booksData {
+		books = append(books, dto.Book{
+			ID:            book.ID.String(),
+			Title:         book.Title,
+			Description:   book.Description,
+			Isbn:          *book.Isbn,
+			PublishedDate: book.PublishedDate.Format(constants.DateTimeFormat),
+			Cover:         coverURLs(book.ID, book.CoverUpdatedAt),
+		})
+	}
+
+	return books, nil
+}
+
+func (s *BookService) SuggestBooks(ctx context.Context, req *dto.SuggestBookRequest) ([]dto.BookSuggestion, error) {
+	booksData, err := s.BookRepo.SuggestBooks(ctx, req.Prefix, req.Limit)
+	if err != nil {
+		s.Logger.Error("service::SuggestBooks - failed to suggest books: ", err)
+		return nil, err
+	}
+
+	suggestions := make([]dto.BookSuggestion, 0)
+	for _, book := range booksData {
+		suggestions = append(suggestions, dto.BookSuggestion{
+			ID:    book.ID.String(),
+			Title: book.Title,
+			Isbn:  *book.Isbn,
+		})
+	}
+
+	return suggestions, nil
+}
+
+// listBookCursorValues is what the cursor of each sort of GetListBook holds
+var listBookCursorValues = map[string]pagination.CursorValue{
+	constants.BookSortTitle:         pagination.CursorValueString,
+	constants.BookSortPublishedDate: pagination.CursorValueDate,
+	constants.BookSortCreatedAt:     pagination.CursorValueTime,
+	constants.BookSortPopularity:    pagination.CursorValueNumber,
+}
+
+func bookCursor(book models.Book) *models.Cursor {
+	return &models.Cursor{
+		UpdatedAt: book.UpdatedAt,
+		ID:        book.ID,
+	}
+}
@@ -0,0 +1,99 @@
+package book
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+// normalizeCategoryIDs puts the primary category first and drops duplicates.
+func normalizeCategoryIDs(primary uuid.UUID, ids []string) []uuid.UUID {
+	var (
+		categoryIDs = []uuid.UUID{primary}
+		seen        = map[uuid.UUID]bool{primary: true}
+	)
+
+	for _, id := range ids {
+		categoryID, err := uuid.Parse(id)
+		if err != nil || seen[categoryID] {
+			continue
+		}
+		seen[categoryID] = true
+		categoryIDs = append(categoryIDs, categoryID)
+	}
+
+	return categoryIDs
+}
+
+// withPrimaryCategory rebuilds the current categories around a new primary
+// category, the previous primary category is dropped.
+func withPrimaryCategory(primary, previous uuid.UUID, current []uuid.UUID) []uuid.UUID {
+	ids := make([]string, 0, len(current))
+	for _, categoryID := range current {
+		if categoryID == previous {
+			continue
+		}
+		ids = append(ids, categoryID.String())
+	}
+
+	return normalizeCategoryIDs(primary, ids)
+}
+
+// knownCategories returns the category ids already stored on the book, which
+// do not need another lookup.
+func knownCategories(bookData *models.Book) map[string]bool {
+	known := map[string]bool{bookData.CategoryID.String(): true}
+	for _, categoryID := range bookData.CategoryIDs {
+		known[categoryID.String()] = true
+	}
+
+	return known
+}
+
+// checkCategories looks up every category not already known to exist.
+func (s *BookService) checkCategories(ctx context.Context, categoryIDs []uuid.UUID, known map[string]bool) error {
+	for _, categoryID := range categoryIDs {
+		id := categoryID.String()
+		if known[id] {
+			continue
+		}
+
+		_, err := s.External.GetDetailCategory(ctx, id)
+		if err != nil {
+			s.Logger.Error("service::checkCategories - failed to get detail category: ", err)
+			return err
+		}
+
+		known[id] = true
+	}
+
+	return nil
+}
+
+// mapCategories resolves category names with the primary category, already
+// fetched, always listed first.
+func (s *BookService) mapCategories(ctx context.Context, bookData *models.Book, category models.CategoryModel) []dto.Category {
+	categories := []dto.Category{{
+		ID:   category.ID,
+		Name: category.Name,
+	}}
+
+	for _, categoryID := range bookData.CategoryIDs {
+		if categoryID == bookData.CategoryID {
+			continue
+		}
+
+		id := categoryID.String()
+
+		categoryData, err := s.External.GetDetailCategory(ctx, id)
+		if err != nil {
+			s.Logger.Warn("service::mapCategories - failed to get detail category: ", err)
+		}
+
+		categories = append(categories, dto.Category{ID: id, Name: categoryData.Name})
+	}
+
+	return categories
+}
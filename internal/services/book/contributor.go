@@ -0,0 +1,123 @@
+package book
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+// normalizeContributors puts the primary author first with the author role,
+// drops repeated author/role pairs and numbers the rest in request order.
+func normalizeContributors(primary uuid.UUID, reqs []dto.ContributorRequest) []models.BookContributor {
+	var (
+		contributors = []models.BookContributor{{AuthorID: primary, Role: constants.ContributorRoleAuthor}}
+		seen         = map[string]bool{primary.String() + ":" + constants.ContributorRoleAuthor: true}
+	)
+
+	for _, req := range reqs {
+		key := req.AuthorID + ":" + req.Role
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		authorID, _ := uuid.Parse(req.AuthorID)
+		contributors = append(contributors, models.BookContributor{
+			AuthorID: authorID,
+			Role:     req.Role,
+			Position: len(contributors),
+		})
+	}
+
+	return contributors
+}
+
+// withPrimaryAuthor rebuilds the current contributor list around a new primary
+// author, the previous primary author entry is replaced.
+func withPrimaryAuthor(primary uuid.UUID, current []models.BookContributor) []models.BookContributor {
+	reqs := make([]dto.ContributorRequest, 0, len(current))
+	for _, contributor := range current {
+		if contributor.Position == 0 {
+			continue
+		}
+
+		reqs = append(reqs, dto.ContributorRequest{
+			AuthorID: contributor.AuthorID.String(),
+			Role:     contributor.Role,
+		})
+	}
+
+	return normalizeContributors(primary, reqs)
+}
+
+// knownContributors returns the author ids already stored on the book, which
+// do not need another lookup.
+func knownContributors(bookData *models.Book) map[string]bool {
+	known := map[string]bool{bookData.AuthorID.String(): true}
+	for _, contributor := range bookData.Contributors {
+		known[contributor.AuthorID.String()] = true
+	}
+
+	return known
+}
+
+// checkContributors looks up every contributor author not already known to exist.
+func (s *BookService) checkContributors(ctx context.Context, contributors []models.BookContributor, known map[string]bool) error {
+	for _, contributor := range contributors {
+		id := contributor.AuthorID.String()
+		if known[id] {
+			continue
+		}
+
+		_, err := s.External.GetDetailAuthor(ctx, id)
+		if err != nil {
+			s.Logger.Error("service::checkContributors - failed to get detail author: ", err)
+			return err
+		}
+
+		known[id] = true
+	}
+
+	return nil
+}
+
+// mapContributors resolves contributor names, reusing the primary author
+// already fetched. Books without contributor rows fall back to the primary author.
+func (s *BookService) mapContributors(ctx context.Context, bookData *models.Book, author models.AuthorModel) []dto.Contributor {
+	if len(bookData.Contributors) == 0 {
+		return []dto.Contributor{{
+			ID:   author.ID,
+			Name: author.Name,
+			Role: constants.ContributorRoleAuthor,
+		}}
+	}
+
+	names := map[string]string{bookData.AuthorID.String(): author.Name}
+	contributors := make([]dto.Contributor, 0, len(bookData.Contributors))
+
+	for _, contributor := range bookData.Contributors {
+		id := contributor.AuthorID.String()
+
+		name, ok := names[id]
+		if !ok {
+			authorData, err := s.External.GetDetailAuthor(ctx, id)
+			if err != nil {
+				s.Logger.Warn("service::GetDetailBook - failed to get detail contributor: ", err)
+			}
+
+			name = authorData.Name
+			names[id] = name
+		}
+
+		contributors = append(contributors, dto.Contributor{
+			ID:   id,
+			Name: name,
+			Role: contributor.Role,
+		})
+	}
+
+	return contributors
+}
@@ -0,0 +1,117 @@
+package book
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+)
+
+// coverSizes are the bounding boxes every uploaded cover is resized into.
+var coverSizes = []struct {
+	name          string
+	width, height int
+}{
+	{constants.CoverSizeThumbnail, 200, 300},
+	{constants.CoverSizeLarge, 800, 1200},
+}
+
+func (s *BookService) UploadCover(ctx context.Context, id string, data []byte) (*dto.Cover, error) {
+	bookData, err := s.BookRepo.FindBookByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::UploadCover - failed to find book by id: ", err)
+		return nil, err
+	}
+
+	img, err := helpers.DecodeImage(data, constants.CoverMaxDimension)
+	if err != nil {
+		s.Logger.Error("service::UploadCover - failed to decode cover: ", err)
+		return nil, err
+	}
+
+	// variants are written before the book row, a failed upload keeps serving the previous cover
+	for _, size := range coverSizes {
+		variant, err := helpers.EncodeJPEG(helpers.ResizeImage(img, size.width, size.height), constants.CoverJPEGQuality)
+		if err != nil {
+			s.Logger.Error("service::UploadCover - failed to encode cover: ", err)
+			return nil, err
+		}
+
+		err = s.Storage.Put(ctx, coverKey(bookData.ID.String(), size.name), variant, constants.MimeJPEG)
+		if err != nil {
+			s.Logger.Error("service::UploadCover - failed to store cover: ", err)
+			return nil, err
+		}
+	}
+
+	updatedAt, err := s.BookRepo.UpdateBookCover(ctx, bookData.ID.String())
+	if err != nil {
+		s.Logger.Error("service::UploadCover - failed to update book cover: ", err)
+		return nil, err
+	}
+
+	return coverURLs(bookData.ID, &updatedAt), nil
+}
+
+func (s *BookService) GetCover(ctx context.Context, id, size string) (*dto.CoverFile, error) {
+	if size != constants.CoverSizeThumbnail && size != constants.CoverSizeLarge {
+		s.Logger.Error("service::GetCover - invalid cover size")
+		return nil, errors.New(constants.ErrInvalidCoverSize)
+	}
+
+	bookData, err := s.BookRepo.FindBookByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::GetCover - failed to find book by id: ", err)
+		return nil, err
+	}
+
+	if bookData.CoverUpdatedAt == nil {
+		s.Logger.Error("service::GetCover - Book has no cover")
+		return nil, errors.New(constants.ErrCoverNotFound)
+	}
+
+	data, err := s.Storage.Get(ctx, coverKey(bookData.ID.String(), size))
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrFileNotFound) {
+			s.Logger.Error("service::GetCover - cover file not found")
+			return nil, errors.New(constants.ErrCoverNotFound)
+		}
+
+		s.Logger.Error("service::GetCover - failed to get cover: ", err)
+		return nil, err
+	}
+
+	return &dto.CoverFile{
+		Data:        data,
+		ContentType: constants.MimeJPEG,
+		UpdatedAt:   *bookData.CoverUpdatedAt,
+	}, nil
+}
+
+func coverKey(bookID, size string) string {
+	return fmt.Sprintf("covers/%s/%s.jpg", bookID, size)
+}
+
+// coverURLs points at GET /book/v1/:id/cover/:size, the v parameter changes
+// on every upload so clients and proxies can cache each URL for good.
+func coverURLs(bookID uuid.UUID, updatedAt *time.Time) *dto.Cover {
+	if updatedAt == nil {
+		return nil
+	}
+
+	baseURL := strings.TrimSuffix(helpers.GetEnv("COVER_BASE_URL", ""), "/")
+	url := func(size string) string {
+		return fmt.Sprintf("%s/book/v1/%s/cover/%s?v=%d", baseURL, bookID, size, updatedAt.Unix())
+	}
+
+	return &dto.Cover{
+		Thumbnail: url(constants.CoverSizeThumbnail),
+		Large:     url(constants.CoverSizeLarge),
+	}
+}
@@ -0,0 +1,132 @@
+package book
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+func hasExpand(expand []string, value string) bool {
+	for _, e := range expand {
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+// expandBooks fills the nested objects asked for with expand. books[i] must
+// be mapped from booksData[i]; stock comes with the rows, names are resolved
+// once per distinct id on the page.
+func (s *BookService) expandBooks(ctx context.Context, booksData []models.Book, books []dto.Book, expand []string) {
+	var (
+		authors    map[string]string
+		categories map[string]string
+	)
+
+	if hasExpand(expand, constants.ExpandAuthor) {
+		ids := make([]string, 0, len(booksData))
+		for _, book := range booksData {
+			ids = append(ids, book.AuthorID.String())
+		}
+
+		authors = s.resolveNames(ctx, constants.ExpandAuthor, ids, func(ctx context.Context, id string) (string, error) {
+			author, err := s.External.GetDetailAuthor(ctx, id)
+			return author.Name, err
+		})
+	}
+
+	if hasExpand(expand, constants.ExpandCategory) {
+		ids := make([]string, 0, len(booksData))
+		for _, book := range booksData {
+			ids = append(ids, book.CategoryID.String())
+		}
+
+		categories = s.resolveNames(ctx, constants.ExpandCategory, ids, func(ctx context.Context, id string) (string, error) {
+			category, err := s.External.GetDetailCategory(ctx, id)
+			return category.Name, err
+		})
+	}
+
+	withStock := hasExpand(expand, constants.ExpandStock)
+
+	for i, book := range booksData {
+		if authors != nil {
+			books[i].Author = &dto.BookAuthor{
+				ID:   book.AuthorID.String(),
+				Name: authors[book.AuthorID.String()],
+			}
+		}
+
+		if categories != nil {
+			books[i].Category = &dto.BookCategory{
+				ID:   book.CategoryID.String(),
+				Name: categories[book.CategoryID.String()],
+			}
+		}
+
+		if withStock {
+			books[i].Stock = &dto.BookAvailability{
+				TotalStock:     book.TotalStock,
+				AvailableStock: book.AvailableStock,
+			}
+		}
+	}
+}
+
+// resolveNames looks up the name of every distinct id, at most
+// ExpandMaxConcurrency at a time. Names are kept in NameCache for a short
+// while, a failed lookup leaves the name empty and is retried next time.
+func (s *BookService) resolveNames(ctx context.Context, kind string, ids []string, fetch func(ctx context.Context, id string) (string, error)) map[string]string {
+	var (
+		res     = make(map[string]string, len(ids))
+		missing = make([]string, 0, len(ids))
+	)
+
+	for _, id := range ids {
+		if _, ok := res[id]; ok {
+			continue
+		}
+
+		name, ok := s.NameCache.Get(kind + ":" + id)
+		if !ok {
+			missing = append(missing, id)
+		}
+		res[id] = name
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, constants.ExpandMaxConcurrency)
+	)
+
+	for _, id := range missing {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			name, err := fetch(ctx, id)
+			if err != nil {
+				s.Logger.Warn("service::resolveNames - failed to get detail "+kind+": ", err)
+				return
+			}
+
+			s.NameCache.Set(kind+":"+id, name)
+
+			mu.Lock()
+			res[id] = name
+			mu.Unlock()
+		}(id)
+	}
+
+	wg.Wait()
+
+	return res
+}
@@ -0,0 +1,277 @@
+package book
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+var exportCSVHeader = []string{
+	"id",
+	"title",
+	"author_id",
+	"category_id",
+	"isbn",
+	"description",
+	"published_date",
+	"total_stock",
+	"available_stock",
+	"created_at",
+	"updated_at",
+}
+
+func (s *BookService) ExportBooks(ctx context.Context, req *dto.ExportBookRequest, w io.Writer) error {
+	filter := &models.ExportBookFilter{
+		CategoryID: req.CategoryID,
+		AuthorID:   req.AuthorID,
+	}
+
+	if req.UpdatedFrom != "" {
+		updatedFrom, err := helpers.ParseDate(req.UpdatedFrom, constants.DateTimeFormat)
+		if err != nil {
+			s.Logger.Error("service::ExportBooks - failed to parse updated from: ", err)
+			return err
+		}
+		filter.UpdatedFrom = &updatedFrom
+	}
+
+	if req.UpdatedTo != "" {
+		updatedTo, err := helpers.ParseDate(req.UpdatedTo, constants.DateTimeFormat)
+		if err != nil {
+			s.Logger.Error("service::ExportBooks - failed to parse updated to: ", err)
+			return err
+		}
+		// updated_to is inclusive of the whole day
+		updatedTo = updatedTo.AddDate(0, 0, 1)
+		filter.UpdatedTo = &updatedTo
+	}
+
+	var (
+		write func(book *models.BookExport) error
+		flush func() error
+	)
+
+	switch req.Format {
+	case constants.ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportCSVHeader); err != nil {
+			return err
+		}
+
+		write = func(book *models.BookExport) error {
+			item := mapExportBook(book)
+			return writer.Write([]string{
+				item.ID,
+				item.Title,
+				item.AuthorID,
+				item.CategoryID,
+				item.Isbn,
+				item.Description,
+				item.PublishedDate,
+				strconv.Itoa(item.TotalStock),
+				strconv.Itoa(item.AvailableStock),
+				item.CreatedAt,
+				item.UpdatedAt,
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case constants.ExportFormatJSONL:
+		encoder := json.NewEncoder(w)
+
+		write = func(book *models.BookExport) error {
+			return encoder.Encode(mapExportBook(book))
+		}
+		flush = func() error {
+			return nil
+		}
+	case constants.ExportFormatMARCXML:
+		marc := &marcXMLWriter{
+			service:    s,
+			w:          w,
+			encoder:    xml.NewEncoder(w),
+			authors:    make(map[string]string),
+			categories: make(map[string]string),
+		}
+		if err := marc.begin(); err != nil {
+			return err
+		}
+
+		write = func(book *models.BookExport) error {
+			return marc.write(ctx, book)
+		}
+		flush = marc.end
+	}
+
+	err := s.BookRepo.StreamBooksForExport(ctx, filter, write)
+	if err != nil {
+		s.Logger.Error("service::ExportBooks - failed to stream books: ", err)
+		return err
+	}
+
+	return flush()
+}
+
+func mapExportBook(book *models.BookExport) dto.ExportBook {
+	return dto.ExportBook{
+		ID:             book.ID.String(),
+		Title:          book.Title,
+		AuthorID:       book.AuthorID.String(),
+		CategoryID:     book.CategoryID.String(),
+		Isbn:           helpers.SafeString(book.Isbn),
+		Description:    book.Description,
+		PublishedDate:  book.PublishedDate.Format(constants.DateTimeFormat),
+		TotalStock:     book.TotalStock,
+		AvailableStock: book.AvailableStock,
+		CreatedAt:      book.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      book.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type marcRecord struct {
+	XMLName       xml.Name           `xml:"record"`
+	Leader        string             `xml:"leader"`
+	ControlFields []marcControlField `xml:"controlfield"`
+	DataFields    []marcDataField    `xml:"datafield"`
+}
+
+type marcControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcDataField struct {
+	Tag       string         `xml:"tag,attr"`
+	Ind1      string         `xml:"ind1,attr"`
+	Ind2      string         `xml:"ind2,attr"`
+	Subfields []marcSubfield `xml:"subfield"`
+}
+
+type marcSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// marcXMLWriter writes a MARC 21 slim collection. Author and category names
+// are resolved once per distinct id, since they live in other services.
+type marcXMLWriter struct {
+	service    *BookService
+	w          io.Writer
+	encoder    *xml.Encoder
+	authors    map[string]string
+	categories map[string]string
+}
+
+func (m *marcXMLWriter) begin() error {
+	_, err := io.WriteString(m.w, xml.Header+`<collection xmlns="http://www.loc.gov/MARC21/slim">`+"\n")
+	return err
+}
+
+func (m *marcXMLWriter) end() error {
+	if err := m.encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(m.w, "\n</collection>\n")
+	return err
+}
+
+func (m *marcXMLWriter) write(ctx context.Context, book *models.BookExport) error {
+	record := marcRecord{
+		Leader: "00000nam a2200000 a 4500",
+		ControlFields: []marcControlField{
+			{Tag: "001", Value: book.ID.String()},
+			{Tag: "005", Value: book.UpdatedAt.Format("20060102150405") + ".0"},
+		},
+	}
+
+	if isbn := helpers.SafeString(book.Isbn); isbn != "" {
+		record.DataFields = append(record.DataFields, marcDataField{
+			Tag: "020", Ind1: " ", Ind2: " ",
+			Subfields: []marcSubfield{{Code: "a", Value: isbn}},
+		})
+	}
+
+	author := marcDataField{Tag: "100", Ind1: "1", Ind2: " "}
+	if name := m.authorName(ctx, book.AuthorID.String()); name != "" {
+		author.Subfields = append(author.Subfields, marcSubfield{Code: "a", Value: name})
+	}
+	author.Subfields = append(author.Subfields, marcSubfield{Code: "0", Value: book.AuthorID.String()})
+	record.DataFields = append(record.DataFields, author)
+
+	record.DataFields = append(record.DataFields, marcDataField{
+		Tag: "245", Ind1: "1", Ind2: "0",
+		Subfields: []marcSubfield{{Code: "a", Value: book.Title}},
+	})
+
+	if !book.PublishedDate.IsZero() {
+		record.DataFields = append(record.DataFields, marcDataField{
+			Tag: "264", Ind1: " ", Ind2: "1",
+			Subfields: []marcSubfield{{Code: "c", Value: strconv.Itoa(book.PublishedDate.Year())}},
+		})
+	}
+
+	if book.Description != "" {
+		record.DataFields = append(record.DataFields, marcDataField{
+			Tag: "520", Ind1: " ", Ind2: " ",
+			Subfields: []marcSubfield{{Code: "a", Value: book.Description}},
+		})
+	}
+
+	subject := marcDataField{Tag: "650", Ind1: " ", Ind2: "4"}
+	if name := m.categoryName(ctx, book.CategoryID.String()); name != "" {
+		subject.Subfields = append(subject.Subfields, marcSubfield{Code: "a", Value: name})
+	}
+	subject.Subfields = append(subject.Subfields, marcSubfield{Code: "0", Value: book.CategoryID.String()})
+	record.DataFields = append(record.DataFields, subject)
+
+	// local holdings summary
+	record.DataFields = append(record.DataFields, marcDataField{
+		Tag: "999", Ind1: " ", Ind2: " ",
+		Subfields: []marcSubfield{
+			{Code: "t", Value: strconv.Itoa(book.TotalStock)},
+			{Code: "v", Value: strconv.Itoa(book.AvailableStock)},
+		},
+	})
+
+	return m.encoder.Encode(record)
+}
+
+func (m *marcXMLWriter) authorName(ctx context.Context, id string) string {
+	if name, ok := m.authors[id]; ok {
+		return name
+	}
+
+	author, err := m.service.External.GetDetailAuthor(ctx, id)
+	if err != nil {
+		m.service.Logger.Warn("service::ExportBooks - failed to get detail author: ", err)
+	}
+
+	m.authors[id] = author.Name
+	return author.Name
+}
+
+func (m *marcXMLWriter) categoryName(ctx context.Context, id string) string {
+	if name, ok := m.categories[id]; ok {
+		return name
+	}
+
+	category, err := m.service.External.GetDetailCategory(ctx, id)
+	if err != nil {
+		m.service.Logger.Warn("service::ExportBooks - failed to get detail category: ", err)
+	}
+
+	m.categories[id] = category.Name
+	return category.Name
+}
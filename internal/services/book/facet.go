@@ -0,0 +1,101 @@
+package book
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+// searchFacets groups the facet counts of a search, keeping the
+// FacetMaxValues largest values of each facet, and names the categories,
+// authors and year buckets.
+func (s *BookService) searchFacets(ctx context.Context, filter *models.SearchBookFilter) (*dto.SearchFacets, error) {
+	facetsData, err := s.BookRepo.SearchBookFacets(ctx, filter)
+	if err != nil {
+		s.Logger.Error("service::searchFacets - failed to count facets: ", err)
+		return nil, err
+	}
+
+	values := make(map[string][]dto.FacetValue)
+	for _, facet := range facetsData {
+		values[facet.Facet] = append(values[facet.Facet], dto.FacetValue{
+			Value: facet.Value,
+			Count: facet.Count,
+		})
+	}
+
+	for facet := range values {
+		facetValues := values[facet]
+		sort.Slice(facetValues, func(i, j int) bool {
+			// year buckets are listed newest first, every other facet largest first
+			if facet == constants.FacetPublishedYear {
+				yearI, _ := strconv.Atoi(facetValues[i].Value)
+				yearJ, _ := strconv.Atoi(facetValues[j].Value)
+				return yearI > yearJ
+			}
+			if facetValues[i].Count != facetValues[j].Count {
+				return facetValues[i].Count > facetValues[j].Count
+			}
+			return facetValues[i].Value < facetValues[j].Value
+		})
+
+		if len(facetValues) > constants.FacetMaxValues {
+			values[facet] = facetValues[:constants.FacetMaxValues]
+		}
+	}
+
+	res := &dto.SearchFacets{
+		Categories:     s.nameFacetValues(ctx, constants.FacetCategory, values[constants.FacetCategory]),
+		Authors:        s.nameFacetValues(ctx, constants.FacetAuthor, values[constants.FacetAuthor]),
+		PublishedYears: make([]dto.FacetValue, 0, len(values[constants.FacetPublishedYear])),
+		Languages:      nonNilFacetValues(values[constants.FacetLanguage]),
+		Availability:   nonNilFacetValues(values[constants.FacetAvailability]),
+	}
+
+	for _, value := range values[constants.FacetPublishedYear] {
+		if year, err := strconv.Atoi(value.Value); err == nil {
+			value.Name = fmt.Sprintf("%d-%d", year, year+constants.FacetYearBucketSize-1)
+		}
+		res.PublishedYears = append(res.PublishedYears, value)
+	}
+
+	return res, nil
+}
+
+// nameFacetValues resolves category or author names the same way expand does.
+func (s *BookService) nameFacetValues(ctx context.Context, facet string, values []dto.FacetValue) []dto.FacetValue {
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		ids = append(ids, value.Value)
+	}
+
+	fetch := func(ctx context.Context, id string) (string, error) {
+		author, err := s.External.GetDetailAuthor(ctx, id)
+		return author.Name, err
+	}
+	if facet == constants.FacetCategory {
+		fetch = func(ctx context.Context, id string) (string, error) {
+			category, err := s.External.GetDetailCategory(ctx, id)
+			return category.Name, err
+		}
+	}
+
+	names := s.resolveNames(ctx, facet, ids, fetch)
+	for i := range values {
+		values[i].Name = names[values[i].Value]
+	}
+
+	return nonNilFacetValues(values)
+}
+
+func nonNilFacetValues(values []dto.FacetValue) []dto.FacetValue {
+	if values == nil {
+		return make([]dto.FacetValue, 0)
+	}
+	return values
+}
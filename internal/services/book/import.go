@@ -0,0 +1,272 @@
+package book
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+func (s *BookService) ImportBooks(ctx context.Context, rows []dto.ImportBookRow, dryRun bool) (*dto.ImportBookResponse, error) {
+	var (
+		results    = make([]dto.ImportBookResult, len(rows))
+		authors    = make(map[string]string)
+		categories = make(map[string]string)
+		works      = make(map[string]string)
+		seenIsbns  = make(map[string]int)
+		pending    = make([]int, 0, len(rows))
+	)
+
+	for i, row := range rows {
+		results[i] = dto.ImportBookResult{
+			Row:  row.Row,
+			Isbn: row.Request.Isbn,
+		}
+
+		if len(row.Errors) > 0 {
+			results[i].Status = constants.ImportStatusRejected
+			results[i].Errors = row.Errors
+			continue
+		}
+
+		isbn, err := helpers.NormalizeIsbn(row.Request.Isbn)
+		if err != nil {
+			results[i].Status = constants.ImportStatusRejected
+			results[i].Errors = map[string][]string{"isbn": {constants.ErrInvalidIsbn}}
+			continue
+		}
+
+		rows[i].Request.Isbn = isbn
+		row.Request.Isbn = isbn
+		results[i].Isbn = isbn
+
+		rowErrors := make(map[string][]string)
+
+		if _, err := helpers.ParseDate(row.Request.PublishedDate, constants.DateTimeFormat); err != nil {
+			rowErrors["published_date"] = append(rowErrors["published_date"], constants.ErrInvalidFormatDate)
+		}
+
+		if msg := s.lookupImportReference(ctx, authors, row.Request.AuthorID, s.checkImportAuthor); msg != "" {
+			rowErrors["author_id"] = append(rowErrors["author_id"], msg)
+		}
+
+		if msg := s.lookupImportReference(ctx, categories, row.Request.CategoryID, s.checkImportCategory); msg != "" {
+			rowErrors["category_id"] = append(rowErrors["category_id"], msg)
+		}
+
+		for _, categoryID := range row.Request.CategoryIDs {
+			if msg := s.lookupImportReference(ctx, categories, categoryID, s.checkImportCategory); msg != "" {
+				rowErrors["category_ids"] = append(rowErrors["category_ids"], msg)
+			}
+		}
+
+		if row.Request.WorkID != "" {
+			if msg := s.lookupImportReference(ctx, works, row.Request.WorkID, s.checkImportWork); msg != "" {
+				rowErrors["work_id"] = append(rowErrors["work_id"], msg)
+			}
+		}
+
+		for _, contributor := range row.Request.Contributors {
+			if msg := s.lookupImportReference(ctx, authors, contributor.AuthorID, s.checkImportAuthor); msg != "" {
+				rowErrors["contributors"] = append(rowErrors["contributors"], msg)
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			results[i].Status = constants.ImportStatusRejected
+			results[i].Errors = rowErrors
+			continue
+		}
+
+		if _, ok := seenIsbns[row.Request.Isbn]; ok {
+			results[i].Status = constants.ImportStatusDuplicate
+			continue
+		}
+
+		seenIsbns[row.Request.Isbn] = i
+		pending = append(pending, i)
+	}
+
+	isbns := make([]string, 0, len(seenIsbns))
+	for isbn := range seenIsbns {
+		isbns = append(isbns, isbn)
+	}
+
+	existingIsbns, err := s.BookRepo.FindExistingIsbns(ctx, isbns)
+	if err != nil {
+		s.Logger.Error("service::ImportBooks - failed to find existing isbns: ", err)
+		return nil, err
+	}
+
+	for _, isbn := range existingIsbns {
+		if i, ok := seenIsbns[isbn]; ok {
+			results[i].Status = constants.ImportStatusDuplicate
+		}
+	}
+
+	books := make([]models.Book, 0, len(pending))
+	rowIndexes := make([]int, 0, len(pending))
+	for _, i := range pending {
+		if results[i].Status != "" {
+			continue
+		}
+
+		if dryRun {
+			results[i].Status = constants.ImportStatusValid
+			continue
+		}
+
+		req := rows[i].Request
+		authorID, _ := uuid.Parse(req.AuthorID)
+		categoryID, _ := uuid.Parse(req.CategoryID)
+		publishedDate, _ := helpers.ParseDate(req.PublishedDate, constants.DateTimeFormat)
+		isbn := req.Isbn
+
+		book := models.Book{
+			Title:         req.Title,
+			AuthorID:      authorID,
+			CategoryID:    categoryID,
+			Isbn:          &isbn,
+			Description:   req.Description,
+			PublishedDate: publishedDate,
+			Subtitle:      helpers.NullableString(req.Subtitle),
+			OriginalTitle: helpers.NullableString(req.OriginalTitle),
+			Language:      helpers.NullableString(helpers.NormalizeLanguage(req.Language)),
+			PageCount:     helpers.NullableInt(req.PageCount),
+			Edition:       helpers.NullableString(req.Edition),
+			Format:        helpers.NullableString(req.Format),
+			Publisher:     helpers.NullableString(req.Publisher),
+			Contributors:  normalizeContributors(authorID, req.Contributors),
+			CategoryIDs:   normalizeCategoryIDs(categoryID, req.CategoryIDs),
+			Tags:          helpers.NormalizeTags(req.Tags),
+		}
+
+		if req.WorkID != "" {
+			workID, _ := uuid.Parse(req.WorkID)
+			book.WorkID = &workID
+		}
+
+		books = append(books, book)
+		rowIndexes = append(rowIndexes, i)
+	}
+
+	for start := 0; start < len(books); start += constants.ImportBatchSize {
+		end := start + constants.ImportBatchSize
+		if end > len(books) {
+			end = len(books)
+		}
+
+		inserted, err := s.BookRepo.InsertNewBooks(ctx, books[start:end])
+		if err != nil {
+			s.Logger.Error("service::ImportBooks - failed to insert new books: ", err)
+			return nil, err
+		}
+
+		insertedIDs := make(map[string]string, len(inserted))
+		for _, book := range inserted {
+			insertedIDs[*book.Isbn] = book.ID.String()
+		}
+
+		for _, i := range rowIndexes[start:end] {
+			if id, ok := insertedIDs[rows[i].Request.Isbn]; ok {
+				results[i].Status = constants.ImportStatusCreated
+				results[i].BookID = id
+			} else {
+				// inserted concurrently by someone else after FindExistingIsbns
+				results[i].Status = constants.ImportStatusDuplicate
+			}
+		}
+	}
+
+	response := &dto.ImportBookResponse{
+		DryRun:  dryRun,
+		Total:   len(rows),
+		Results: results,
+	}
+
+	for _, result := range results {
+		switch result.Status {
+		case constants.ImportStatusCreated:
+			response.Created++
+		case constants.ImportStatusValid:
+			response.Valid++
+		case constants.ImportStatusDuplicate:
+			response.Skipped++
+		case constants.ImportStatusRejected:
+			response.Rejected++
+		}
+	}
+
+	return response, nil
+}
+
+// lookupImportReference resolves every distinct author, category or work id only
+// once per import and returns the rejection message, if any.
+func (s *BookService) lookupImportReference(ctx context.Context, seen map[string]string, id string, check func(ctx context.Context, id string) string) string {
+	if msg, ok := seen[id]; ok {
+		return msg
+	}
+
+	msg := check(ctx, id)
+	seen[id] = msg
+
+	return msg
+}
+
+func (s *BookService) checkImportAuthor(ctx context.Context, id string) string {
+	if !helpers.IsValidUUID(id) {
+		return constants.ErrIdIsNotValidUUID
+	}
+
+	_, err := s.External.GetDetailAuthor(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrAuthorNotFound) {
+			return constants.ErrAuthorNotFound
+		}
+
+		s.Logger.Error("service::ImportBooks - failed to get detail author: ", err)
+		return err.Error()
+	}
+
+	return ""
+}
+
+func (s *BookService) checkImportCategory(ctx context.Context, id string) string {
+	if !helpers.IsValidUUID(id) {
+		return constants.ErrIdIsNotValidUUID
+	}
+
+	_, err := s.External.GetDetailCategory(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrCategoryNotFound) {
+			return constants.ErrCategoryNotFound
+		}
+
+		s.Logger.Error("service::ImportBooks - failed to get detail category: ", err)
+		return err.Error()
+	}
+
+	return ""
+}
+
+func (s *BookService) checkImportWork(ctx context.Context, id string) string {
+	if !helpers.IsValidUUID(id) {
+		return constants.ErrIdIsNotValidUUID
+	}
+
+	_, err := s.BookRepo.FindWorkByID(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrWorkNotFound) {
+			return constants.ErrWorkNotFound
+		}
+
+		s.Logger.Error("service::ImportBooks - failed to find work by id: ", err)
+		return err.Error()
+	}
+
+	return ""
+}
@@ -0,0 +1,399 @@
+package book
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+// parseSearchQuery parses the advanced search syntax of GET /book/v1/search:
+//
+//	title:"dune" author:<uuid> year:1960..1970 -category:<uuid> (tag:scifi OR tag:fantasy)
+//
+// Terms next to each other must all match, OR (or AND) joins them explicitly
+// and binds weaker than the implicit AND, a leading - or NOT negates a term or
+// group. Only year understands ranges, either end may be left open. Errors are
+// returned as a *helpers.CustomError keyed by query.<field>, syntax errors by query.
+func parseSearchQuery(input string) (*models.SearchQueryNode, error) {
+	tokens, err := lexSearchQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &searchQueryParser{
+		tokens: tokens,
+		errs:   helpers.NewCustomErrors(http.StatusBadRequest, helpers.WithMessage(constants.ErrInvalidSearchQuery)),
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != searchTokenEOF {
+		return nil, searchQuerySyntaxError("%q tidak terduga di posisi %d.", tok.text, tok.pos+1)
+	}
+
+	if p.errs.HasErrors() {
+		return nil, p.errs
+	}
+
+	return node, nil
+}
+
+const (
+	searchTokenEOF = iota
+	searchTokenTerm
+	searchTokenOr
+	searchTokenAnd
+	searchTokenNot
+	searchTokenLParen
+	searchTokenRParen
+)
+
+type searchQueryToken struct {
+	kind  int
+	text  string
+	field string
+	value string
+	pos   int
+}
+
+func searchQuerySyntaxError(format string, args ...interface{}) *helpers.CustomError {
+	return helpers.NewCustomErrors(http.StatusBadRequest,
+		helpers.WithMessage(constants.ErrInvalidSearchQuery),
+		helpers.WithErrors(constants.SearchQueryErrorField, fmt.Sprintf(format, args...)),
+	)
+}
+
+func lexSearchQuery(input string) ([]searchQueryToken, error) {
+	var (
+		tokens = make([]searchQueryToken, 0)
+		i      = 0
+	)
+
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+	}
+	isDelimiter := func(c byte) bool {
+		return isSpace(c) || c == '(' || c == ')'
+	}
+
+	for i < len(input) {
+		c := input[i]
+
+		switch {
+		case isSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, searchQueryToken{kind: searchTokenLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, searchQueryToken{kind: searchTokenRParen, text: ")", pos: i})
+			i++
+		case c == '-' && i+1 < len(input) && !isDelimiter(input[i+1]):
+			tokens = append(tokens, searchQueryToken{kind: searchTokenNot, text: "-", pos: i})
+			i++
+		default:
+			start := i
+
+			var field string
+			if c != '"' {
+				for i < len(input) && !isDelimiter(input[i]) && input[i] != ':' && input[i] != '"' {
+					i++
+				}
+
+				if i < len(input) && input[i] == ':' {
+					field = strings.ToLower(input[start:i])
+					i++
+				} else {
+					// a bare word, possibly one of the keywords
+					word := input[start:i]
+					tok := searchQueryToken{kind: searchTokenTerm, text: word, value: word, pos: start}
+
+					switch word {
+					case "OR":
+						tok.kind = searchTokenOr
+					case "AND":
+						tok.kind = searchTokenAnd
+					case "NOT":
+						tok.kind = searchTokenNot
+					}
+
+					tokens = append(tokens, tok)
+					continue
+				}
+			}
+
+			var value string
+			if i < len(input) && input[i] == '"' {
+				phrase, end, ok := readSearchQueryPhrase(input, i)
+				if !ok {
+					return nil, searchQuerySyntaxError("tanda kutip di posisi %d tidak ditutup.", i+1)
+				}
+				value, i = phrase, end
+			} else {
+				valueStart := i
+				for i < len(input) && !isDelimiter(input[i]) {
+					i++
+				}
+				value = input[valueStart:i]
+			}
+
+			tokens = append(tokens, searchQueryToken{
+				kind:  searchTokenTerm,
+				text:  input[start:i],
+				field: field,
+				value: value,
+				pos:   start,
+			})
+		}
+	}
+
+	return append(tokens, searchQueryToken{kind: searchTokenEOF, pos: len(input)}), nil
+}
+
+// readSearchQueryPhrase reads the quoted phrase starting at input[start], \"
+// and \\ escape a quote and a backslash.
+func readSearchQueryPhrase(input string, start int) (string, int, bool) {
+	var phrase strings.Builder
+
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 < len(input) && (input[i+1] == '"' || input[i+1] == '\\') {
+				i++
+			}
+			phrase.WriteByte(input[i])
+		case '"':
+			return phrase.String(), i + 1, true
+		default:
+			phrase.WriteByte(input[i])
+		}
+	}
+
+	return "", 0, false
+}
+
+type searchQueryParser struct {
+	tokens []searchQueryToken
+	pos    int
+	depth  int
+	terms  int
+	errs   *helpers.CustomError
+}
+
+func (p *searchQueryParser) peek() searchQueryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *searchQueryParser) next() searchQueryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != searchTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *searchQueryParser) parseOr() (*models.SearchQueryNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*models.SearchQueryNode{node}
+	for p.peek().kind == searchTokenOr {
+		p.next()
+
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &models.SearchQueryNode{Op: constants.SearchQueryOr, Children: children}, nil
+}
+
+func (p *searchQueryParser) parseAnd() (*models.SearchQueryNode, error) {
+	children := make([]*models.SearchQueryNode, 0)
+
+	for {
+		tok := p.peek()
+		if tok.kind == searchTokenEOF || tok.kind == searchTokenOr || tok.kind == searchTokenRParen {
+			break
+		}
+
+		if tok.kind == searchTokenAnd {
+			if len(children) == 0 {
+				return nil, searchQuerySyntaxError("AND di posisi %d tidak memiliki operand kiri.", tok.pos+1)
+			}
+			p.next()
+			continue
+		}
+
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+
+	if len(children) == 0 {
+		tok := p.peek()
+		if tok.kind == searchTokenEOF {
+			return nil, searchQuerySyntaxError("ekspresi kosong di akhir query.")
+		}
+		return nil, searchQuerySyntaxError("ekspresi kosong sebelum %q di posisi %d.", tok.text, tok.pos+1)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &models.SearchQueryNode{Op: constants.SearchQueryAnd, Children: children}, nil
+}
+
+func (p *searchQueryParser) parseUnary() (*models.SearchQueryNode, error) {
+	if p.peek().kind != searchTokenNot {
+		return p.parsePrimary()
+	}
+
+	p.next()
+
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &models.SearchQueryNode{Op: constants.SearchQueryNot, Children: []*models.SearchQueryNode{node}}, nil
+}
+
+func (p *searchQueryParser) parsePrimary() (*models.SearchQueryNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case searchTokenLParen:
+		if p.depth++; p.depth > constants.SearchQueryMaxDepth {
+			return nil, searchQuerySyntaxError("kurung bersarang lebih dari %d tingkat.", constants.SearchQueryMaxDepth)
+		}
+
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.next().kind != searchTokenRParen {
+			return nil, searchQuerySyntaxError("kurung di posisi %d tidak ditutup.", tok.pos+1)
+		}
+		p.depth--
+
+		return node, nil
+	case searchTokenTerm:
+		if p.terms++; p.terms > constants.SearchQueryMaxTerms {
+			return nil, searchQuerySyntaxError("query berisi lebih dari %d term.", constants.SearchQueryMaxTerms)
+		}
+
+		return p.parseTerm(tok), nil
+	case searchTokenEOF:
+		return nil, searchQuerySyntaxError("query berakhir tanpa term setelah operator.")
+	}
+
+	return nil, searchQuerySyntaxError("%q tidak terduga di posisi %d.", tok.text, tok.pos+1)
+}
+
+// parseTerm validates and normalizes a term. Invalid values are collected in
+// p.errs so every bad field is reported at once.
+func (p *searchQueryParser) parseTerm(tok searchQueryToken) *models.SearchQueryNode {
+	var (
+		node       = &models.SearchQueryNode{Op: constants.SearchQueryTerm, Field: tok.field, Value: strings.TrimSpace(tok.value)}
+		errorField = constants.SearchQueryErrorField + "." + tok.field
+	)
+
+	if node.Value == "" {
+		if tok.field == "" {
+			errorField = constants.SearchQueryErrorField
+		}
+		p.errs.Add(errorField, fmt.Sprintf("nilai %q di posisi %d kosong.", tok.text, tok.pos+1))
+		return node
+	}
+
+	switch tok.field {
+	case "", constants.SearchFieldTitle, constants.SearchFieldPublisher:
+	case constants.SearchFieldAuthor, constants.SearchFieldCategory:
+		if !helpers.IsValidUUID(node.Value) {
+			p.errs.Add(errorField, fmt.Sprintf("%s harus berupa UUID yang valid.", tok.field))
+		}
+	case constants.SearchFieldIsbn:
+		isbn, err := helpers.NormalizeIsbn(node.Value)
+		if err != nil {
+			p.errs.Add(errorField, fmt.Sprintf("%s bukan ISBN-10 atau ISBN-13 yang valid.", tok.field))
+		}
+		node.Value = isbn
+	case constants.SearchFieldYear:
+		p.parseYear(node, errorField)
+	case constants.SearchFieldLanguage:
+		if !helpers.IsValidLanguage(node.Value) {
+			p.errs.Add(errorField, fmt.Sprintf("%s bukan kode bahasa ISO 639-1 yang valid.", tok.field))
+		}
+		node.Value = helpers.NormalizeLanguage(node.Value)
+	case constants.SearchFieldFormat:
+		node.Value = strings.ToLower(node.Value)
+		switch node.Value {
+		case constants.BookFormatHardcover, constants.BookFormatPaperback, constants.BookFormatEbook, constants.BookFormatAudio:
+		default:
+			p.errs.Add(errorField, fmt.Sprintf("%s harus salah satu dari %s, %s, %s, atau %s.", tok.field,
+				constants.BookFormatHardcover, constants.BookFormatPaperback, constants.BookFormatEbook, constants.BookFormatAudio))
+		}
+	case constants.SearchFieldTag:
+		node.Value = strings.ToLower(strings.Join(strings.Fields(node.Value), " "))
+	default:
+		p.errs.Add(errorField, fmt.Sprintf("field %s tidak dikenal, gunakan salah satu dari %s.", tok.field, strings.Join([]string{
+			constants.SearchFieldTitle, constants.SearchFieldAuthor, constants.SearchFieldCategory, constants.SearchFieldIsbn,
+			constants.SearchFieldYear, constants.SearchFieldLanguage, constants.SearchFieldFormat, constants.SearchFieldPublisher,
+			constants.SearchFieldTag,
+		}, ", ")))
+	}
+
+	return node
+}
+
+// parseYear accepts a single year or a from..to range with at most one open end.
+func (p *searchQueryParser) parseYear(node *models.SearchQueryNode, errorField string) {
+	message := fmt.Sprintf("%s harus berupa tahun atau rentang seperti 1960..1970.", constants.SearchFieldYear)
+
+	isYear := func(s string) bool {
+		year, err := strconv.Atoi(s)
+		return err == nil && year > 0 && year <= 9999
+	}
+
+	from, to, isRange := strings.Cut(node.Value, "..")
+	if !isRange {
+		if !isYear(node.Value) {
+			p.errs.Add(errorField, message)
+		}
+		return
+	}
+
+	if (from == "" && to == "") || (from != "" && !isYear(from)) || (to != "" && !isYear(to)) {
+		p.errs.Add(errorField, message)
+		return
+	}
+
+	if from != "" && to != "" {
+		fromYear, _ := strconv.Atoi(from)
+		toYear, _ := strconv.Atoi(to)
+		if fromYear > toYear {
+			p.errs.Add(errorField, fmt.Sprintf("awal rentang %s tidak boleh lebih besar dari akhirnya.", constants.SearchFieldYear))
+			return
+		}
+	}
+
+	node.IsRange, node.From, node.To = true, from, to
+}
@@ -0,0 +1,124 @@
+package book
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hilmiikhsan/library-book-service/helpers"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "single term", input: "dune", want: `"dune"`},
+		{name: "implicit and", input: "dune herbert", want: `("dune" AND "herbert")`},
+		{name: "explicit and", input: "dune AND herbert", want: `("dune" AND "herbert")`},
+		{name: "and binds tighter than or", input: "a b OR c", want: `(("a" AND "b") OR "c")`},
+		{name: "or on the left", input: "a OR b c", want: `("a" OR ("b" AND "c"))`},
+		{name: "parentheses group or", input: "a (b OR c)", want: `("a" AND ("b" OR "c"))`},
+		{name: "flat or chain", input: "a OR b OR c", want: `("a" OR "b" OR "c")`},
+		{name: "minus negates a term", input: "-a b", want: `(-"a" AND "b")`},
+		{name: "not negates a group", input: "NOT (a OR b)", want: `-("a" OR "b")`},
+		{name: "double negation", input: "--a", want: `--"a"`},
+		{name: "lone minus is a term", input: "a - b", want: `("a" AND "-" AND "b")`},
+		{name: "lowercase keywords are terms", input: "war or peace", want: `("war" AND "or" AND "peace")`},
+		{name: "hyphen inside a word", input: "sci-fi", want: `"sci-fi"`},
+		{name: "field is case insensitive", input: "TITLE:dune", want: `title:"dune"`},
+		{name: "quoted phrase", input: `title:"the left hand of darkness"`, want: `title:"the left hand of darkness"`},
+		{name: "quoted keywords stay a phrase", input: `"war OR peace"`, want: `"war OR peace"`},
+		{name: "escaped quote", input: `title:"say \"hi\""`, want: `title:"say \"hi\""`},
+		{name: "escaped backslash", input: `title:"a\\b"`, want: `title:"a\\b"`},
+		{name: "unknown escape is kept", input: `title:"a\nb"`, want: `title:"a\\nb"`},
+		{name: "phrase next to parenthesis", input: `("dune")`, want: `"dune"`},
+		{name: "year", input: "year:1965", want: "year:\"1965\""},
+		{name: "year range", input: "year:1960..1970", want: "year:1960..1970"},
+		{name: "year open start", input: "year:..1970", want: "year:..1970"},
+		{name: "year open end", input: "year:1960..", want: "year:1960.."},
+		{name: "isbn is normalized", input: "isbn:0-306-40615-2", want: `isbn:"9780306406157"`},
+		{name: "language is normalized", input: "language:EN", want: `language:"en"`},
+		{name: "format is normalized", input: "format:EBOOK", want: `format:"ebook"`},
+		{name: "tag is normalized", input: `tag:"Science   Fiction"`, want: `tag:"science fiction"`},
+		{name: "uuid fields", input: "author:9b2f4c1e-6a43-4d0b-9d5e-1f3a2b4c5d6e -category:1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f",
+			want: `(author:"9b2f4c1e-6a43-4d0b-9d5e-1f3a2b4c5d6e" AND -category:"1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f")`},
+		{name: "max terms", input: strings.Repeat("a ", 20), want: "(" + strings.TrimSuffix(strings.Repeat(`"a" AND `, 20), " AND ") + ")"},
+		{name: "max depth", input: "(((((a)))))", want: `"a"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseSearchQuery(tt.input)
+			if err != nil {
+				t.Fatalf("parseSearchQuery(%q) error = %v %v", tt.input, err, searchQueryErrors(err))
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("parseSearchQuery(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantFields []string
+		wantMsg    string
+	}{
+		{name: "empty", input: "", wantFields: []string{"query"}, wantMsg: "ekspresi kosong di akhir query."},
+		{name: "unclosed parenthesis", input: "(a b", wantFields: []string{"query"}, wantMsg: "kurung di posisi 1 tidak ditutup."},
+		{name: "unopened parenthesis", input: "a b)", wantFields: []string{"query"}, wantMsg: `")" tidak terduga di posisi 4.`},
+		{name: "empty group", input: "a ()", wantFields: []string{"query"}, wantMsg: `ekspresi kosong sebelum ")" di posisi 4.`},
+		{name: "unclosed quote", input: `title:"dune`, wantFields: []string{"query"}, wantMsg: "tanda kutip di posisi 7 tidak ditutup."},
+		{name: "escaped closing quote", input: `"dune\"`, wantFields: []string{"query"}, wantMsg: "tanda kutip di posisi 1 tidak ditutup."},
+		{name: "leading or", input: "OR a", wantFields: []string{"query"}, wantMsg: `ekspresi kosong sebelum "OR" di posisi 1.`},
+		{name: "trailing or", input: "a OR", wantFields: []string{"query"}, wantMsg: "ekspresi kosong di akhir query."},
+		{name: "leading and", input: "AND a", wantFields: []string{"query"}, wantMsg: "AND di posisi 1 tidak memiliki operand kiri."},
+		{name: "trailing not", input: "a NOT", wantFields: []string{"query"}, wantMsg: "query berakhir tanpa term setelah operator."},
+		{name: "too many terms", input: strings.Repeat("a ", 21), wantFields: []string{"query"}, wantMsg: "query berisi lebih dari 20 term."},
+		{name: "too deep", input: "((((((a))))))", wantFields: []string{"query"}, wantMsg: "kurung bersarang lebih dari 5 tingkat."},
+		{name: "empty value", input: `title:""`, wantFields: []string{"query.title"}},
+		{name: "empty bare phrase", input: `""`, wantFields: []string{"query"}},
+		{name: "year range reversed", input: "year:1970..1960", wantFields: []string{"query.year"}},
+		{name: "year range open both ends", input: "year:..", wantFields: []string{"query.year"}},
+		{name: "year not a number", input: "year:sixties", wantFields: []string{"query.year"}},
+		{name: "every bad field at once", input: "author:abc year:1970..1960 isbn:123 language:xx format:scroll foo:bar",
+			wantFields: []string{"query.author", "query.foo", "query.format", "query.isbn", "query.language", "query.year"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseSearchQuery(tt.input)
+			if err == nil {
+				t.Fatalf("parseSearchQuery(%q) = %s, want error", tt.input, node)
+			}
+
+			errs := searchQueryErrors(err)
+			fields := make([]string, 0, len(errs))
+			for field := range errs {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Fatalf("parseSearchQuery(%q) error fields = %v, want %v", tt.input, fields, tt.wantFields)
+			}
+			if tt.wantMsg != "" && !reflect.DeepEqual(errs["query"], []string{tt.wantMsg}) {
+				t.Errorf("parseSearchQuery(%q) error = %v, want %q", tt.input, errs["query"], tt.wantMsg)
+			}
+		})
+	}
+}
+
+func searchQueryErrors(err error) map[string][]string {
+	var customErr *helpers.CustomError
+	if !errors.As(err, &customErr) {
+		return nil
+	}
+	return customErr.Errors
+}
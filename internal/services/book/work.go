@@ -0,0 +1,88 @@
+package book
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+)
+
+func (s *BookService) CreateWork(ctx context.Context, req *dto.CreateWorkRequest) (*dto.CreateWorkResponse, error) {
+	work := &models.Work{
+		Title:       req.Title,
+		Description: req.Description,
+	}
+
+	err := s.BookRepo.InsertNewWork(ctx, work)
+	if err != nil {
+		s.Logger.Error("service::CreateWork - failed to insert new work: ", err)
+		return nil, err
+	}
+
+	return &dto.CreateWorkResponse{
+		ID: work.ID.String(),
+	}, nil
+}
+
+func (s *BookService) GetDetailWork(ctx context.Context, id string) (*dto.GetDetailWorkResponse, error) {
+	workData, err := s.BookRepo.FindWorkByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::GetDetailWork - failed to find work by id: ", err)
+		return nil, err
+	}
+
+	editionsData, err := s.BookRepo.FindEditionsByWorkID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::GetDetailWork - failed to find editions by work id: ", err)
+		return nil, err
+	}
+
+	res := &dto.GetDetailWorkResponse{
+		ID:          workData.ID.String(),
+		Title:       workData.Title,
+		Description: workData.Description,
+		Editions:    make([]dto.WorkEdition, 0, len(editionsData)),
+		CreatedAt:   workData.CreatedAt.Format(constants.DateTimeFormat),
+		UpdatedAt:   workData.UpdatedAt.Format(constants.DateTimeFormat),
+	}
+
+	for _, edition := range editionsData {
+		res.TotalStock += edition.TotalStock
+		res.AvailableStock += edition.AvailableStock
+
+		res.Editions = append(res.Editions, dto.WorkEdition{
+			ID:             edition.ID.String(),
+			Title:          edition.Title,
+			Isbn:           helpers.SafeString(edition.Isbn),
+			Edition:        helpers.SafeString(edition.Edition),
+			Format:         helpers.SafeString(edition.Format),
+			Publisher:      helpers.SafeString(edition.Publisher),
+			PublishedDate:  edition.PublishedDate.Format(constants.DateTimeFormat),
+			TotalStock:     edition.TotalStock,
+			AvailableStock: edition.AvailableStock,
+		})
+	}
+
+	return res, nil
+}
+
+// checkWork makes sure a book is only attached to an existing work.
+func (s *BookService) checkWork(ctx context.Context, workID string) (*uuid.UUID, error) {
+	_, err := s.BookRepo.FindWorkByID(ctx, workID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrWorkNotFound) {
+			s.Logger.Error("service::checkWork - work not found")
+			return nil, err
+		}
+
+		s.Logger.Error("service::checkWork - failed to find work by id: ", err)
+		return nil, err
+	}
+
+	id, _ := uuid.Parse(workID)
+	return &id, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/hilmiikhsan/library-book-service/internal/dto"
 	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
 	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/services/book_cache"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
@@ -17,6 +18,9 @@ import (
 type BookBorrowedService struct {
 	BookBorrowedRepo interfaces.IBookBorrowedRepository
 	BookStockRepo    interfaces.IBookStockRepository
+	BookCopyRepo     interfaces.IBookCopyRepository
+	BookRepo         interfaces.IBookRepository
+	BranchRepo       interfaces.IBranchRepository
 	Logger           *logrus.Logger
 	DB               *sqlx.DB
 }
@@ -31,7 +35,15 @@ func (s *BookBorrowedService) BookBorrowed(ctx context.Context, req *dto.BookBor
 		return errors.New(constants.ErrInvalidFormatDate)
 	}
 
-	countData, err := s.BookStockRepo.ValidateBookStockByBookID(ctx, req.BookID)
+	branchId, _ := uuid.Parse(req.BranchID)
+
+	_, err = s.BranchRepo.FindBranchByID(ctx, req.BranchID)
+	if err != nil {
+		s.Logger.Error("service::BookBorrowed - failed to find branch by id: ", err)
+		return err
+	}
+
+	countData, err := s.BookStockRepo.ValidateBookStockByBookID(ctx, req.BookID, req.BranchID)
 	if err != nil {
 		s.Logger.Error("service::BookBorrowed - failed to validate book stock: ", err)
 		return err
@@ -61,33 +73,85 @@ func (s *BookBorrowedService) BookBorrowed(ctx context.Context, req *dto.BookBor
 		return err
 	}
 
-	err = s.BookStockRepo.LockBookStock(ctx, tx, req.BookID)
+	err = s.BookStockRepo.LockBookStock(ctx, tx, req.BookID, req.BranchID)
 	if err != nil {
 		s.Logger.Error("service::BookBorrowed - failed to lock book stock: ", err)
 		return err
 	}
 
-	err = s.BookBorrowedRepo.InsertNewBookBorrowed(ctx, tx, &models.BookBorrowed{
-		UserID:  userId,
-		BookID:  bookId,
-		DueDate: dueDate,
-	})
+	// branches with copies of the book lend a specific copy, the others only count
+	// stock. The copies are counted under the stock lock, so none can be added in between
+	copyCount, err := s.BookCopyRepo.CountBookCopiesByBranch(ctx, tx, req.BookID, req.BranchID)
 	if err != nil {
-		s.Logger.Error("service::BookBorrowed - failed to insert new book borrowed: ", err)
+		s.Logger.Error("service::BookBorrowed - failed to count book copies: ", err)
 		return err
 	}
 
-	err = s.BookStockRepo.DecrementAvailableStock(ctx, tx, req.BookID, 1)
+	if copyCount == 0 && req.Barcode != "" {
+		s.Logger.Error("service::BookBorrowed - book has no copies")
+		err = errors.New(constants.ErrBookCopyNotFound)
+		return err
+	}
+
+	var bookCopy *models.BookCopy
+	if copyCount > 0 {
+		bookCopy, err = s.BookCopyRepo.LockAvailableBookCopy(ctx, tx, req.BookID, req.BranchID, req.Barcode)
+		if err != nil {
+			s.Logger.Error("service::BookBorrowed - failed to lock book copy: ", err)
+			return err
+		}
+
+		// a copy shelved at another branch cannot be checked out here
+		if bookCopy.Status != constants.CopyStatusAvailable || bookCopy.BranchID != branchId {
+			s.Logger.Error("service::BookBorrowed - book copy not available")
+			err = errors.New(constants.ErrBookCopyNotAvailable)
+			return err
+		}
+	}
+
+	bookBorrowed := &models.BookBorrowed{
+		UserID:   userId,
+		BookID:   bookId,
+		BranchID: branchId,
+		DueDate:  dueDate,
+	}
+	if bookCopy != nil {
+		bookBorrowed.CopyID = &bookCopy.ID
+	}
+
+	err = s.BookBorrowedRepo.InsertNewBookBorrowed(ctx, tx, bookBorrowed)
 	if err != nil {
-		s.Logger.Error("service::BookBorrowed - failed to update available stock: ", err)
+		s.Logger.Error("service::BookBorrowed - failed to insert new book borrowed: ", err)
 		return err
 	}
 
+	if bookCopy != nil {
+		err = s.BookCopyRepo.UpdateBookCopyStatus(ctx, tx, bookCopy.ID.String(), constants.CopyStatusOnLoan)
+		if err != nil {
+			s.Logger.Error("service::BookBorrowed - failed to update book copy status: ", err)
+			return err
+		}
+
+		err = s.BookStockRepo.SyncBookStockWithCopies(ctx, tx, req.BookID, req.BranchID, models.NewStockMovement(constants.StockMovementLoan, "", userID))
+		if err != nil {
+			s.Logger.Error("service::BookBorrowed - failed to sync book stock: ", err)
+			return err
+		}
+	} else {
+		err = s.BookStockRepo.DecrementAvailableStock(ctx, tx, req.BookID, req.BranchID, 1, models.NewStockMovement(constants.StockMovementLoan, "", userID))
+		if err != nil {
+			s.Logger.Error("service::BookBorrowed - failed to update available stock: ", err)
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		s.Logger.Error("service::BookBorrowed - failed to commit transaction: ", err)
 		return err
 	}
 
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, req.BookID)
+
 	return nil
 }
 
@@ -98,15 +162,12 @@ func (s *BookBorrowedService) BookReturned(ctx context.Context, req *dto.BookRet
 		return errors.New(constants.ErrInvalidFormatDate)
 	}
 
-	countData, err := s.BookStockRepo.ValidateBookStockByBookID(ctx, req.BookID)
-	if err != nil {
-		s.Logger.Error("service::BookBorrowed - failed to validate book stock: ", err)
-		return err
-	}
-
-	if countData <= 0 {
-		s.Logger.Error("service::BookBorrowed - book stock not found")
-		return errors.New(constants.ErrBookStockNotFound)
+	if req.BranchID != "" {
+		_, err = s.BranchRepo.FindBranchByID(ctx, req.BranchID)
+		if err != nil {
+			s.Logger.Error("service::BookReturned - failed to find branch by id: ", err)
+			return err
+		}
 	}
 
 	tx, err := s.DB.BeginTx(ctx, nil)
@@ -122,34 +183,112 @@ func (s *BookBorrowedService) BookReturned(ctx context.Context, req *dto.BookRet
 		}
 	}()
 
-	err = s.BookStockRepo.LockBookStockReturned(ctx, tx, req.BookID)
+	err = s.BookBorrowedRepo.ValidateBookReturned(ctx, tx, req.BookID, userID)
 	if err != nil {
-		s.Logger.Error("service::BookReturned - failed to lock book stock: ", err)
+		s.Logger.Error("service::BookReturned - failed to validate book returned: ", err)
 		return err
 	}
 
-	err = s.BookBorrowedRepo.ValidateBookReturned(ctx, tx, req.BookID, userID)
+	loan, err := s.BookBorrowedRepo.FindActiveLoan(ctx, tx, req.BookID, userID)
 	if err != nil {
-		s.Logger.Error("service::BookReturned - failed to validate book returned: ", err)
+		s.Logger.Error("service::BookReturned - failed to find active loan: ", err)
 		return err
 	}
 
-	err = s.BookBorrowedRepo.UpdateBookReturned(ctx, tx, returnedDate, req.BookID, userID)
+	if loan == nil {
+		s.Logger.Error("service::BookReturned - book not borrowed")
+		err = errors.New(constants.ErrBookAlreadyReturned)
+		return err
+	}
+
+	var (
+		loanBranchID   = loan.BranchID.String()
+		returnBranchID = req.BranchID
+	)
+	if returnBranchID == "" {
+		returnBranchID = loanBranchID
+	}
+
+	err = s.BookStockRepo.LockBookStockReturned(ctx, tx, req.BookID, loanBranchID, returnBranchID)
 	if err != nil {
-		s.Logger.Error("service::BookReturned - failed to update book returned: ", err)
+		s.Logger.Error("service::BookReturned - failed to lock book stock: ", err)
 		return err
 	}
 
-	err = s.BookStockRepo.IncrementAvailableStock(ctx, tx, req.BookID, 1)
+	// a copy can only be shelved at a branch that lends copies and a counted unit only
+	// at one that counts stock, the next copy sync or reconcile would drop it otherwise
+	if returnBranchID != loanBranchID {
+		var copyCount int
+		copyCount, err = s.BookCopyRepo.CountBookCopiesByBranch(ctx, tx, req.BookID, returnBranchID)
+		if err != nil {
+			s.Logger.Error("service::BookReturned - failed to count book copies: ", err)
+			return err
+		}
+
+		if (loan.CopyID != nil) != (copyCount > 0) {
+			s.Logger.Error("service::BookReturned - return branch tracks stock differently")
+			err = errors.New(constants.ErrBranchStockModeMismatch)
+			return err
+		}
+	}
+
+	err = s.BookBorrowedRepo.UpdateBookReturned(ctx, tx, returnedDate, loan.ID.String(), returnBranchID)
 	if err != nil {
-		s.Logger.Error("service::BookReturned - failed to update available stock: ", err)
+		s.Logger.Error("service::BookReturned - failed to update book returned: ", err)
 		return err
 	}
 
+	if loan.CopyID != nil {
+		status := constants.CopyStatusAvailable
+		if req.Damaged {
+			status = constants.CopyStatusInRepair
+		}
+
+		err = s.BookCopyRepo.UpdateBookCopyStatus(ctx, tx, loan.CopyID.String(), status)
+		if err != nil {
+			s.Logger.Error("service::BookReturned - failed to update book copy status: ", err)
+			return err
+		}
+
+		// the copy stays at the branch it was handed in at
+		branchIDs := []string{loanBranchID}
+		if returnBranchID != loanBranchID {
+			err = s.BookCopyRepo.UpdateBookCopyBranch(ctx, tx, loan.CopyID.String(), returnBranchID)
+			if err != nil {
+				s.Logger.Error("service::BookReturned - failed to update book copy branch: ", err)
+				return err
+			}
+
+			branchIDs = append(branchIDs, returnBranchID)
+		}
+
+		for _, branchID := range branchIDs {
+			err = s.BookStockRepo.SyncBookStockWithCopies(ctx, tx, req.BookID, branchID, models.NewStockMovement(constants.StockMovementReturn, "", userID))
+			if err != nil {
+				s.Logger.Error("service::BookReturned - failed to sync book stock: ", err)
+				return err
+			}
+		}
+	} else if returnBranchID != loanBranchID {
+		err = s.BookStockRepo.MoveStockToBranch(ctx, tx, req.BookID, loanBranchID, returnBranchID, 1, models.NewStockMovement(constants.StockMovementReturn, "", userID))
+		if err != nil {
+			s.Logger.Error("service::BookReturned - failed to return stock to branch: ", err)
+			return err
+		}
+	} else {
+		err = s.BookStockRepo.IncrementAvailableStock(ctx, tx, req.BookID, loanBranchID, 1, models.NewStockMovement(constants.StockMovementReturn, "", userID))
+		if err != nil {
+			s.Logger.Error("service::BookReturned - failed to update available stock: ", err)
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		s.Logger.Error("service::BookReturned - failed to commit transaction: ", err)
 		return err
 	}
 
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, req.BookID)
+
 	return nil
 }
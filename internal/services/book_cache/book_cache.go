@@ -0,0 +1,17 @@
+package book_cache
+
+import (
+	"context"
+
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/sirupsen/logrus"
+)
+
+// Refresh keeps the cached availability of a book in step with a committed loan,
+// stock, copy or transfer change. A failure only leaves the cache stale until it
+// expires, so it is logged and not returned.
+func Refresh(ctx context.Context, bookRepo interfaces.IBookRepository, logger *logrus.Logger, bookID string) {
+	if err := bookRepo.RefreshBookCache(ctx, bookID); err != nil {
+		logger.Warn("service::RefreshBookCache - failed to refresh book cache: ", err)
+	}
+}
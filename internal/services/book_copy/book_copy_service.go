@@ -0,0 +1,366 @@
+package book_copy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
+	"github.com/hilmiikhsan/library-book-service/internal/services/book_cache"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type BookCopyService struct {
+	BookCopyRepo     interfaces.IBookCopyRepository
+	BookStockRepo    interfaces.IBookStockRepository
+	BookBorrowedRepo interfaces.IBookBorrowedRepository
+	BookRepo         interfaces.IBookRepository
+	BranchRepo       interfaces.IBranchRepository
+	Logger           *logrus.Logger
+	DB               *sqlx.DB
+}
+
+func (s *BookCopyService) CreateBookCopy(ctx context.Context, req *dto.CreateBookCopyRequest, actorID string) (*dto.GetDetailBookCopyResponse, error) {
+	bookID, _ := uuid.Parse(req.BookID)
+	branchID, _ := uuid.Parse(req.BranchID)
+
+	_, err := s.BookRepo.FindBookByID(ctx, req.BookID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			s.Logger.Error("service::CreateBookCopy - book not found")
+			return nil, err
+		}
+
+		s.Logger.Error("service::CreateBookCopy - failed to get detail book: ", err)
+		return nil, err
+	}
+
+	_, err = s.BranchRepo.FindBranchByID(ctx, req.BranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookCopy - failed to find branch by id: ", err)
+		return nil, err
+	}
+
+	bookCopy := &models.BookCopy{
+		BookID:          bookID,
+		BranchID:        branchID,
+		Barcode:         strings.TrimSpace(req.Barcode),
+		AccessionNumber: strings.TrimSpace(req.AccessionNumber),
+		ShelfLocation:   helpers.NullableString(strings.TrimSpace(req.ShelfLocation)),
+		Status:          req.Status,
+	}
+
+	if bookCopy.Status == "" {
+		bookCopy.Status = constants.CopyStatusAvailable
+	}
+
+	if req.AcquisitionDate != "" {
+		acquisitionDate, err := helpers.ParseDate(req.AcquisitionDate, constants.DateTimeFormat)
+		if err != nil {
+			s.Logger.Error("service::CreateBookCopy - failed to parse acquisition date: ", err)
+			return nil, errors.New(constants.ErrInvalidFormatDate)
+		}
+		bookCopy.AcquisitionDate = &acquisitionDate
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::CreateBookCopy - failed to begin transaction: ", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = s.BookStockRepo.LockBookStock(ctx, tx, req.BookID, req.BranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookCopy - failed to lock book stock: ", err)
+		return nil, err
+	}
+
+	copyCount, err := s.BookCopyRepo.CountBookCopiesByBranch(ctx, tx, req.BookID, req.BranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookCopy - failed to count book copies: ", err)
+		return nil, err
+	}
+
+	// the first copy at a branch hands its stock over to the copy sync, which cannot see
+	// loans that were only counted against the stock
+	if copyCount == 0 {
+		err = s.BookBorrowedRepo.ValidateLoansWithoutCopy(ctx, tx, req.BookID, req.BranchID)
+		if err != nil {
+			s.Logger.Error("service::CreateBookCopy - failed to validate loans without copy: ", err)
+			return nil, err
+		}
+	}
+
+	err = s.BookCopyRepo.InsertNewBookCopy(ctx, tx, bookCopy)
+	if err != nil {
+		s.Logger.Error("service::CreateBookCopy - failed to insert new book copy: ", err)
+		return nil, err
+	}
+
+	err = s.BookStockRepo.SyncBookStockWithCopies(ctx, tx, req.BookID, req.BranchID, models.NewStockMovement(constants.StockMovementAcquisition, "", actorID))
+	if err != nil {
+		s.Logger.Error("service::CreateBookCopy - failed to sync book stock: ", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::CreateBookCopy - failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, req.BookID)
+
+	return s.GetDetailBookCopy(ctx, bookCopy.ID.String())
+}
+
+func (s *BookCopyService) GetDetailBookCopy(ctx context.Context, id string) (*dto.GetDetailBookCopyResponse, error) {
+	bookCopyData, err := s.BookCopyRepo.FindBookCopyByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::GetDetailBookCopy - failed to find book copy by id: ", err)
+		return nil, err
+	}
+
+	res := mapBookCopy(bookCopyData)
+	return &res, nil
+}
+
+func (s *BookCopyService) GetListBookCopy(ctx context.Context, req *dto.GetListBookCopyRequest) (*dto.GetListBookCopyResponse, error) {
+	pageReq := &dto.PaginationRequest{
+		Page:   req.Page,
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+	}
+
+	page, err := pagination.NewPageRequest(pageReq)
+	if err != nil {
+		s.Logger.Error("service::GetListBookCopy - invalid cursor: ", err)
+		return nil, err
+	}
+
+	filter := &models.BookCopyFilter{
+		BookID:   req.BookID,
+		BranchID: req.BranchID,
+		Status:   req.Status,
+	}
+
+	bookCopyData, err := s.BookCopyRepo.FindAllBookCopy(ctx, filter, page)
+	if err != nil {
+		s.Logger.Error("service::GetListBookCopy - failed to find all book copy: ", err)
+		return nil, err
+	}
+
+	totalItems, err := s.BookCopyRepo.CountAllBookCopy(ctx, filter)
+	if err != nil {
+		s.Logger.Error("service::GetListBookCopy - failed to count all book copy: ", err)
+		return nil, err
+	}
+
+	bookCopyData, hasMore := pagination.TrimPage(bookCopyData, page)
+
+	bookCopies := make([]dto.GetDetailBookCopyResponse, 0, len(bookCopyData))
+	for i := range bookCopyData {
+		bookCopies = append(bookCopies, mapBookCopy(&bookCopyData[i]))
+	}
+
+	first, last := pagination.PageCursors(bookCopyData, func(bookCopy models.BookCopy) *models.Cursor {
+		return &models.Cursor{
+			UpdatedAt: bookCopy.UpdatedAt,
+			ID:        bookCopy.ID,
+		}
+	})
+
+	return &dto.GetListBookCopyResponse{
+		BookCopyList: bookCopies,
+		Pagination:   pagination.New(pageReq, page, totalItems, hasMore, first, last),
+	}, nil
+}
+
+func (s *BookCopyService) PatchBookCopy(ctx context.Context, id string, req *dto.PatchBookCopyRequest, actorID string) error {
+	patch := &models.BookCopyPatch{
+		Version:         req.Version,
+		Barcode:         trimmed(req.Barcode),
+		AccessionNumber: trimmed(req.AccessionNumber),
+		ShelfLocation:   trimmed(req.ShelfLocation),
+		Status:          req.Status,
+	}
+
+	if req.AcquisitionDate != nil {
+		acquisitionDate := time.Time{}
+		if *req.AcquisitionDate != "" {
+			parsedDate, err := helpers.ParseDate(*req.AcquisitionDate, constants.DateTimeFormat)
+			if err != nil {
+				s.Logger.Error("service::PatchBookCopy - failed to parse acquisition date: ", err)
+				return errors.New(constants.ErrInvalidFormatDate)
+			}
+			acquisitionDate = parsedDate
+		}
+		patch.AcquisitionDate = &acquisitionDate
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::PatchBookCopy - failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	bookCopyData, err := s.BookCopyRepo.LockBookCopy(ctx, tx, id)
+	if err != nil {
+		s.Logger.Error("service::PatchBookCopy - failed to lock book copy: ", err)
+		return err
+	}
+
+	if bookCopyData.Version != req.Version {
+		s.Logger.Error("service::PatchBookCopy - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	if bookCopyData.Status == constants.CopyStatusWithdrawn {
+		s.Logger.Error("service::PatchBookCopy - book copy withdrawn")
+		return errors.New(constants.ErrBookCopyWithdrawn)
+	}
+
+	// a loaned copy changes status through its return only
+	if req.Status != nil && bookCopyData.Status == constants.CopyStatusOnLoan {
+		s.Logger.Error("service::PatchBookCopy - book copy on loan")
+		return errors.New(constants.ErrBookCopyOnLoan)
+	}
+
+	// and a copy in transit through the receipt of its transfer
+	if req.Status != nil && bookCopyData.Status == constants.CopyStatusInTransit {
+		s.Logger.Error("service::PatchBookCopy - book copy in transit")
+		return errors.New(constants.ErrBookCopyInTransit)
+	}
+
+	patch.ID = bookCopyData.ID
+
+	err = s.BookCopyRepo.PatchBookCopyByID(ctx, tx, patch)
+	if err != nil {
+		s.Logger.Error("service::PatchBookCopy - failed to patch book copy: ", err)
+		return err
+	}
+
+	if req.Status != nil {
+		// a copy that went missing is written off, any other status change corrects the counts
+		movementType := constants.StockMovementCorrection
+		if *req.Status == constants.CopyStatusLost {
+			movementType = constants.StockMovementWriteOff
+		}
+
+		err = s.BookStockRepo.SyncBookStockWithCopies(ctx, tx, bookCopyData.BookID.String(), bookCopyData.BranchID.String(), models.NewStockMovement(movementType, "", actorID))
+		if err != nil {
+			s.Logger.Error("service::PatchBookCopy - failed to sync book stock: ", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::PatchBookCopy - failed to commit transaction: ", err)
+		return err
+	}
+
+	if req.Status != nil {
+		book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookCopyData.BookID.String())
+	}
+
+	return nil
+}
+
+// WithdrawBookCopy takes a copy out of the stock for good, its row is kept
+// for the loan history.
+func (s *BookCopyService) WithdrawBookCopy(ctx context.Context, id, actorID string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::WithdrawBookCopy - failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	bookCopyData, err := s.BookCopyRepo.LockBookCopy(ctx, tx, id)
+	if err != nil {
+		s.Logger.Error("service::WithdrawBookCopy - failed to lock book copy: ", err)
+		return err
+	}
+
+	switch bookCopyData.Status {
+	case constants.CopyStatusWithdrawn:
+		s.Logger.Error("service::WithdrawBookCopy - book copy already withdrawn")
+		return errors.New(constants.ErrBookCopyWithdrawn)
+	case constants.CopyStatusOnLoan:
+		s.Logger.Error("service::WithdrawBookCopy - book copy on loan")
+		return errors.New(constants.ErrBookCopyOnLoan)
+	case constants.CopyStatusInTransit:
+		s.Logger.Error("service::WithdrawBookCopy - book copy in transit")
+		return errors.New(constants.ErrBookCopyInTransit)
+	}
+
+	err = s.BookCopyRepo.UpdateBookCopyStatus(ctx, tx, id, constants.CopyStatusWithdrawn)
+	if err != nil {
+		s.Logger.Error("service::WithdrawBookCopy - failed to withdraw book copy: ", err)
+		return err
+	}
+
+	err = s.BookStockRepo.SyncBookStockWithCopies(ctx, tx, bookCopyData.BookID.String(), bookCopyData.BranchID.String(), models.NewStockMovement(constants.StockMovementWriteOff, "", actorID))
+	if err != nil {
+		s.Logger.Error("service::WithdrawBookCopy - failed to sync book stock: ", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::WithdrawBookCopy - failed to commit transaction: ", err)
+		return err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookCopyData.BookID.String())
+
+	return nil
+}
+
+func mapBookCopy(bookCopy *models.BookCopy) dto.GetDetailBookCopyResponse {
+	res := dto.GetDetailBookCopyResponse{
+		ID: bookCopy.ID.String(),
+		Book: dto.DetailBook{
+			ID:    bookCopy.BookID.String(),
+			Title: bookCopy.BookTitle,
+		},
+		Branch: dto.DetailBranch{
+			ID:   bookCopy.BranchID.String(),
+			Name: bookCopy.BranchName,
+		},
+		Barcode:         bookCopy.Barcode,
+		AccessionNumber: bookCopy.AccessionNumber,
+		ShelfLocation:   helpers.SafeString(bookCopy.ShelfLocation),
+		Status:          bookCopy.Status,
+		CreatedAt:       bookCopy.CreatedAt.Format(constants.DateTimeFormat),
+		UpdatedAt:       bookCopy.UpdatedAt.Format(constants.DateTimeFormat),
+		Version:         bookCopy.Version,
+	}
+
+	if bookCopy.AcquisitionDate != nil {
+		res.AcquisitionDate = bookCopy.AcquisitionDate.Format(constants.DateTimeFormat)
+	}
+
+	if bookCopy.WithdrawnAt != nil {
+		res.WithdrawnAt = bookCopy.WithdrawnAt.Format(constants.DateTimeFormat)
+	}
+
+	return res
+}
+
+func trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+
+	res := strings.TrimSpace(*value)
+	return &res
+}
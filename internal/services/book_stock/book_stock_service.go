@@ -2,25 +2,36 @@ package book_stock
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
 	"github.com/hilmiikhsan/library-book-service/internal/dto"
 	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
 	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
+	"github.com/hilmiikhsan/library-book-service/internal/services/book_cache"
+	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
 
 type BookStockService struct {
 	BookStockRepo interfaces.IBookStockRepository
+	BookCopyRepo  interfaces.IBookCopyRepository
 	BookRepo      interfaces.IBookRepository
+	BranchRepo    interfaces.IBranchRepository
 	Logger        *logrus.Logger
+	DB            *sqlx.DB
 }
 
-func (s *BookStockService) CreateBookStock(ctx context.Context, req *dto.CreateBookStockRequest) error {
+func (s *BookStockService) CreateBookStock(ctx context.Context, req *dto.CreateBookStockRequest, actorID string) error {
 	bookID, _ := uuid.Parse(req.BookID)
+	branchID, _ := uuid.Parse(req.BranchID)
 
 	_, err := s.BookRepo.FindBookByID(ctx, req.BookID)
 	if err != nil {
@@ -33,7 +44,13 @@ func (s *BookStockService) CreateBookStock(ctx context.Context, req *dto.CreateB
 		return err
 	}
 
-	countData, err := s.BookStockRepo.ValidateBookStockByBookID(ctx, req.BookID)
+	_, err = s.BranchRepo.FindBranchByID(ctx, req.BranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookStock - failed to find branch by id: ", err)
+		return err
+	}
+
+	countData, err := s.BookStockRepo.ValidateBookStockByBookID(ctx, req.BookID, req.BranchID)
 	if err != nil {
 		s.Logger.Error("service::CreateBookStock - failed to validate BookStock by book id: ", err)
 		return err
@@ -44,16 +61,55 @@ func (s *BookStockService) CreateBookStock(ctx context.Context, req *dto.CreateB
 		return errors.New(constants.ErrBookStockAlreadyExist)
 	}
 
-	err = s.BookStockRepo.InsertNewBookStock(ctx, &models.BookStock{
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::CreateBookStock - failed to begin transaction: ", err)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::CreateBookStock - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	err = s.validateManualStock(ctx, tx, req.BookID, req.BranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookStock - failed to validate manual stock: ", err)
+		return err
+	}
+
+	heldStock, err := s.BookStockRepo.CountHeldStock(ctx, tx, req.BookID, req.BranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookStock - failed to count held stock: ", err)
+		return err
+	}
+
+	err = validateStockCounts(*req.TotalStock, *req.AvailableStock, heldStock)
+	if err != nil {
+		s.Logger.Error("service::CreateBookStock - invalid stock counts: ", err)
+		return err
+	}
+
+	err = s.BookStockRepo.InsertNewBookStock(ctx, tx, &models.BookStock{
 		BookID:         bookID,
-		TotalStock:     req.TotalStock,
-		AvailableStock: req.AvailableStock,
-	})
+		BranchID:       branchID,
+		TotalStock:     *req.TotalStock,
+		AvailableStock: *req.AvailableStock,
+	}, models.NewStockMovement(constants.StockMovementAcquisition, "", actorID))
 	if err != nil {
 		s.Logger.Error("service::CreateBookStock - failed to insert new BookStock: ", err)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::CreateBookStock - failed to commit transaction: ", err)
+		return err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, req.BookID)
+
 	return nil
 }
 
@@ -70,21 +126,37 @@ func (s *BookStockService) GetDetailBookStock(ctx context.Context, id string) (*
 			ID:    bookStockData.BookID.String(),
 			Title: bookStockData.BookTitle,
 		},
+		Branch: dto.DetailBranch{
+			ID:   bookStockData.BranchID.String(),
+			Name: bookStockData.BranchName,
+		},
 		TotalStock:     bookStockData.TotalStock,
 		AvailableStock: bookStockData.AvailableStock,
+		Version:        bookStockData.Version,
 	}, nil
 }
 
-func (s *BookStockService) GetListBookStock(ctx context.Context, limit, offset int) (*dto.GetListBookStockResponse, error) {
-	pageSize := limit
-	pageIndex := (offset - 1) * limit
+func (s *BookStockService) GetListBookStock(ctx context.Context, req *dto.PaginationRequest) (*dto.GetListBookStockResponse, error) {
+	page, err := pagination.NewPageRequest(req)
+	if err != nil {
+		s.Logger.Error("service::GetListBookStock - invalid cursor: ", err)
+		return nil, err
+	}
 
-	bookStockData, err := s.BookStockRepo.FindAllBookStock(ctx, pageSize, pageIndex)
+	bookStockData, err := s.BookStockRepo.FindAllBookStock(ctx, page)
 	if err != nil {
 		s.Logger.Error("service::GetListBookStock - failed to find all BookStock: ", err)
 		return nil, err
 	}
 
+	totalItems, err := s.BookStockRepo.CountAllBookStock(ctx)
+	if err != nil {
+		s.Logger.Error("service::GetListBookStock - failed to count all BookStock: ", err)
+		return nil, err
+	}
+
+	bookStockData, hasMore := pagination.TrimPage(bookStockData, page)
+
 	bookStocks := make([]dto.BookStock, 0)
 	for _, bookStock := range bookStockData {
 		bookStocks = append(bookStocks, dto.BookStock{
@@ -93,25 +165,31 @@ func (s *BookStockService) GetListBookStock(ctx context.Context, limit, offset i
 				ID:    bookStock.BookID.String(),
 				Title: bookStock.BookTitle,
 			},
+			Branch: dto.DetailBranch{
+				ID:   bookStock.BranchID.String(),
+				Name: bookStock.BranchName,
+			},
 			TotalStock:     bookStock.TotalStock,
 			AvailableStock: bookStock.AvailableStock,
 		})
 	}
 
-	pagination := dto.Pagination{
-		Page:  offset,
-		Limit: limit,
-	}
+	first, last := pagination.PageCursors(bookStockData, func(bookStock models.BookStock) *models.Cursor {
+		return &models.Cursor{
+			UpdatedAt: bookStock.UpdatedAt,
+			ID:        bookStock.ID,
+		}
+	})
 
 	response := &dto.GetListBookStockResponse{
 		BookStockList: bookStocks,
-		Pagination:    pagination,
+		Pagination:    pagination.New(req, page, totalItems, hasMore, first, last),
 	}
 
 	return response, nil
 }
 
-func (s *BookStockService) UpdateBookStock(ctx context.Context, req *dto.UpdateBookStockRequest) error {
+func (s *BookStockService) UpdateBookStock(ctx context.Context, req *dto.UpdateBookStockRequest, actorID string) error {
 	bookStockData, err := s.BookStockRepo.FindBookStockByID(ctx, req.ID)
 	if err != nil {
 		s.Logger.Error("service::UpdateBookStock - failed to find BookStock by id: ", err)
@@ -123,25 +201,165 @@ func (s *BookStockService) UpdateBookStock(ctx context.Context, req *dto.UpdateB
 		return errors.New(constants.ErrBookStockNotFound)
 	}
 
-	bookID, _ := uuid.Parse(req.BookID)
+	if bookStockData.Version != req.Version {
+		s.Logger.Error("service::UpdateBookStock - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	// the loans and movements of a stock row belong to its book, so the row cannot move to another one
+	if req.BookID != bookStockData.BookID.String() {
+		s.Logger.Error("service::UpdateBookStock - book id changed")
+		return helpers.NewCustomErrors(http.StatusBadRequest,
+			helpers.WithMessage(constants.ErrBookStockBookChanged),
+			helpers.WithErrors("book_id", "book_id tidak dapat diubah, buat stok baru untuk buku lain."),
+		)
+	}
 
 	mappingBookStockData := &models.BookStock{
 		ID:             bookStockData.ID,
-		BookID:         bookID,
-		TotalStock:     req.TotalStock,
-		AvailableStock: req.AvailableStock,
+		BookID:         bookStockData.BookID,
+		TotalStock:     *req.TotalStock,
+		AvailableStock: *req.AvailableStock,
+		Version:        req.Version,
+	}
+
+	movement := models.NewStockMovement(constants.StockMovementCorrection, req.Reason, actorID)
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::UpdateBookStock - failed to begin transaction: ", err)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::UpdateBookStock - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	// the counts are checked against the loans while the row is locked, so none
+	// can be opened or returned in between
+	current, err := s.BookStockRepo.LockBookStockByID(ctx, tx, bookStockData.ID.String())
+	if err != nil {
+		s.Logger.Error("service::UpdateBookStock - failed to lock BookStock: ", err)
+		return err
+	}
+
+	err = s.validateManualStock(ctx, tx, current.BookID.String(), current.BranchID.String())
+	if err != nil {
+		s.Logger.Error("service::UpdateBookStock - failed to validate manual stock: ", err)
+		return err
 	}
 
-	err = s.BookStockRepo.UpdateNewBookStock(ctx, mappingBookStockData)
+	heldStock, err := s.BookStockRepo.CountHeldStock(ctx, tx, current.BookID.String(), current.BranchID.String())
+	if err != nil {
+		s.Logger.Error("service::UpdateBookStock - failed to count held stock: ", err)
+		return err
+	}
+
+	err = validateStockCounts(*req.TotalStock, *req.AvailableStock, heldStock)
+	if err != nil {
+		s.Logger.Error("service::UpdateBookStock - invalid stock counts: ", err)
+		return err
+	}
+
+	err = s.BookStockRepo.UpdateNewBookStock(ctx, tx, mappingBookStockData, movement)
 	if err != nil {
 		s.Logger.Error("service::UpdateBookStock - failed to update BookStock: ", err)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::UpdateBookStock - failed to commit transaction: ", err)
+		return err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookStockData.BookID.String())
+
+	return nil
+}
+
+func (s *BookStockService) PatchBookStock(ctx context.Context, id string, req *dto.PatchBookStockRequest, actorID string) error {
+	bookStockData, err := s.BookStockRepo.FindBookStockByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::PatchBookStock - failed to find BookStock by id: ", err)
+		return err
+	}
+
+	if bookStockData.Version != req.Version {
+		s.Logger.Error("service::PatchBookStock - version conflict")
+		return errors.New(constants.ErrVersionConflict)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::PatchBookStock - failed to begin transaction: ", err)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::PatchBookStock - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	current, err := s.BookStockRepo.LockBookStockByID(ctx, tx, bookStockData.ID.String())
+	if err != nil {
+		s.Logger.Error("service::PatchBookStock - failed to lock BookStock: ", err)
+		return err
+	}
+
+	err = s.validateManualStock(ctx, tx, current.BookID.String(), current.BranchID.String())
+	if err != nil {
+		s.Logger.Error("service::PatchBookStock - failed to validate manual stock: ", err)
+		return err
+	}
+
+	heldStock, err := s.BookStockRepo.CountHeldStock(ctx, tx, current.BookID.String(), current.BranchID.String())
+	if err != nil {
+		s.Logger.Error("service::PatchBookStock - failed to count held stock: ", err)
+		return err
+	}
+
+	// the patched counts are checked against the stored ones they leave unchanged
+	totalStock, availableStock := current.TotalStock, current.AvailableStock
+	if req.TotalStock != nil {
+		totalStock = *req.TotalStock
+	}
+	if req.AvailableStock != nil {
+		availableStock = *req.AvailableStock
+	}
+
+	err = validateStockCounts(totalStock, availableStock, heldStock)
+	if err != nil {
+		s.Logger.Error("service::PatchBookStock - invalid stock counts: ", err)
+		return err
+	}
+
+	err = s.BookStockRepo.PatchBookStockByID(ctx, tx, &models.BookStockPatch{
+		ID:             bookStockData.ID,
+		Version:        req.Version,
+		TotalStock:     req.TotalStock,
+		AvailableStock: req.AvailableStock,
+	}, models.NewStockMovement(constants.StockMovementCorrection, helpers.SafeString(req.Reason), actorID))
+	if err != nil {
+		s.Logger.Error("service::PatchBookStock - failed to patch BookStock: ", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::PatchBookStock - failed to commit transaction: ", err)
+		return err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookStockData.BookID.String())
+
 	return nil
 }
 
-func (s *BookStockService) DeleteBookStock(ctx context.Context, id string) error {
+func (s *BookStockService) DeleteBookStock(ctx context.Context, id, actorID string) error {
 	bookStockData, err := s.BookStockRepo.FindBookStockByID(ctx, id)
 	if err != nil {
 		s.Logger.Error("service::DeleteBookStock - failed to find BookStock by id: ", err)
@@ -153,11 +371,277 @@ func (s *BookStockService) DeleteBookStock(ctx context.Context, id string) error
 		return errors.New(constants.ErrBookStockNotFound)
 	}
 
-	err = s.BookStockRepo.DeleteBookStockByID(ctx, bookStockData.ID.String())
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::DeleteBookStock - failed to begin transaction: ", err)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::DeleteBookStock - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	current, err := s.BookStockRepo.LockBookStockByID(ctx, tx, bookStockData.ID.String())
+	if err != nil {
+		s.Logger.Error("service::DeleteBookStock - failed to lock BookStock: ", err)
+		return err
+	}
+
+	err = s.validateManualStock(ctx, tx, current.BookID.String(), current.BranchID.String())
+	if err != nil {
+		s.Logger.Error("service::DeleteBookStock - failed to validate manual stock: ", err)
+		return err
+	}
+
+	err = s.BookStockRepo.DeleteBookStockByID(ctx, tx, bookStockData.ID.String(), models.NewStockMovement(constants.StockMovementWriteOff, "", actorID))
 	if err != nil {
 		s.Logger.Error("service::DeleteBookStock - failed to delete BookStock: ", err)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::DeleteBookStock - failed to commit transaction: ", err)
+		return err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookStockData.BookID.String())
+
+	return nil
+}
+
+func (s *BookStockService) AdjustBookStock(ctx context.Context, id string, req *dto.AdjustBookStockRequest, actorID string) (*dto.GetDetailBookStockResponse, error) {
+	// acquisitions only add stock and write-offs only remove it, corrections go either way
+	if req.Type == constants.StockMovementAcquisition && req.Delta < 0 {
+		s.Logger.Error("service::AdjustBookStock - negative delta for acquisition")
+		return nil, invalidStockAdjustment("delta harus positif untuk acquisition.")
+	}
+
+	if req.Type == constants.StockMovementWriteOff && req.Delta > 0 {
+		s.Logger.Error("service::AdjustBookStock - positive delta for write-off")
+		return nil, invalidStockAdjustment("delta harus negatif untuk write_off.")
+	}
+
+	bookStockData, err := s.BookStockRepo.FindBookStockByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::AdjustBookStock - failed to find BookStock by id: ", err)
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::AdjustBookStock - failed to begin transaction: ", err)
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::AdjustBookStock - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	current, err := s.BookStockRepo.LockBookStockByID(ctx, tx, bookStockData.ID.String())
+	if err != nil {
+		s.Logger.Error("service::AdjustBookStock - failed to lock BookStock: ", err)
+		return nil, err
+	}
+
+	err = s.validateManualStock(ctx, tx, current.BookID.String(), current.BranchID.String())
+	if err != nil {
+		s.Logger.Error("service::AdjustBookStock - failed to validate manual stock: ", err)
+		return nil, err
+	}
+
+	if current.TotalStock+req.Delta < 0 || current.AvailableStock+req.Delta < 0 {
+		s.Logger.Error("service::AdjustBookStock - insufficient stock")
+		err = errors.New(constants.ErrInsufficientStock)
+		return nil, err
+	}
+
+	heldStock, err := s.BookStockRepo.CountHeldStock(ctx, tx, current.BookID.String(), current.BranchID.String())
+	if err != nil {
+		s.Logger.Error("service::AdjustBookStock - failed to count held stock: ", err)
+		return nil, err
+	}
+
+	err = validateStockCounts(current.TotalStock+req.Delta, current.AvailableStock+req.Delta, heldStock)
+	if err != nil {
+		s.Logger.Error("service::AdjustBookStock - invalid stock counts: ", err)
+		return nil, err
+	}
+
+	err = s.BookStockRepo.AdjustBookStock(ctx, tx, bookStockData.ID.String(), req.Delta, models.NewStockMovement(req.Type, req.Reason, actorID))
+	if err != nil {
+		s.Logger.Error("service::AdjustBookStock - failed to adjust BookStock: ", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::AdjustBookStock - failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookStockData.BookID.String())
+
+	return s.GetDetailBookStock(ctx, bookStockData.ID.String())
+}
+
+func (s *BookStockService) GetListStockMovement(ctx context.Context, id string, req *dto.PaginationRequest) (*dto.GetListStockMovementResponse, error) {
+	page, err := pagination.NewPageRequest(req)
+	if err != nil {
+		s.Logger.Error("service::GetListStockMovement - invalid cursor: ", err)
+		return nil, err
+	}
+
+	bookStockData, err := s.BookStockRepo.FindBookStockByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::GetListStockMovement - failed to find BookStock by id: ", err)
+		return nil, err
+	}
+
+	movementData, err := s.BookStockRepo.FindStockMovementsByBookStockID(ctx, bookStockData.ID.String(), page)
+	if err != nil {
+		s.Logger.Error("service::GetListStockMovement - failed to find stock movements: ", err)
+		return nil, err
+	}
+
+	totalItems, err := s.BookStockRepo.CountStockMovementsByBookStockID(ctx, bookStockData.ID.String())
+	if err != nil {
+		s.Logger.Error("service::GetListStockMovement - failed to count stock movements: ", err)
+		return nil, err
+	}
+
+	movementData, hasMore := pagination.TrimPage(movementData, page)
+
+	movements := make([]dto.StockMovement, 0)
+	for _, movement := range movementData {
+		var actorID string
+		if movement.ActorID != nil {
+			actorID = movement.ActorID.String()
+		}
+
+		movements = append(movements, dto.StockMovement{
+			ID:             movement.ID.String(),
+			Type:           movement.Type,
+			TotalDelta:     movement.TotalDelta,
+			AvailableDelta: movement.AvailableDelta,
+			Reason:         helpers.SafeString(movement.Reason),
+			ActorID:        actorID,
+			CreatedAt:      movement.CreatedAt.Format(constants.DateTimeFormat),
+		})
+	}
+
+	first, last := pagination.PageCursors(movementData, func(movement models.StockMovement) *models.Cursor {
+		return &models.Cursor{
+			UpdatedAt: movement.CreatedAt,
+			ID:        movement.ID,
+		}
+	})
+
+	response := &dto.GetListStockMovementResponse{
+		StockMovementList: movements,
+		Pagination:        pagination.New(req, page, totalItems, hasMore, first, last),
+	}
+
+	return response, nil
+}
+
+// ReconcileBookStock reports the stock rows whose counts drifted from their
+// loans and copies, with fix they are corrected in a single transaction.
+func (s *BookStockService) ReconcileBookStock(ctx context.Context, fix bool, actorID string) (*dto.ReconcileBookStockResponse, error) {
+	var (
+		driftData []models.BookStockDrift
+		err       error
+	)
+
+	if fix {
+		driftData, err = s.BookStockRepo.ReconcileBookStock(ctx, models.NewStockMovement(constants.StockMovementCorrection, constants.StockMovementReasonReconcile, actorID))
+	} else {
+		driftData, err = s.BookStockRepo.FindBookStockDrift(ctx)
+	}
+	if err != nil {
+		s.Logger.Error("service::ReconcileBookStock - failed to reconcile BookStock: ", err)
+		return nil, err
+	}
+
+	items := make([]dto.BookStockDrift, 0)
+	for _, drift := range driftData {
+		items = append(items, dto.BookStockDrift{
+			ID: drift.ID.String(),
+			Book: dto.DetailBook{
+				ID:    drift.BookID.String(),
+				Title: drift.BookTitle,
+			},
+			Branch: dto.DetailBranch{
+				ID:   drift.BranchID.String(),
+				Name: drift.BranchName,
+			},
+			ManagedByCopies:        drift.ManagedByCopies,
+			ActiveLoans:            drift.ActiveLoans,
+			TotalStock:             drift.TotalStock,
+			ExpectedTotalStock:     drift.ExpectedTotalStock,
+			AvailableStock:         drift.AvailableStock,
+			ExpectedAvailableStock: drift.ExpectedAvailableStock,
+		})
+
+		if fix {
+			book_cache.Refresh(ctx, s.BookRepo, s.Logger, drift.BookID.String())
+		}
+	}
+
+	return &dto.ReconcileBookStockResponse{
+		Fixed:   fix,
+		Drifted: len(items),
+		Items:   items,
+	}, nil
+}
+
+// validateStockCounts keeps both counts non-negative and available_stock
+// within total_stock less heldStock, the units on loan or in transit that are
+// off the shelf but still counted in total_stock.
+func validateStockCounts(totalStock, availableStock, heldStock int) error {
+	errs := helpers.NewCustomErrors(http.StatusBadRequest, helpers.WithMessage(constants.ErrInvalidStockCounts))
+
+	if totalStock < 0 {
+		errs.Add("total_stock", "total_stock tidak boleh negatif.")
+	}
+
+	if availableStock < 0 {
+		errs.Add("available_stock", "available_stock tidak boleh negatif.")
+	} else if availableStock > totalStock-heldStock && heldStock > 0 {
+		errs.Add("available_stock", fmt.Sprintf("available_stock tidak boleh melebihi total_stock dikurangi %d stok yang sedang dipinjam atau dikirim.", heldStock))
+	} else if availableStock > totalStock {
+		errs.Add("available_stock", "available_stock tidak boleh melebihi total_stock.")
+	}
+
+	if errs.HasErrors() {
+		return errs
+	}
+
+	return nil
+}
+
+func invalidStockAdjustment(msg string) *helpers.CustomError {
+	return helpers.NewCustomErrors(http.StatusBadRequest,
+		helpers.WithMessage(constants.ErrInvalidStockAdjustment),
+		helpers.WithErrors("delta", msg),
+	)
+}
+
+// validateManualStock rejects hand written counts for a book with copies at the
+// branch, they would be overwritten by the next copy status change there.
+func (s *BookStockService) validateManualStock(ctx context.Context, tx *sql.Tx, bookID, branchID string) error {
+	copyCount, err := s.BookCopyRepo.CountBookCopiesByBranch(ctx, tx, bookID, branchID)
+	if err != nil {
+		return err
+	}
+
+	if copyCount > 0 {
+		return errors.New(constants.ErrBookStockManagedByCopies)
+	}
+
 	return nil
 }
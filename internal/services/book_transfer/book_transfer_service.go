@@ -0,0 +1,480 @@
+package book_transfer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/hilmiikhsan/library-book-service/internal/pagination"
+	"github.com/hilmiikhsan/library-book-service/internal/services/book_cache"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type BookTransferService struct {
+	BookTransferRepo interfaces.IBookTransferRepository
+	BookStockRepo    interfaces.IBookStockRepository
+	BookCopyRepo     interfaces.IBookCopyRepository
+	BookRepo         interfaces.IBookRepository
+	BranchRepo       interfaces.IBranchRepository
+	Logger           *logrus.Logger
+	DB               *sqlx.DB
+}
+
+func (s *BookTransferService) CreateBookTransfer(ctx context.Context, req *dto.CreateBookTransferRequest, actorID string) (*dto.GetDetailBookTransferResponse, error) {
+	if req.SourceBranchID == req.DestinationBranchID {
+		s.Logger.Error("service::CreateBookTransfer - source and destination branch are the same")
+		return nil, helpers.NewCustomErrors(http.StatusBadRequest,
+			helpers.WithMessage(constants.ErrInvalidBookTransfer),
+			helpers.WithErrors("destination_branch_id", "destination_branch_id harus berbeda dari source_branch_id."),
+		)
+	}
+
+	_, err := s.BookRepo.FindBookByID(ctx, req.BookID)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.ErrBookNotFound) {
+			s.Logger.Error("service::CreateBookTransfer - book not found")
+			return nil, err
+		}
+
+		s.Logger.Error("service::CreateBookTransfer - failed to get detail book: ", err)
+		return nil, err
+	}
+
+	for _, branchID := range []string{req.SourceBranchID, req.DestinationBranchID} {
+		_, err = s.BranchRepo.FindBranchByID(ctx, branchID)
+		if err != nil {
+			s.Logger.Error("service::CreateBookTransfer - failed to find branch by id: ", err)
+			return nil, err
+		}
+	}
+
+	countData, err := s.BookStockRepo.ValidateBookStockByBookID(ctx, req.BookID, req.SourceBranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookTransfer - failed to validate book stock: ", err)
+		return nil, err
+	}
+
+	if countData <= 0 {
+		s.Logger.Error("service::CreateBookTransfer - book stock not found")
+		return nil, errors.New(constants.ErrBookStockNotFound)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::CreateBookTransfer - failed to begin transaction: ", err)
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::CreateBookTransfer - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	// both branches stay locked until the transfer is stored, so neither can
+	// switch between copies and counted stock in between
+	err = s.BookStockRepo.LockBookStockTransfer(ctx, tx, req.BookID, req.SourceBranchID, req.DestinationBranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookTransfer - failed to lock book stock: ", err)
+		return nil, err
+	}
+
+	copyCount, err := s.BookCopyRepo.CountBookCopiesByBranch(ctx, tx, req.BookID, req.SourceBranchID)
+	if err != nil {
+		s.Logger.Error("service::CreateBookTransfer - failed to count book copies: ", err)
+		return nil, err
+	}
+
+	err = s.validateDestinationMode(ctx, tx, req.BookID, req.DestinationBranchID, copyCount > 0)
+	if err != nil {
+		s.Logger.Error("service::CreateBookTransfer - failed to validate destination branch: ", err)
+		return nil, err
+	}
+
+	bookID, _ := uuid.Parse(req.BookID)
+	sourceBranchID, _ := uuid.Parse(req.SourceBranchID)
+	destinationBranchID, _ := uuid.Parse(req.DestinationBranchID)
+
+	bookTransfer := &models.BookTransfer{
+		BookID:              bookID,
+		SourceBranchID:      sourceBranchID,
+		DestinationBranchID: destinationBranchID,
+		Quantity:            req.Quantity,
+		Status:              constants.TransferStatusRequested,
+		RequestedBy:         actorUUID(actorID),
+	}
+
+	err = s.BookTransferRepo.InsertNewBookTransfer(ctx, tx, bookTransfer)
+	if err != nil {
+		s.Logger.Error("service::CreateBookTransfer - failed to insert new book transfer: ", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::CreateBookTransfer - failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	return s.GetDetailBookTransfer(ctx, bookTransfer.ID.String())
+}
+
+func (s *BookTransferService) GetDetailBookTransfer(ctx context.Context, id string) (*dto.GetDetailBookTransferResponse, error) {
+	bookTransferData, err := s.BookTransferRepo.FindBookTransferByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::GetDetailBookTransfer - failed to find book transfer by id: ", err)
+		return nil, err
+	}
+
+	res := mapBookTransfer(bookTransferData)
+	return &res, nil
+}
+
+func (s *BookTransferService) GetListBookTransfer(ctx context.Context, req *dto.GetListBookTransferRequest) (*dto.GetListBookTransferResponse, error) {
+	pageReq := &dto.PaginationRequest{
+		Page:   req.Page,
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+	}
+
+	page, err := pagination.NewPageRequest(pageReq)
+	if err != nil {
+		s.Logger.Error("service::GetListBookTransfer - invalid cursor: ", err)
+		return nil, err
+	}
+
+	filter := &models.BookTransferFilter{
+		BranchID: req.BranchID,
+		Status:   req.Status,
+	}
+
+	bookTransferData, err := s.BookTransferRepo.FindAllBookTransfer(ctx, filter, page)
+	if err != nil {
+		s.Logger.Error("service::GetListBookTransfer - failed to find all book transfer: ", err)
+		return nil, err
+	}
+
+	totalItems, err := s.BookTransferRepo.CountAllBookTransfer(ctx, filter)
+	if err != nil {
+		s.Logger.Error("service::GetListBookTransfer - failed to count all book transfer: ", err)
+		return nil, err
+	}
+
+	bookTransferData, hasMore := pagination.TrimPage(bookTransferData, page)
+
+	bookTransfers := make([]dto.GetDetailBookTransferResponse, 0, len(bookTransferData))
+	for i := range bookTransferData {
+		bookTransfers = append(bookTransfers, mapBookTransfer(&bookTransferData[i]))
+	}
+
+	first, last := pagination.PageCursors(bookTransferData, func(bookTransfer models.BookTransfer) *models.Cursor {
+		return &models.Cursor{
+			UpdatedAt: bookTransfer.UpdatedAt,
+			ID:        bookTransfer.ID,
+		}
+	})
+
+	return &dto.GetListBookTransferResponse{
+		BookTransferList: bookTransfers,
+		Pagination:       pagination.New(pageReq, page, totalItems, hasMore, first, last),
+	}, nil
+}
+
+// ShipBookTransfer takes the quantity off the available stock of the source
+// branch. A book with copies sends that many available copies, which stay in
+// transit until the transfer is received.
+func (s *BookTransferService) ShipBookTransfer(ctx context.Context, id, actorID string) (*dto.GetDetailBookTransferResponse, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::ShipBookTransfer - failed to begin transaction: ", err)
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::ShipBookTransfer - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	bookTransfer, err := s.BookTransferRepo.LockBookTransfer(ctx, tx, id)
+	if err != nil {
+		s.Logger.Error("service::ShipBookTransfer - failed to lock book transfer: ", err)
+		return nil, err
+	}
+
+	if bookTransfer.Status != constants.TransferStatusRequested {
+		s.Logger.Error("service::ShipBookTransfer - book transfer already shipped")
+		err = errors.New(constants.ErrBookTransferStatus)
+		return nil, err
+	}
+
+	var (
+		bookID              = bookTransfer.BookID.String()
+		sourceBranchID      = bookTransfer.SourceBranchID.String()
+		destinationBranchID = bookTransfer.DestinationBranchID.String()
+		movement            = models.NewStockMovement(constants.StockMovementTransfer, "", actorID)
+	)
+
+	err = s.BookStockRepo.LockBookStockTransfer(ctx, tx, bookID, sourceBranchID, destinationBranchID)
+	if err != nil {
+		s.Logger.Error("service::ShipBookTransfer - failed to lock book stock: ", err)
+		return nil, err
+	}
+
+	copyCount, err := s.BookCopyRepo.CountBookCopiesByBranch(ctx, tx, bookID, sourceBranchID)
+	if err != nil {
+		s.Logger.Error("service::ShipBookTransfer - failed to count book copies: ", err)
+		return nil, err
+	}
+
+	// either branch may have changed how it tracks the book since the transfer was requested
+	err = s.validateDestinationMode(ctx, tx, bookID, destinationBranchID, copyCount > 0)
+	if err != nil {
+		s.Logger.Error("service::ShipBookTransfer - failed to validate destination branch: ", err)
+		return nil, err
+	}
+
+	if copyCount > 0 {
+		var bookCopies []models.BookCopy
+		bookCopies, err = s.BookCopyRepo.LockAvailableBookCopies(ctx, tx, bookID, sourceBranchID, bookTransfer.Quantity)
+		if err != nil {
+			s.Logger.Error("service::ShipBookTransfer - failed to lock book copies: ", err)
+			return nil, err
+		}
+
+		if len(bookCopies) < bookTransfer.Quantity {
+			s.Logger.Error("service::ShipBookTransfer - not enough available copies")
+			err = errors.New(constants.ErrInsufficientStock)
+			return nil, err
+		}
+
+		copyIDs := make([]uuid.UUID, 0, len(bookCopies))
+		for _, bookCopy := range bookCopies {
+			err = s.BookCopyRepo.UpdateBookCopyStatus(ctx, tx, bookCopy.ID.String(), constants.CopyStatusInTransit)
+			if err != nil {
+				s.Logger.Error("service::ShipBookTransfer - failed to update book copy status: ", err)
+				return nil, err
+			}
+
+			copyIDs = append(copyIDs, bookCopy.ID)
+		}
+
+		err = s.BookTransferRepo.InsertBookTransferCopies(ctx, tx, id, copyIDs)
+		if err != nil {
+			s.Logger.Error("service::ShipBookTransfer - failed to insert book transfer copies: ", err)
+			return nil, err
+		}
+
+		err = s.BookStockRepo.SyncBookStockWithCopies(ctx, tx, bookID, sourceBranchID, movement)
+		if err != nil {
+			s.Logger.Error("service::ShipBookTransfer - failed to sync book stock: ", err)
+			return nil, err
+		}
+	} else {
+		err = s.BookStockRepo.DecrementAvailableStock(ctx, tx, bookID, sourceBranchID, bookTransfer.Quantity, movement)
+		if err != nil {
+			s.Logger.Error("service::ShipBookTransfer - failed to update available stock: ", err)
+			return nil, err
+		}
+	}
+
+	err = s.BookTransferRepo.UpdateBookTransferShipped(ctx, tx, id, actorUUID(actorID))
+	if err != nil {
+		s.Logger.Error("service::ShipBookTransfer - failed to update book transfer: ", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::ShipBookTransfer - failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookID)
+
+	return s.GetDetailBookTransfer(ctx, id)
+}
+
+// ReceiveBookTransfer moves the shipped quantity out of the total of the
+// source branch and adds it, available, to the destination branch.
+func (s *BookTransferService) ReceiveBookTransfer(ctx context.Context, id, actorID string) (*dto.GetDetailBookTransferResponse, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.Logger.Error("service::ReceiveBookTransfer - failed to begin transaction: ", err)
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.Logger.Error("service::ReceiveBookTransfer - failed to rollback transaction: ", rollbackErr)
+			}
+		}
+	}()
+
+	bookTransfer, err := s.BookTransferRepo.LockBookTransfer(ctx, tx, id)
+	if err != nil {
+		s.Logger.Error("service::ReceiveBookTransfer - failed to lock book transfer: ", err)
+		return nil, err
+	}
+
+	if bookTransfer.Status != constants.TransferStatusInTransit {
+		s.Logger.Error("service::ReceiveBookTransfer - book transfer not in transit")
+		err = errors.New(constants.ErrBookTransferStatus)
+		return nil, err
+	}
+
+	var (
+		bookID              = bookTransfer.BookID.String()
+		sourceBranchID      = bookTransfer.SourceBranchID.String()
+		destinationBranchID = bookTransfer.DestinationBranchID.String()
+		movement            = models.NewStockMovement(constants.StockMovementTransfer, "", actorID)
+	)
+
+	err = s.BookStockRepo.LockBookStockTransfer(ctx, tx, bookID, sourceBranchID, destinationBranchID)
+	if err != nil {
+		s.Logger.Error("service::ReceiveBookTransfer - failed to lock book stock: ", err)
+		return nil, err
+	}
+
+	// a transfer shipped with copies receives those copies, any other moves counts
+	copyIDs, err := s.BookTransferRepo.FindBookTransferCopyIDs(ctx, tx, id)
+	if err != nil {
+		s.Logger.Error("service::ReceiveBookTransfer - failed to find book transfer copies: ", err)
+		return nil, err
+	}
+
+	err = s.validateDestinationMode(ctx, tx, bookID, destinationBranchID, len(copyIDs) > 0)
+	if err != nil {
+		s.Logger.Error("service::ReceiveBookTransfer - failed to validate destination branch: ", err)
+		return nil, err
+	}
+
+	if len(copyIDs) > 0 {
+		for _, copyID := range copyIDs {
+			err = s.BookCopyRepo.UpdateBookCopyBranch(ctx, tx, copyID.String(), destinationBranchID)
+			if err != nil {
+				s.Logger.Error("service::ReceiveBookTransfer - failed to update book copy branch: ", err)
+				return nil, err
+			}
+
+			err = s.BookCopyRepo.UpdateBookCopyStatus(ctx, tx, copyID.String(), constants.CopyStatusAvailable)
+			if err != nil {
+				s.Logger.Error("service::ReceiveBookTransfer - failed to update book copy status: ", err)
+				return nil, err
+			}
+		}
+
+		for _, branchID := range []string{sourceBranchID, destinationBranchID} {
+			err = s.BookStockRepo.SyncBookStockWithCopies(ctx, tx, bookID, branchID, movement)
+			if err != nil {
+				s.Logger.Error("service::ReceiveBookTransfer - failed to sync book stock: ", err)
+				return nil, err
+			}
+		}
+	} else {
+		err = s.BookStockRepo.MoveStockToBranch(ctx, tx, bookID, sourceBranchID, destinationBranchID, bookTransfer.Quantity, movement)
+		if err != nil {
+			s.Logger.Error("service::ReceiveBookTransfer - failed to move stock to branch: ", err)
+			return nil, err
+		}
+	}
+
+	err = s.BookTransferRepo.UpdateBookTransferReceived(ctx, tx, id, actorUUID(actorID))
+	if err != nil {
+		s.Logger.Error("service::ReceiveBookTransfer - failed to update book transfer: ", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("service::ReceiveBookTransfer - failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	book_cache.Refresh(ctx, s.BookRepo, s.Logger, bookID)
+
+	return s.GetDetailBookTransfer(ctx, id)
+}
+
+// validateDestinationMode rejects a transfer to a branch that tracks the stock of
+// the book differently. Copies received at a branch that counts its stock would
+// overwrite its counts, counted units received at a branch with copies would be
+// dropped by its next copy sync. A branch without stock for the book takes either.
+func (s *BookTransferService) validateDestinationMode(ctx context.Context, tx *sql.Tx, bookID, destinationBranchID string, withCopies bool) error {
+	copyCount, err := s.BookCopyRepo.CountBookCopiesByBranch(ctx, tx, bookID, destinationBranchID)
+	if err != nil {
+		return err
+	}
+
+	if copyCount > 0 {
+		if !withCopies {
+			return errors.New(constants.ErrBranchStockModeMismatch)
+		}
+		return nil
+	}
+
+	if !withCopies {
+		return nil
+	}
+
+	countData, err := s.BookStockRepo.ValidateBookStockByBookID(ctx, bookID, destinationBranchID)
+	if err != nil {
+		return err
+	}
+
+	if countData > 0 {
+		return errors.New(constants.ErrBranchStockModeMismatch)
+	}
+
+	return nil
+}
+
+// actorUUID is nil for actors without a UUID, as on stock movements.
+func actorUUID(actorID string) *uuid.UUID {
+	id, err := uuid.Parse(actorID)
+	if err != nil {
+		return nil
+	}
+
+	return &id
+}
+
+func mapBookTransfer(bookTransfer *models.BookTransfer) dto.GetDetailBookTransferResponse {
+	res := dto.GetDetailBookTransferResponse{
+		ID: bookTransfer.ID.String(),
+		Book: dto.DetailBook{
+			ID:    bookTransfer.BookID.String(),
+			Title: bookTransfer.BookTitle,
+		},
+		SourceBranch: dto.DetailBranch{
+			ID:   bookTransfer.SourceBranchID.String(),
+			Name: bookTransfer.SourceBranchName,
+		},
+		DestinationBranch: dto.DetailBranch{
+			ID:   bookTransfer.DestinationBranchID.String(),
+			Name: bookTransfer.DestinationBranchName,
+		},
+		Quantity:  bookTransfer.Quantity,
+		Status:    bookTransfer.Status,
+		CreatedAt: bookTransfer.CreatedAt.Format(constants.DateTimeFormat),
+		UpdatedAt: bookTransfer.UpdatedAt.Format(constants.DateTimeFormat),
+	}
+
+	if bookTransfer.ShippedAt != nil {
+		res.ShippedAt = bookTransfer.ShippedAt.Format(constants.DateTimeFormat)
+	}
+
+	if bookTransfer.ReceivedAt != nil {
+		res.ReceivedAt = bookTransfer.ReceivedAt.Format(constants.DateTimeFormat)
+	}
+
+	return res
+}
@@ -0,0 +1,71 @@
+package branch
+
+import (
+	"context"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/hilmiikhsan/library-book-service/helpers"
+	"github.com/hilmiikhsan/library-book-service/internal/dto"
+	"github.com/hilmiikhsan/library-book-service/internal/interfaces"
+	"github.com/hilmiikhsan/library-book-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+type BranchService struct {
+	BranchRepo interfaces.IBranchRepository
+	Logger     *logrus.Logger
+}
+
+func (s *BranchService) CreateBranch(ctx context.Context, req *dto.CreateBranchRequest) (*dto.GetDetailBranchResponse, error) {
+	branch := &models.Branch{
+		Code:    req.Code,
+		Name:    req.Name,
+		Address: helpers.NullableString(req.Address),
+	}
+
+	err := s.BranchRepo.InsertNewBranch(ctx, branch)
+	if err != nil {
+		s.Logger.Error("service::CreateBranch - failed to insert new branch: ", err)
+		return nil, err
+	}
+
+	return mapDetailBranch(branch), nil
+}
+
+func (s *BranchService) GetDetailBranch(ctx context.Context, id string) (*dto.GetDetailBranchResponse, error) {
+	branchData, err := s.BranchRepo.FindBranchByID(ctx, id)
+	if err != nil {
+		s.Logger.Error("service::GetDetailBranch - failed to find branch by id: ", err)
+		return nil, err
+	}
+
+	return mapDetailBranch(branchData), nil
+}
+
+func (s *BranchService) GetListBranch(ctx context.Context) (*dto.GetListBranchResponse, error) {
+	branchData, err := s.BranchRepo.FindAllBranch(ctx)
+	if err != nil {
+		s.Logger.Error("service::GetListBranch - failed to find all branch: ", err)
+		return nil, err
+	}
+
+	branches := make([]dto.GetDetailBranchResponse, 0)
+	for i := range branchData {
+		branches = append(branches, *mapDetailBranch(&branchData[i]))
+	}
+
+	return &dto.GetListBranchResponse{
+		BranchList: branches,
+	}, nil
+}
+
+func mapDetailBranch(branch *models.Branch) *dto.GetDetailBranchResponse {
+	return &dto.GetDetailBranchResponse{
+		ID:        branch.ID.String(),
+		Code:      branch.Code,
+		Name:      branch.Name,
+		Address:   helpers.SafeString(branch.Address),
+		CreatedAt: branch.CreatedAt.Format(constants.DateTimeFormat),
+		UpdatedAt: branch.UpdatedAt.Format(constants.DateTimeFormat),
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/hilmiikhsan/library-book-service/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// LocalStorage keeps objects as files below BasePath, keys use forward slashes.
+type LocalStorage struct {
+	BasePath string
+	Logger   *logrus.Logger
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path := s.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		s.Logger.Error("storage::Put - failed to create directory: ", err)
+		return err
+	}
+
+	// write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		s.Logger.Error("storage::Put - failed to create temporary file: ", err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		s.Logger.Error("storage::Put - failed to write file: ", err)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		s.Logger.Error("storage::Put - failed to close file: ", err)
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		s.Logger.Error("storage::Put - failed to rename file: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errors.New(constants.ErrFileNotFound)
+		}
+
+		s.Logger.Error("storage::Get - failed to read file: ", err)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// path keeps every key inside BasePath, even one containing "..".
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.BasePath, filepath.FromSlash(filepath.Clean("/"+key)))
+}
@@ -8,6 +8,7 @@ import (
 	// ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	// en_translations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/hilmiikhsan/library-book-service/helpers"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -66,6 +67,12 @@ func NewValidator() *Validator {
 	if err := v.RegisterValidation("unique_in_slice", isUniqueInSlice); err != nil {
 		log.Fatal("validator::NewValidator Error while registering unique_in_slice validator")
 	}
+	if err := v.RegisterValidation("valid_isbn", isValidIsbn); err != nil {
+		log.Fatal("validator::NewValidator Error while registering valid_isbn validator")
+	}
+	if err := v.RegisterValidation("valid_language", isValidLanguage); err != nil {
+		log.Fatal("validator::NewValidator Error while registering valid_language validator")
+	}
 
 	validatorCustom.validator = v
 	// validatorCustom.trans = trans
@@ -135,3 +142,13 @@ func isUniqueInSlice(fl validator.FieldLevel) bool {
 	}
 	return true
 }
+
+// isbn validator, accepts ISBN-10 and ISBN-13 with or without hyphens
+func isValidIsbn(fl validator.FieldLevel) bool {
+	return helpers.IsValidIsbn(fl.Field().String())
+}
+
+// language validator, accepts ISO 639-1 codes in any case
+func isValidLanguage(fl validator.FieldLevel) bool {
+	return helpers.IsValidLanguage(fl.Field().String())
+}
@@ -24,6 +24,12 @@ func main() {
 	// Setup Redis connection
 	helpers.SetupRedis()
 
+	// Run one-off commands instead of the servers
+	if len(os.Args) > 1 && os.Args[1] == "reconcile-stock" {
+		cmd.RunReconcileStock(os.Args[2:])
+		return
+	}
+
 	// WaitGroup to manage goroutines
 	var wg sync.WaitGroup
 
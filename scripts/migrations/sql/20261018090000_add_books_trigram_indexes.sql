@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram index for typo tolerant title search and title suggestions
+CREATE INDEX IF NOT EXISTS idx_books_title_trgm
+ON books USING gin (title gin_trgm_ops);
+
+-- Pattern index for isbn prefix suggestions
+CREATE INDEX IF NOT EXISTS idx_books_isbn_pattern
+ON books (isbn varchar_pattern_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_books_isbn_pattern;
+DROP INDEX IF EXISTS idx_books_title_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,119 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION normalize_isbn(raw TEXT) RETURNS TEXT AS $$
+DECLARE
+    digits TEXT := upper(regexp_replace(raw, '[^0-9Xx]', '', 'g'));
+    total INT := 0;
+    check_char TEXT;
+BEGIN
+    IF length(digits) = 10 THEN
+        IF substr(digits, 1, 9) !~ '^[0-9]{9}$' THEN
+            RETURN NULL;
+        END IF;
+
+        FOR i IN 1..9 LOOP
+            total := total + (11 - i) * substr(digits, i, 1)::INT;
+        END LOOP;
+
+        check_char := substr(digits, 10, 1);
+        total := total + CASE WHEN check_char = 'X' THEN 10 ELSE check_char::INT END;
+
+        IF total % 11 <> 0 THEN
+            RETURN NULL;
+        END IF;
+
+        -- convert to ISBN-13 with the 978 prefix and a recomputed check digit
+        digits := '978' || substr(digits, 1, 9);
+        total := 0;
+        FOR i IN 1..12 LOOP
+            total := total + substr(digits, i, 1)::INT * CASE WHEN i % 2 = 0 THEN 3 ELSE 1 END;
+        END LOOP;
+
+        RETURN digits || ((10 - total % 10) % 10)::TEXT;
+    ELSIF digits ~ '^97[89][0-9]{10}$' THEN
+        FOR i IN 1..13 LOOP
+            total := total + substr(digits, i, 1)::INT * CASE WHEN i % 2 = 0 THEN 3 ELSE 1 END;
+        END LOOP;
+
+        IF total % 10 <> 0 THEN
+            RETURN NULL;
+        END IF;
+
+        RETURN digits;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Report of every row the normalization touched or could not touch:
+-- normalized = rewritten to ISBN-13, collision = several books share the same
+-- canonical ISBN and were left as is, invalid = checksum or format is wrong
+CREATE TABLE IF NOT EXISTS book_isbn_normalizations (
+    book_id UUID PRIMARY KEY,
+    original_isbn VARCHAR(20) NOT NULL,
+    normalized_isbn VARCHAR(20),
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+WITH normalized AS (
+    SELECT id, isbn, normalize_isbn(isbn) AS normalized_isbn
+    FROM books
+), collisions AS (
+    SELECT normalized_isbn
+    FROM normalized
+    WHERE normalized_isbn IS NOT NULL
+    GROUP BY normalized_isbn
+    HAVING COUNT(*) > 1
+)
+INSERT INTO book_isbn_normalizations (book_id, original_isbn, normalized_isbn, status)
+SELECT
+    n.id,
+    n.isbn,
+    n.normalized_isbn,
+    CASE
+        WHEN n.normalized_isbn IS NULL THEN 'invalid'
+        WHEN c.normalized_isbn IS NOT NULL THEN 'collision'
+        ELSE 'normalized'
+    END
+FROM normalized n
+LEFT JOIN collisions c ON c.normalized_isbn = n.normalized_isbn
+WHERE n.normalized_isbn IS NULL
+    OR n.normalized_isbn <> n.isbn
+    OR c.normalized_isbn IS NOT NULL;
+
+UPDATE books b
+SET
+    isbn = l.normalized_isbn,
+    updated_at = NOW()
+FROM book_isbn_normalizations l
+WHERE l.book_id = b.id AND l.status = 'normalized';
+
+DO $$
+DECLARE
+    collision_count INT;
+    invalid_count INT;
+BEGIN
+    SELECT COUNT(*) INTO collision_count FROM book_isbn_normalizations WHERE status = 'collision';
+    SELECT COUNT(*) INTO invalid_count FROM book_isbn_normalizations WHERE status = 'invalid';
+
+    IF collision_count > 0 OR invalid_count > 0 THEN
+        RAISE WARNING 'isbn normalization skipped % colliding and % invalid books, see book_isbn_normalizations', collision_count, invalid_count;
+    END IF;
+END $$;
+
+DROP FUNCTION IF EXISTS normalize_isbn(TEXT);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE books b
+SET
+    isbn = l.original_isbn,
+    updated_at = NOW()
+FROM book_isbn_normalizations l
+WHERE l.book_id = b.id AND l.status = 'normalized';
+
+DROP TABLE IF EXISTS book_isbn_normalizations;
+-- +goose StatementEnd
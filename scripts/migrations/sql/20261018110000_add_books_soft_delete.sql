@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS deleted_by UUID NULL;
+
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- loan history must outlive the book, so hard deleting a book with loans is refused
+ALTER TABLE borrowed_books DROP CONSTRAINT IF EXISTS fk_borrowed_books_book;
+ALTER TABLE borrowed_books ADD CONSTRAINT fk_borrowed_books_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE RESTRICT ON UPDATE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE borrowed_books DROP CONSTRAINT IF EXISTS fk_borrowed_books_book;
+ALTER TABLE borrowed_books ADD CONSTRAINT fk_borrowed_books_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+DROP INDEX IF EXISTS idx_books_deleted_at;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
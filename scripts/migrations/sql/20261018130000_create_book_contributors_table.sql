@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_contributors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    book_id UUID NOT NULL,
+    author_id UUID NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    position INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_book_contributors_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT chk_book_contributors_role CHECK (role IN ('author', 'co-author', 'editor', 'translator', 'illustrator')),
+    CONSTRAINT uq_book_contributors_position UNIQUE (book_id, position),
+    CONSTRAINT uq_book_contributors_author_role UNIQUE (book_id, author_id, role)
+);
+
+CREATE INDEX idx_book_contributors_author_id ON book_contributors (author_id);
+
+-- the primary author_id is always the first contributor
+INSERT INTO book_contributors (book_id, author_id, role, position)
+SELECT id, author_id, 'author', 0 FROM books;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS book_contributors;
+-- +goose StatementEnd
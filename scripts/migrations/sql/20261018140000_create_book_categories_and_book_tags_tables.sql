@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_categories (
+    book_id UUID NOT NULL,
+    category_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (book_id, category_id),
+    CONSTRAINT fk_book_categories_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_book_categories_category_id ON book_categories (category_id);
+
+-- the primary category_id is always one of the book categories
+INSERT INTO book_categories (book_id, category_id)
+SELECT id, category_id FROM books;
+
+CREATE TABLE IF NOT EXISTS book_tags (
+    book_id UUID NOT NULL,
+    tag VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (book_id, tag),
+    CONSTRAINT fk_book_tags_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_book_tags_tag ON book_tags (tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS book_tags;
+DROP TABLE IF EXISTS book_categories;
+-- +goose StatementEnd
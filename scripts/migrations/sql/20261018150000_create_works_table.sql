@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS works (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- books without a work are standalone editions
+ALTER TABLE books
+    ADD COLUMN work_id UUID NULL,
+    ADD COLUMN edition VARCHAR(100) NULL,
+    ADD COLUMN format VARCHAR(20) NULL,
+    ADD COLUMN publisher VARCHAR(255) NULL,
+    ADD CONSTRAINT fk_books_work FOREIGN KEY (work_id) REFERENCES works (id) ON DELETE SET NULL ON UPDATE CASCADE,
+    ADD CONSTRAINT chk_books_format CHECK (format IN ('hardcover', 'paperback', 'ebook', 'audio'));
+
+CREATE INDEX idx_books_work_id ON books (work_id) WHERE work_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_books_work_id;
+
+ALTER TABLE books
+    DROP CONSTRAINT IF EXISTS chk_books_format,
+    DROP CONSTRAINT IF EXISTS fk_books_work,
+    DROP COLUMN IF EXISTS publisher,
+    DROP COLUMN IF EXISTS format,
+    DROP COLUMN IF EXISTS edition,
+    DROP COLUMN IF EXISTS work_id;
+
+DROP TABLE IF EXISTS works;
+-- +goose StatementEnd
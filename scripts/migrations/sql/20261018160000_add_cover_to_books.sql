@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- cover variants live in object storage under covers/<book id>/, this marks when they were last written
+ALTER TABLE books ADD COLUMN cover_updated_at TIMESTAMP NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE books DROP COLUMN IF EXISTS cover_updated_at;
+-- +goose StatementEnd
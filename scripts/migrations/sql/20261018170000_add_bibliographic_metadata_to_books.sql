@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- language holds an ISO 639-1 code, edition, format and publisher were added with works
+ALTER TABLE books
+    ADD COLUMN subtitle VARCHAR(255) NULL,
+    ADD COLUMN original_title VARCHAR(255) NULL,
+    ADD COLUMN language VARCHAR(2) NULL,
+    ADD COLUMN page_count INT NULL,
+    ADD CONSTRAINT chk_books_page_count CHECK (page_count > 0);
+
+CREATE INDEX idx_books_language ON books (language) WHERE deleted_at IS NULL;
+CREATE INDEX idx_books_format ON books (format) WHERE deleted_at IS NULL;
+CREATE INDEX idx_books_publisher_lower ON books (lower(publisher)) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_books_publisher_lower;
+DROP INDEX IF EXISTS idx_books_format;
+DROP INDEX IF EXISTS idx_books_language;
+
+ALTER TABLE books
+    DROP CONSTRAINT IF EXISTS chk_books_page_count,
+    DROP COLUMN IF EXISTS page_count,
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS original_title,
+    DROP COLUMN IF EXISTS subtitle;
+-- +goose StatementEnd
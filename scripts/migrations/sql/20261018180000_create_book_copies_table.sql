@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_copies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    book_id UUID NOT NULL,
+    barcode VARCHAR(64) NOT NULL,
+    accession_number VARCHAR(64) NOT NULL,
+    acquisition_date DATE NULL,
+    shelf_location VARCHAR(100) NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'available',
+    withdrawn_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INT NOT NULL DEFAULT 1,
+    CONSTRAINT fk_book_copies_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT chk_book_copies_status CHECK (status IN ('available', 'on_loan', 'in_repair', 'lost', 'withdrawn')),
+    CONSTRAINT uq_book_copies_barcode UNIQUE (barcode),
+    CONSTRAINT uq_book_copies_accession_number UNIQUE (accession_number)
+);
+
+CREATE INDEX idx_book_copies_book_id_status ON book_copies (book_id, status);
+
+-- loans made before copies were tracked keep a NULL copy
+ALTER TABLE borrowed_books
+    ADD COLUMN copy_id UUID NULL,
+    ADD CONSTRAINT fk_borrowed_books_copy FOREIGN KEY (copy_id) REFERENCES book_copies (id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE UNIQUE INDEX uq_borrowed_books_active_copy ON borrowed_books (copy_id) WHERE returned_date IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS uq_borrowed_books_active_copy;
+
+ALTER TABLE borrowed_books
+    DROP CONSTRAINT IF EXISTS fk_borrowed_books_copy,
+    DROP COLUMN IF EXISTS copy_id;
+
+DROP TABLE IF EXISTS book_copies;
+-- +goose StatementEnd
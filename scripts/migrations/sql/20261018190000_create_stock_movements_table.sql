@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- no foreign keys, the history of a stock row outlives the row itself
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    book_stock_id UUID NOT NULL,
+    book_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    total_delta INT NOT NULL DEFAULT 0,
+    available_delta INT NOT NULL DEFAULT 0,
+    reason VARCHAR(255) NULL,
+    actor_id UUID NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_stock_movements_type CHECK (type IN ('acquisition', 'loan', 'return', 'write_off', 'correction')),
+    CONSTRAINT chk_stock_movements_delta CHECK (total_delta <> 0 OR available_delta <> 0)
+);
+
+CREATE INDEX idx_stock_movements_book_stock_id_created_at ON stock_movements (book_stock_id, created_at DESC, id DESC);
+CREATE INDEX idx_stock_movements_book_id ON stock_movements (book_id);
+
+CREATE OR REPLACE FUNCTION reject_stock_movement_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION reject_stock_movement_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+DROP FUNCTION IF EXISTS reject_stock_movement_change();
+DROP TABLE IF EXISTS stock_movements;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- NOT VALID enforces the constraints on every new write without failing on rows
+-- that already drifted, fix those with reconcile-stock and validate afterwards
+ALTER TABLE book_stocks
+    ADD CONSTRAINT chk_book_stocks_total_stock CHECK (total_stock >= 0) NOT VALID,
+    ADD CONSTRAINT chk_book_stocks_available_stock CHECK (available_stock >= 0 AND available_stock <= total_stock) NOT VALID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE book_stocks
+    DROP CONSTRAINT IF EXISTS chk_book_stocks_available_stock,
+    DROP CONSTRAINT IF EXISTS chk_book_stocks_total_stock;
+-- +goose StatementEnd
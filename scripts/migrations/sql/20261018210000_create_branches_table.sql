@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS branches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    address VARCHAR(255) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_branches_code UNIQUE (code)
+);
+
+-- stock, copies and loans recorded before branches belong to the main branch
+INSERT INTO branches (code, name) VALUES ('MAIN', 'Main Branch');
+
+ALTER TABLE book_stocks ADD COLUMN branch_id UUID NULL;
+UPDATE book_stocks SET branch_id = (SELECT id FROM branches WHERE code = 'MAIN');
+ALTER TABLE book_stocks
+    ALTER COLUMN branch_id SET NOT NULL,
+    ADD CONSTRAINT fk_book_stocks_branch FOREIGN KEY (branch_id) REFERENCES branches (id) ON UPDATE CASCADE;
+
+-- one stock row per book and branch
+CREATE UNIQUE INDEX uq_book_stocks_book_id_branch_id ON book_stocks (book_id, branch_id);
+
+ALTER TABLE book_copies ADD COLUMN branch_id UUID NULL;
+UPDATE book_copies SET branch_id = (SELECT id FROM branches WHERE code = 'MAIN');
+ALTER TABLE book_copies
+    ALTER COLUMN branch_id SET NOT NULL,
+    ADD CONSTRAINT fk_book_copies_branch FOREIGN KEY (branch_id) REFERENCES branches (id) ON UPDATE CASCADE;
+
+CREATE INDEX idx_book_copies_book_id_branch_id_status ON book_copies (book_id, branch_id, status);
+
+-- returned_branch_id differs from branch_id when a book is returned elsewhere
+ALTER TABLE borrowed_books
+    ADD COLUMN branch_id UUID NULL,
+    ADD COLUMN returned_branch_id UUID NULL;
+UPDATE borrowed_books SET branch_id = (SELECT id FROM branches WHERE code = 'MAIN');
+UPDATE borrowed_books SET returned_branch_id = branch_id WHERE returned_date IS NOT NULL;
+ALTER TABLE borrowed_books
+    ALTER COLUMN branch_id SET NOT NULL,
+    ADD CONSTRAINT fk_borrowed_books_branch FOREIGN KEY (branch_id) REFERENCES branches (id) ON UPDATE CASCADE,
+    ADD CONSTRAINT fk_borrowed_books_returned_branch FOREIGN KEY (returned_branch_id) REFERENCES branches (id) ON UPDATE CASCADE;
+
+CREATE INDEX idx_borrowed_books_book_id_branch_id ON borrowed_books (book_id, branch_id) WHERE returned_date IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_borrowed_books_book_id_branch_id;
+
+ALTER TABLE borrowed_books
+    DROP CONSTRAINT IF EXISTS fk_borrowed_books_returned_branch,
+    DROP CONSTRAINT IF EXISTS fk_borrowed_books_branch,
+    DROP COLUMN IF EXISTS returned_branch_id,
+    DROP COLUMN IF EXISTS branch_id;
+
+DROP INDEX IF EXISTS idx_book_copies_book_id_branch_id_status;
+
+ALTER TABLE book_copies
+    DROP CONSTRAINT IF EXISTS fk_book_copies_branch,
+    DROP COLUMN IF EXISTS branch_id;
+
+DROP INDEX IF EXISTS uq_book_stocks_book_id_branch_id;
+
+ALTER TABLE book_stocks
+    DROP CONSTRAINT IF EXISTS fk_book_stocks_branch,
+    DROP COLUMN IF EXISTS branch_id;
+
+DROP TABLE IF EXISTS branches;
+-- +goose StatementEnd
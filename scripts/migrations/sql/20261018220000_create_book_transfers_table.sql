@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    book_id UUID NOT NULL,
+    source_branch_id UUID NOT NULL,
+    destination_branch_id UUID NOT NULL,
+    quantity INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested',
+    requested_by UUID NULL,
+    shipped_by UUID NULL,
+    received_by UUID NULL,
+    shipped_at TIMESTAMP NULL,
+    received_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_book_transfers_book FOREIGN KEY (book_id) REFERENCES books (id) ON UPDATE CASCADE,
+    CONSTRAINT fk_book_transfers_source_branch FOREIGN KEY (source_branch_id) REFERENCES branches (id) ON UPDATE CASCADE,
+    CONSTRAINT fk_book_transfers_destination_branch FOREIGN KEY (destination_branch_id) REFERENCES branches (id) ON UPDATE CASCADE,
+    CONSTRAINT chk_book_transfers_status CHECK (status IN ('requested', 'in_transit', 'received')),
+    CONSTRAINT chk_book_transfers_quantity CHECK (quantity > 0),
+    CONSTRAINT chk_book_transfers_branches CHECK (source_branch_id <> destination_branch_id)
+);
+
+CREATE INDEX idx_book_transfers_source_branch_id_status ON book_transfers (source_branch_id, status);
+CREATE INDEX idx_book_transfers_destination_branch_id_status ON book_transfers (destination_branch_id, status);
+
+-- the copies a transfer of a book with copies took off the shelf when it shipped
+CREATE TABLE IF NOT EXISTS book_transfer_copies (
+    book_transfer_id UUID NOT NULL,
+    copy_id UUID NOT NULL,
+    PRIMARY KEY (book_transfer_id, copy_id),
+    CONSTRAINT fk_book_transfer_copies_transfer FOREIGN KEY (book_transfer_id) REFERENCES book_transfers (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_book_transfer_copies_copy FOREIGN KEY (copy_id) REFERENCES book_copies (id) ON UPDATE CASCADE
+);
+
+ALTER TABLE book_copies
+    DROP CONSTRAINT IF EXISTS chk_book_copies_status,
+    ADD CONSTRAINT chk_book_copies_status CHECK (status IN ('available', 'on_loan', 'in_repair', 'in_transit', 'lost', 'withdrawn'));
+
+ALTER TABLE stock_movements
+    DROP CONSTRAINT IF EXISTS chk_stock_movements_type,
+    ADD CONSTRAINT chk_stock_movements_type CHECK (type IN ('acquisition', 'loan', 'return', 'transfer', 'write_off', 'correction'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_movements
+    DROP CONSTRAINT IF EXISTS chk_stock_movements_type,
+    ADD CONSTRAINT chk_stock_movements_type CHECK (type IN ('acquisition', 'loan', 'return', 'write_off', 'correction')) NOT VALID;
+
+ALTER TABLE book_copies
+    DROP CONSTRAINT IF EXISTS chk_book_copies_status,
+    ADD CONSTRAINT chk_book_copies_status CHECK (status IN ('available', 'on_loan', 'in_repair', 'lost', 'withdrawn')) NOT VALID;
+
+DROP TABLE IF EXISTS book_transfer_copies;
+DROP TABLE IF EXISTS book_transfers;
+-- +goose StatementEnd